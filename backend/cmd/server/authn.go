@@ -0,0 +1,78 @@
+package main
+
+import (
+	"dashboard/internal/auth"
+	"dashboard/internal/config"
+	"dashboard/internal/database"
+)
+
+// buildAuthenticator собирает цепочку источников учётных записей: сначала
+// каталог LDAP (если задан LDAP_URL), затем локальные пользователи из БД,
+// а без БД - резервный логин из LOGIN_USER/LOGIN_PASSWORD
+func buildAuthenticator(cfg *config.Config, users *database.UserStore) (auth.Chain, error) {
+	var chain auth.Chain
+
+	if cfg.LDAPURL != "" {
+		mappings, err := auth.ParseGroupMappings(cfg.LDAPGroupRoles)
+		if err != nil {
+			return nil, err
+		}
+		var external auth.ExternalUsers
+		if users.Available() {
+			external = users
+		}
+		ldapAuth, err := auth.NewLDAPAuthenticator(auth.LDAPConfig{
+			URL:                cfg.LDAPURL,
+			StartTLS:           cfg.LDAPStartTLS,
+			InsecureSkipVerify: cfg.LDAPInsecureSkipVerify,
+			BindDN:             cfg.LDAPBindDN,
+			BindPassword:       cfg.LDAPBindPassword,
+			BaseDN:             cfg.LDAPBaseDN,
+			UserFilter:         cfg.LDAPUserFilter,
+			GroupAttribute:     cfg.LDAPGroupAttribute,
+			Mappings:           mappings,
+		}, external)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, ldapAuth)
+		logger.Info("Вход через LDAP", "url", cfg.LDAPURL, "mappings", len(mappings))
+	}
+
+	if users.Available() {
+		chain = append(chain, auth.NewLocalAuthenticator(users))
+	} else if cfg.LoginPassword != "" {
+		chain = append(chain, auth.NewStaticAuthenticator(cfg.LoginUser, cfg.LoginPassword, cfg.LoginRole))
+	}
+	return chain, nil
+}
+
+// buildOIDC создаёт клиента OpenID Connect, если задан OIDC_ISSUER
+func buildOIDC(cfg *config.Config, users *database.UserStore) (*auth.OIDCProvider, error) {
+	if cfg.OIDCIssuer == "" {
+		return nil, nil
+	}
+	mappings, err := auth.ParseGroupMappings(cfg.OIDCClaimRoles)
+	if err != nil {
+		return nil, err
+	}
+	var external auth.ExternalUsers
+	if users.Available() {
+		external = users
+	}
+	provider, err := auth.NewOIDCProvider(auth.OIDCConfig{
+		Issuer:        cfg.OIDCIssuer,
+		ClientID:      cfg.OIDCClientID,
+		ClientSecret:  cfg.OIDCClientSecret,
+		RedirectURL:   cfg.OIDCRedirectURL,
+		Scopes:        cfg.OIDCScopes,
+		UsernameClaim: cfg.OIDCUsernameClaim,
+		RoleClaim:     cfg.OIDCRoleClaim,
+		Mappings:      mappings,
+	}, external)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Вход через OIDC", "issuer", cfg.OIDCIssuer, "mappings", len(mappings))
+	return provider, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"dashboard/internal/auth"
+	"dashboard/internal/config"
+	"dashboard/internal/database"
+	"dashboard/internal/models"
+)
+
+// runBootstrapAdmin создаёт первого администратора:
+//
+//	server bootstrap-admin -username admin
+//
+// Пароль берётся из -password, переменной BOOTSTRAP_ADMIN_PASSWORD
+// или читается из стандартного ввода.
+func runBootstrapAdmin(args []string) error {
+	fs := flag.NewFlagSet("bootstrap-admin", flag.ContinueOnError)
+	username := fs.String("username", "admin", "логин администратора")
+	password := fs.String("password", "", "пароль (лучше передать через BOOTSTRAP_ADMIN_PASSWORD или stdin)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	pass := *password
+	if pass == "" {
+		pass = os.Getenv("BOOTSTRAP_ADMIN_PASSWORD")
+	}
+	if pass == "" {
+		fmt.Fprint(os.Stderr, "Пароль: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("ошибка чтения пароля: %v", err)
+		}
+		pass = strings.TrimRight(line, "\r\n")
+	}
+	if err := auth.ValidatePassword(pass); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg.DatabaseURL == "" {
+		return fmt.Errorf("DATABASE_URL не указан: пользователи хранятся в БД")
+	}
+	if err := database.Connect(cfg.DatabaseURL); err != nil {
+		return err
+	}
+	defer database.Close()
+	if err := database.InitSchema(); err != nil {
+		return err
+	}
+
+	hash, err := auth.HashPassword(pass)
+	if err != nil {
+		return err
+	}
+	user, err := database.NewUserStore(database.DB).Create(models.User{
+		Username:     *username,
+		PasswordHash: hash,
+		Role:         models.RoleAdmin,
+	})
+	if errors.Is(err, database.ErrDuplicate) {
+		return fmt.Errorf("пользователь %s уже существует", *username)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Администратор %s создан (id %d)\n", user.Username, user.ID)
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"dashboard/internal/config"
+)
+
+// runConfig выполняет команды конфигурации:
+//
+//	server config check [-file config.yaml]
+//
+// check загружает файл и окружение так же, как при запуске, и выводит
+// все ошибки сразу. Код выхода 1, если сервер с такой конфигурацией не запустится.
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "check" {
+		return fmt.Errorf("использование: server config check [-file config.yaml]")
+	}
+	fs := flag.NewFlagSet("config check", flag.ContinueOnError)
+	file := fs.String("file", os.Getenv("CONFIG_FILE"), "файл конфигурации (по умолчанию config.yaml в корне проекта)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadFile(*file)
+	if err != nil {
+		return err
+	}
+
+	source := cfg.File
+	if source == "" {
+		source = "только переменные окружения"
+	}
+	fmt.Printf("Конфигурация корректна (%s)\n", source)
+	fmt.Printf("  окружение:     %s\n", cfg.Env)
+	fmt.Printf("  адрес:         %s:%s\n", cfg.ServerHost, cfg.ServerPort)
+	fmt.Printf("  обновление:    %s (%s)\n", strings.Join(cfg.RefreshSchedules, "; "), cfg.RefreshTimezone)
+	if len(cfg.RefreshQuietHours) > 0 {
+		fmt.Printf("  окна тишины:   %s\n", strings.Join(cfg.RefreshQuietHours, ", "))
+	}
+	fmt.Printf("  посещаемость:  %s -> %s\n", cfg.AttendanceInput, cfg.AttendanceOutput)
+	fmt.Printf("  ведомость:     %s -> %s\n", cfg.StatementInput, cfg.StatementOutput)
+	for _, w := range cfg.Warnings() {
+		fmt.Printf("Предупреждение: %s\n", w)
+	}
+	return nil
+}
@@ -3,24 +3,44 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"dashboard/internal/api"
+	"dashboard/internal/audit"
+	"dashboard/internal/auth"
 	"dashboard/internal/config"
 	"dashboard/internal/database"
+	"dashboard/internal/events"
+	"dashboard/internal/health"
+	"dashboard/internal/logging"
+	"dashboard/internal/metrics"
 	"dashboard/internal/middleware"
+	"dashboard/internal/models"
+	"dashboard/internal/notify"
+	"dashboard/internal/quiet"
+	"dashboard/internal/ratelimit"
 	"dashboard/internal/scheduler"
 	"dashboard/internal/services"
+	"dashboard/internal/tracing"
+	"dashboard/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 	"github.com/robfig/cron/v3"
 )
 
+var logger = logging.For("server")
+
+// fatal пишет ошибку в лог и завершает процесс
+func fatal(msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
+
 // @title Dashboard Backend API
 // @version 1.0
 // @description API для управления дашбордом посещаемости студентов
@@ -32,15 +52,54 @@ import (
 // @host localhost:8080
 // @BasePath /api
 func main() {
+	// Служебные команды
+	if len(os.Args) > 1 && os.Args[1] == "bootstrap-admin" {
+		if err := runBootstrapAdmin(os.Args[2:]); err != nil {
+			fatal("Ошибка создания администратора", "error", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfig(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Загружаем конфигурацию
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("[Server] Ошибка загрузки конфигурации: %v", err)
+		fatal("Ошибка загрузки конфигурации", "error", err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+		fatal("Ошибка настройки логов", "error", err)
 	}
 
-	log.Printf("[Server] Запуск бэкенд сервера...")
-	log.Printf("[Server] Корневая директория: %s", cfg.ProjectRoot)
-	log.Printf("[Server] Интервал обновления: %v", cfg.RefreshInterval)
+	// Трассировка OpenTelemetry (TRACING_ENABLED)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Enabled:     cfg.TracingEnabled,
+		Endpoint:    cfg.TracingEndpoint,
+		Insecure:    cfg.TracingInsecure,
+		SampleRatio: cfg.TracingSampleRatio,
+		ServiceName: "dashboard-backend",
+		Environment: cfg.Env,
+	})
+	if err != nil {
+		fatal("Ошибка настройки трассировки", "error", err)
+	}
+	if cfg.TracingEnabled {
+		logger.Info("Трассировка включена", "endpoint", cfg.TracingEndpoint, "sample_ratio", cfg.TracingSampleRatio)
+	}
+
+	logger.Info("Запуск бэкенд сервера",
+		"root", cfg.ProjectRoot,
+		"config", cfg.File,
+		"refresh_schedule", strings.Join(cfg.RefreshSchedules, "; "),
+		"timezone", cfg.RefreshTimezone)
+	for _, w := range cfg.Warnings() {
+		logger.Warn(w)
+	}
 
 	// Устанавливаем режим работы Gin (release для продакшена)
 	gin.SetMode(gin.ReleaseMode)
@@ -48,18 +107,18 @@ func main() {
 	// Подключаемся к БД
 	if cfg.DatabaseURL != "" {
 		if err := database.Connect(cfg.DatabaseURL); err != nil {
-			log.Printf("[Server] Предупреждение: не удалось подключиться к БД: %v", err)
-			log.Println("[Server] Продолжаем работу без БД (данные не будут сохраняться)")
+			logger.Warn("Не удалось подключиться к БД, продолжаем работу без БД (данные не будут сохраняться)", "error", err)
 		} else {
 			// Инициализируем схему БД
 			if err := database.InitSchema(); err != nil {
-				log.Printf("[Server] Предупреждение: не удалось инициализировать схему БД: %v", err)
+				logger.Warn("Не удалось инициализировать схему БД", "error", err)
 			}
 			// Закрываем подключение при завершении
 			defer database.Close()
+			metrics.RegisterDB(database.DB)
 		}
 	} else {
-		log.Println("[Server] DATABASE_URL не указан, работаем без БД")
+		logger.Info("DATABASE_URL не указан, работаем без БД")
 	}
 
 	// Инициализируем загрузчик БД
@@ -75,40 +134,261 @@ func main() {
 		cfg.PythonScript,
 	)
 
+	// Единый исполнитель задач обновления для cron и API
+	runner := scheduler.NewRunner(sched, dbLoader, cfg.AttendanceOutput, cfg.StatementOutput)
+	// История обновлений в БД переживает перезапуск, в том числе задачи,
+	// прерванные остановкой сервера
+	if refreshJobStore := database.NewRefreshJobStore(database.DB); refreshJobStore.Available() {
+		if err := runner.UseHistory(context.Background(), refreshJobStore); err != nil {
+			logger.Warn("История обновлений не загружена", "error", err)
+		}
+	}
+
+	// Брокер событий для SSE: ход обновления, новые данные, алерты
+	broker := events.NewBroker()
+	runner.AddListener(broker.RefreshListener())
+
+	// Плановое обновление по cron-расписанию
+	scheduleCfg, err := scheduleConfig(cfg)
+	if err != nil {
+		fatal("Ошибка настройки расписания обновления", "error", err)
+	}
+	refreshSchedule, err := scheduler.NewSchedule(runner, scheduleCfg)
+	if err != nil {
+		fatal("Ошибка настройки расписания обновления", "error", err)
+	}
+
 	// Инициализируем сервисы
 	attendanceService := services.NewAttendanceService(cfg.AttendanceOutput)
+	alertStore := database.NewAlertStore(database.DB)
+	ruleStore := database.NewRuleStore(database.DB)
+	alertService := services.NewAlertService(alertStore, ruleStore, cfg.StatementOutput, cfg.AbsenceThreshold)
+	alertService.AddListener(func(a models.Alert) {
+		broker.Publish(events.AlertCreated, a)
+		metrics.AlertCreated(a.Rule, a.Severity)
+	})
+
+	// Проверки готовности: БД и схема, снимки данных, входные файлы, планировщик
+	healthChecker := health.NewChecker(health.Config{
+		DatabaseConfigured: cfg.DatabaseURL != "",
+		DB:                 database.DB,
+		SchemaCheck:        database.MissingTables,
+		Snapshots: []health.File{
+			{Name: "attendance", Path: cfg.AttendanceOutput, Required: true},
+			{Name: "statement", Path: cfg.StatementOutput},
+		},
+		Inputs: []health.File{
+			{Name: "attendance", Path: cfg.AttendanceInput},
+			{Name: "statement", Path: cfg.StatementInput},
+		},
+		Runner:   runner,
+		Schedule: refreshSchedule,
+	}, healthThresholds(cfg))
+
+	// CORS и заголовки безопасности
+	corsPolicy, err := middleware.NewCORS(cfg.CORSOrigins, cfg.CORSAllowCredentials, cfg.CORSMaxAge)
+	if err != nil {
+		fatal("Ошибка настройки CORS", "error", err)
+	}
+	securityHeaders := middleware.NewSecurityHeaders(securityPolicy(cfg))
+
+	// Безопасные настройки (порог алертов, расписание, CORS, заголовки) перечитываются по SIGHUP
+	cfgWatcher := config.NewWatcher(cfg, config.Load)
+	cfgWatcher.OnReload(func(c *config.Config) {
+		alertService.SetThreshold(c.AbsenceThreshold)
+		healthChecker.SetThresholds(healthThresholds(c))
+		if err := logging.SetLevel(c.LogLevel); err != nil {
+			logger.Warn("Уровень логов не обновлён", "error", err)
+		}
+		if sc, err := scheduleConfig(c); err != nil {
+			logger.Warn("Расписание не обновлено", "error", err)
+		} else if err := refreshSchedule.Update(sc); err != nil {
+			logger.Warn("Расписание не обновлено", "error", err)
+		}
+		if err := corsPolicy.SetOrigins(c.CORSOrigins); err != nil {
+			logger.Warn("CORS не обновлён", "error", err)
+		}
+		securityHeaders.SetPolicy(securityPolicy(c))
+	})
+	go watchReload(cfgWatcher)
+
+	// Email уведомления об алертах и еженедельные сводки
+	notificationStore := database.NewNotificationStore(database.DB)
+	var notifier *notify.Notifier
+	if cfg.SMTPHost != "" {
+		sender := notify.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPassword, cfg.SMTPFrom)
+		notifier = notify.NewNotifier(sender, notificationStore, func() ([]models.Alert, error) {
+			open, err := alertStore.List(database.AlertFilter{Status: models.AlertOpen, Limit: 1000})
+			if err != nil {
+				return nil, err
+			}
+			acked, err := alertStore.List(database.AlertFilter{Status: models.AlertAcknowledged, Limit: 1000})
+			if err != nil {
+				return nil, err
+			}
+			return append(open, acked...), nil
+		}, cfg.DashboardURL)
+		notifier.Start()
+		defer notifier.Stop()
+		alertService.AddListener(notifier.Enqueue)
+		logger.Info("Email уведомления включены", "smtp", cfg.SMTPHost+":"+cfg.SMTPPort)
+	} else {
+		logger.Info("SMTP_HOST не указан, email уведомления отключены")
+	}
+
+	// После успешного обновления подменяем снимок данных в памяти
+	// и один раз проверяем алерты по новым данным
+	runner.AddListener(func(event string, job scheduler.Job) {
+		if event != scheduler.EventJobFinished || job.Status != scheduler.JobSuccess {
+			return
+		}
+		ctx := logging.WithJobID(context.Background(), job.ID)
+		if job.RequestID != "" {
+			ctx = logging.WithRequestID(ctx, job.RequestID)
+		}
+		snap, changed, err := attendanceService.Reload(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "Ошибка загрузки снимка посещаемости", "error", err)
+			return
+		}
+		if changed {
+			broker.Publish(events.SnapshotActive, snap.Info())
+		}
+
+		if _, err := alertService.Evaluate(ctx, snap); err != nil {
+			logger.WarnContext(ctx, "Ошибка проверки алертов", "error", err)
+		}
+	})
+
+	// Webhooks: внешние системы получают события обновления и алертов
+	webhookStore := database.NewWebhookStore(database.DB)
+	dispatcher := webhooks.NewDispatcher(webhookStore)
+	if database.DB != nil {
+		dispatcher.Listen(broker)
+	}
+	defer dispatcher.Stop()
 
 	// Инициализируем handlers
-	ginHandler := api.NewGinHandler(sched, dbLoader)
-	authHandler := api.NewAuthHandler(cfg)
-	dashboardHandler := api.NewDashboardHandler(attendanceService, cfg.AbsenceThreshold)
+	ginHandler := api.NewGinHandler(runner, refreshSchedule)
+	healthHandler := api.NewHealthHandler(healthChecker)
+	userStore := database.NewUserStore(database.DB)
+	if !userStore.Available() {
+		logger.Warn("БД недоступна, локальные учётные записи заменены входом по LOGIN_USER/LOGIN_PASSWORD")
+	}
+
+	// Ключи подписи JWT
+	jwtKeys, err := auth.NewKeySet(cfg.JWTKeys, cfg.JWTActiveKID, cfg.JWTIssuer, cfg.JWTAudience)
+	if err != nil {
+		fatal("Ошибка настройки JWT", "error", err)
+	}
+	if cfg.Env == "development" && cfg.JWTKeys[cfg.JWTActiveKID] == config.DefaultJWTSecret {
+		logger.Warn("Используется JWT секрет по умолчанию (только для разработки)")
+	}
+
+	// Сеансы: короткие access-токены, ротируемые refresh-токены и список отзыва
+	sessionStore := database.NewSessionStore(database.DB)
+	sessions := auth.NewSessionManager(sessionStore, userStore,
+		func(user models.User, sid string, ttl time.Duration) (string, error) {
+			return middleware.IssueJWT(jwtKeys, user, sid, ttl)
+		},
+		cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	if sessionStore.Available() {
+		if err := sessions.LoadRevoked(); err != nil {
+			logger.Warn("Не удалось загрузить отозванные сеансы", "error", err)
+		}
+	}
+	jwtAuth := middleware.JWTAuth(jwtKeys, sessions)
+
+	authn, err := buildAuthenticator(cfg, userStore)
+	if err != nil {
+		fatal("Ошибка настройки входа", "error", err)
+	}
+
+	oidcProvider, err := buildOIDC(cfg, userStore)
+	if err != nil {
+		fatal("Ошибка настройки OIDC", "error", err)
+	}
+
+	// Журнал аудита: входы, блокировки и все изменяющие запросы
+	auditStore := database.NewAuditStore(database.DB)
+	auditRecorder := audit.NewRecorder(auditStore)
+
+	// Защита входа от подбора паролей; блокировки попадают в журнал аудита
+	loginGuard := ratelimit.NewLoginGuard(cfg.LoginRateLimit, cfg.LoginMaxFailures, cfg.LoginLockout, cfg.LoginLockoutMax)
+	loginGuard.AddListener(func(ev ratelimit.LockoutEvent) {
+		target := "user:" + ev.Username
+		if ev.Kind == ratelimit.LockoutIP {
+			target = "ip:" + ev.IP
+		}
+		auditRecorder.Record(context.Background(), models.AuditEvent{
+			Actor:  ev.Username,
+			IP:     ev.IP,
+			Action: models.AuditLockout,
+			Target: target,
+			After:  audit.Payload(ev),
+		})
+	})
+
+	mfa := auth.NewMFAManager(userStore, cfg.MFAIssuer, cfg.MFARequiredRoles)
+
+	authHandler := api.NewAuthHandler(cfg, authn, userStore, sessions, jwtKeys, loginGuard, mfa, auditRecorder)
+	mfaHandler := api.NewMFAHandler(mfa, sessions)
+	oidcHandler := api.NewOIDCHandler(authHandler, oidcProvider, cfg.OIDCPostLoginURL)
+	usersHandler := api.NewUsersHandler(userStore, sessionStore, sessions)
+	dashboardHandler := api.NewDashboardHandler(attendanceService)
+	alertsHandler := api.NewAlertsHandler(alertStore)
+	rulesHandler := api.NewRulesHandler(ruleStore, alertService, attendanceService)
+	eventsHandler := api.NewEventsHandler(broker)
+	notificationsHandler := api.NewNotificationsHandler(notificationStore, notifier)
+	webhooksHandler := api.NewWebhooksHandler(webhookStore, dispatcher)
+	apiKeyStore := database.NewAPIKeyStore(database.DB)
+	apiKeysHandler := api.NewAPIKeysHandler(apiKeyStore)
+	auditHandler := api.NewAuditHandler(auditStore)
 
 	// Настраиваем Gin router (используем gin.New() вместо gin.Default() чтобы избежать дублирования middleware)
 	router := gin.New()
 
 	// Подключаем middleware
-	router.Use(middleware.SetupCORS())
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Tracing())
+	router.Use(corsPolicy.Handler())
+	router.Use(securityHeaders.Handler())
 	router.Use(middleware.Logger())
+	router.Use(middleware.Metrics())
 	router.Use(middleware.Recovery())
 
-	// Передаём пути в контекст для handlers
-	router.Use(func(c *gin.Context) {
-		c.Set("attendance_output", cfg.AttendanceOutput)
-		c.Set("statement_output", cfg.StatementOutput)
-		c.Next()
-	})
-
 	// API эндпоинты
 	apiGroup := router.Group("/api")
 	{
 		// Публичные эндпоинты (без авторизации)
 		apiGroup.POST("/login", authHandler.Login)
+		apiGroup.POST("/login/mfa", authHandler.LoginMFA)
+		apiGroup.POST("/login/mfa/setup", authHandler.LoginMFASetup)
+		apiGroup.POST("/refresh", authHandler.Refresh)
+		apiGroup.GET("/oidc/login", oidcHandler.Login)
+		apiGroup.GET("/oidc/callback", oidcHandler.Callback)
 		apiGroup.GET("/health", ginHandler.HealthCheck)
+		apiGroup.GET("/health/live", ginHandler.HealthCheck)
+		apiGroup.GET("/health/ready", healthHandler.Ready)
 
-		// Защищённые эндпоинты (требуют JWT)
+		// Поток событий (SSE): токен можно передать в ?access_token= для EventSource
+		apiGroup.GET("/events", middleware.TokenFromQuery(), jwtAuth, eventsHandler.Stream)
+
+		// Защищённые эндпоинты (требуют JWT или ключ API только для чтения)
 		protected := apiGroup.Group("")
-		protected.Use(middleware.JWTAuth(cfg.JWTSecret))
+		protected.Use(middleware.APIKeyOrJWT(apiKeyStore, jwtAuth))
+		if cfg.APIRateLimit > 0 {
+			protected.Use(middleware.RateLimit(ratelimit.NewLimiter(cfg.APIRateLimit, 0), middleware.TokenKey))
+		}
+		protected.Use(middleware.Audit(auditRecorder))
 		{
+			protected.POST("/logout", authHandler.Logout)
+
+			// Двухфакторная аутентификация текущего пользователя
+			protected.POST("/me/2fa/setup", mfaHandler.Setup)
+			protected.POST("/me/2fa/enable", mfaHandler.Enable)
+			protected.POST("/me/2fa/disable", mfaHandler.Disable)
+
 			// Эндпоинты дашборда (доступны всем авторизованным)
 			protected.GET("/attendance", dashboardHandler.List)
 			protected.GET("/attendance/summary", dashboardHandler.Summary)
@@ -116,6 +396,16 @@ func main() {
 			protected.GET("/attendance/drill/groups", dashboardHandler.DrillGroups)
 			protected.GET("/attendance/drill/students", dashboardHandler.DrillStudents)
 
+			// Алерты
+			protected.GET("/alerts", alertsHandler.List)
+			protected.GET("/alerts/:id", alertsHandler.Get)
+			// Статус алертов меняют те, кто отвечает за группы: заведующие и кураторы
+			// в своей области. viewer (и API-ключи) - только чтение; преподаватели
+			// видят алерты своих групп, но разбирать их - дело куратора.
+			alertEditors := middleware.RequireRole(models.RoleAdmin, models.RoleDepartmentHead, models.RoleCurator)
+			protected.POST("/alerts/:id/ack", alertEditors, alertsHandler.Acknowledge)
+			protected.POST("/alerts/:id/resolve", alertEditors, alertsHandler.Resolve)
+
 			// Админские эндпоинты (только для admin)
 			adminGroup := protected.Group("/admin")
 			adminGroup.Use(middleware.RequireRole("admin"))
@@ -123,6 +413,61 @@ func main() {
 				adminGroup.POST("/refresh-data", ginHandler.RefreshData)
 				adminGroup.GET("/refresh-status", ginHandler.GetRefreshStatus)
 				adminGroup.GET("/refresh-history", ginHandler.GetRefreshHistory)
+				adminGroup.GET("/refresh-jobs/:id", ginHandler.GetRefreshJob)
+				adminGroup.POST("/refresh-jobs/:id/cancel", ginHandler.CancelRefreshJob)
+				adminGroup.POST("/refresh-schedule/pause", ginHandler.PauseSchedule)
+				adminGroup.POST("/refresh-schedule/resume", ginHandler.ResumeSchedule)
+				adminGroup.GET("/health", healthHandler.Report)
+
+				// Правила алертов
+				adminGroup.GET("/alert-rules", rulesHandler.List)
+				adminGroup.POST("/alert-rules", rulesHandler.Create)
+				adminGroup.POST("/alert-rules/preview", rulesHandler.Preview)
+				adminGroup.POST("/alert-rules/evaluate", rulesHandler.Evaluate)
+				adminGroup.GET("/alert-rules/:id", rulesHandler.Get)
+				adminGroup.PUT("/alert-rules/:id", rulesHandler.Update)
+				adminGroup.DELETE("/alert-rules/:id", rulesHandler.Delete)
+
+				// Email уведомления
+				adminGroup.GET("/notifications/recipients", notificationsHandler.ListRecipients)
+				adminGroup.POST("/notifications/recipients", notificationsHandler.CreateRecipient)
+				adminGroup.PUT("/notifications/recipients/:id", notificationsHandler.UpdateRecipient)
+				adminGroup.DELETE("/notifications/recipients/:id", notificationsHandler.DeleteRecipient)
+				adminGroup.GET("/notifications/log", notificationsHandler.ListDeliveries)
+				adminGroup.POST("/notifications/digest", notificationsHandler.SendDigest)
+				adminGroup.POST("/notifications/test", notificationsHandler.SendTest)
+
+				// Пользователи
+				adminGroup.GET("/users", usersHandler.List)
+				adminGroup.POST("/users", usersHandler.Create)
+				adminGroup.GET("/users/:id", usersHandler.Get)
+				adminGroup.POST("/users/:id/disable", usersHandler.Disable)
+				adminGroup.POST("/users/:id/enable", usersHandler.Enable)
+				adminGroup.POST("/users/:id/password", usersHandler.ResetPassword)
+				adminGroup.PUT("/users/:id/role", usersHandler.SetRole)
+				adminGroup.GET("/users/:id/sessions", usersHandler.Sessions)
+				adminGroup.DELETE("/users/:id/sessions", usersHandler.RevokeSessions)
+				adminGroup.DELETE("/users/:id/sessions/:sid", usersHandler.RevokeSession)
+				adminGroup.POST("/users/:id/2fa/reset", mfaHandler.Reset)
+
+				// Webhooks
+				adminGroup.GET("/webhooks", webhooksHandler.List)
+				adminGroup.POST("/webhooks", webhooksHandler.Create)
+				adminGroup.GET("/webhooks/:id", webhooksHandler.Get)
+				adminGroup.PUT("/webhooks/:id", webhooksHandler.Update)
+				adminGroup.DELETE("/webhooks/:id", webhooksHandler.Delete)
+				adminGroup.GET("/webhooks/:id/deliveries", webhooksHandler.Deliveries)
+				adminGroup.POST("/webhooks/deliveries/:delivery_id/replay", webhooksHandler.Replay)
+
+				// Ключи API для интеграций
+				adminGroup.GET("/api-keys", apiKeysHandler.List)
+				adminGroup.POST("/api-keys", apiKeysHandler.Create)
+				adminGroup.DELETE("/api-keys/:id", apiKeysHandler.Revoke)
+
+				// Журнал аудита
+				adminGroup.GET("/audit", auditHandler.List)
+				adminGroup.GET("/audit/export", auditHandler.Export)
+				adminGroup.GET("/audit/verify", auditHandler.Verify)
 			}
 		}
 	}
@@ -130,65 +475,55 @@ func main() {
 	// Swagger документация (упрощённый вариант через CDN)
 	router.GET("/swagger/*path", serveSwagger)
 
+	// Метрики Prometheus
+	if cfg.MetricsEnabled {
+		router.GET("/metrics", middleware.StaticToken(cfg.MetricsToken), gin.WrapH(metrics.Handler()))
+	}
+
 	serverAddr := fmt.Sprintf("%s:%s", cfg.ServerHost, cfg.ServerPort)
 	httpServer := &http.Server{
 		Addr:    serverAddr,
 		Handler: router,
 	}
+	// Потоки SSE живут, пока открыта вкладка: закрываем их при остановке,
+	// иначе Shutdown ждал бы их до таймаута
+	httpServer.RegisterOnShutdown(broker.Close)
 
 	// Запускаем HTTP сервер в отдельной горутине
 	go func() {
-		log.Printf("[Server] HTTP сервер запущен на http://%s", serverAddr)
-		log.Printf("[Server] Swagger UI доступен на http://%s/swagger/", serverAddr)
+		logger.Info("HTTP сервер запущен",
+			"addr", "http://"+serverAddr, "swagger", fmt.Sprintf("http://%s/swagger/", serverAddr))
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("[Server] Ошибка запуска HTTP сервера: %v", err)
+			fatal("Ошибка запуска HTTP сервера", "error", err)
 		}
 	}()
 
-	// Настраиваем cron задачу
-	c := cron.New()
-
-	// Формируем cron выражение из интервала
-	cronExpr := formatCronInterval(cfg.RefreshInterval)
-	_, err = c.AddFunc(cronExpr, func() {
-		log.Println("[Server] Запуск автоматического обновления данных...")
-		if err := sched.RefreshData(); err != nil {
-			log.Printf("[Server] Ошибка обновления данных: %v", err)
-		}
-		// После обновления загружаем в БД
-		if database.DB != nil {
-			if err := dbLoader.LoadAttendance(cfg.AttendanceOutput); err != nil {
-				log.Printf("[Server] Предупреждение при загрузке посещаемости в БД: %v", err)
-			}
-			if err := dbLoader.LoadStatement(cfg.StatementOutput); err != nil {
-				log.Printf("[Server] Предупреждение при загрузке ведомости в БД: %v", err)
+	// Cron для еженедельной сводки по алертам - в том же часовом поясе,
+	// что и плановое обновление (смена пояса применится к сводке после перезапуска)
+	c := cron.New(cron.WithLocation(scheduleCfg.Location))
+	if notifier != nil {
+		if _, err := c.AddFunc(cfg.DigestCron, func() {
+			if _, err := notifier.SendDigest(); err != nil {
+				logger.Error("Ошибка отправки сводки", "error", err)
 			}
+		}); err != nil {
+			fatal("Ошибка настройки расписания сводки", "cron", cfg.DigestCron, "error", err)
 		}
-	})
-	if err != nil {
-		log.Fatalf("[Server] Ошибка настройки cron: %v", err)
 	}
 
-	// Запускаем обновление сразу при старте
-	log.Println("[Server] Первоначальное обновление данных...")
-	if err := sched.RefreshData(); err != nil {
-		log.Printf("[Server] Предупреждение при первоначальном обновлении: %v", err)
-	}
-
-	// Загружаем в БД после первоначального обновления
-	if database.DB != nil {
-		if err := dbLoader.LoadAttendance(cfg.AttendanceOutput); err != nil {
-			log.Printf("[Server] Предупреждение при загрузке посещаемости в БД: %v", err)
-		}
-		if err := dbLoader.LoadStatement(cfg.StatementOutput); err != nil {
-			log.Printf("[Server] Предупреждение при загрузке ведомости в БД: %v", err)
-		}
+	// Запускаем обновление сразу при старте (в фоне, через общую очередь)
+	if job, err := runner.Submit(context.Background(), scheduler.TriggerStartup); err != nil {
+		logger.Warn("Первоначальное обновление не запущено", "error", err)
+	} else {
+		logger.Info("Первоначальное обновление данных поставлено в очередь", "job_id", job.ID)
 	}
 
-	// Запускаем планировщик
+	// Запускаем планировщики
 	c.Start()
-	log.Printf("[Server] Планировщик запущен. Обновление данных каждые %v.", cfg.RefreshInterval)
-	log.Println("[Server] Нажмите Ctrl+C для остановки...")
+	refreshSchedule.Start()
+	if next := refreshSchedule.Status().NextRun; next != nil {
+		logger.Info("Планировщик запущен", "next_run", next.Format(time.RFC3339))
+	}
 
 	// Обработка сигналов для корректного завершения
 	sigChan := make(chan os.Signal, 1)
@@ -196,33 +531,64 @@ func main() {
 
 	// Блокируем выполнение до получения сигнала
 	<-sigChan
-	log.Println("[Server] Получен сигнал завершения. Остановка сервера...")
+	logger.Info("Получен сигнал завершения, остановка сервера")
 	c.Stop()
+	refreshSchedule.Stop()
+
+	// Даём текущему обновлению завершиться, иначе отменяем его
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), cfg.ShutdownDrainTimeout)
+	if err := runner.Shutdown(drainCtx); err != nil {
+		logger.Warn("Обновление данных прервано при остановке", "error", err)
+	}
+	drainCancel()
 
 	// Останавливаем HTTP сервер
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := httpServer.Shutdown(ctx); err != nil {
-		log.Printf("[Server] Ошибка остановки HTTP сервера: %v", err)
+		logger.Error("Ошибка остановки HTTP сервера", "error", err)
+	}
+	// У отправки спанов свой таймаут: контекст остановки HTTP мог истечь,
+	// а последние спаны - как раз о прерванном обновлении
+	traceCtx, traceCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer traceCancel()
+	if err := shutdownTracing(traceCtx); err != nil {
+		logger.Error("Ошибка отправки спанов", "error", err)
 	}
 
-	log.Println("[Server] Сервер остановлен.")
+	logger.Info("Сервер остановлен")
 }
 
-// formatCronInterval преобразует time.Duration в cron выражение
-// Для упрощения используем @every, но можно сделать более точное расписание
-func formatCronInterval(d time.Duration) string {
-	// robfig/cron поддерживает @every напрямую
-	minutes := int(d.Minutes())
-	if minutes < 60 {
-		return fmt.Sprintf("@every %dm", minutes)
+// healthThresholds собирает пороги возраста данных для проверки готовности
+func healthThresholds(cfg *config.Config) health.Thresholds {
+	return health.Thresholds{MaxDataAge: cfg.HealthMaxDataAge, MaxInputAge: cfg.HealthMaxInputAge}
+}
+
+// scheduleConfig собирает расписание обновления из конфигурации
+func scheduleConfig(cfg *config.Config) (scheduler.ScheduleConfig, error) {
+	loc, err := time.LoadLocation(cfg.RefreshTimezone)
+	if err != nil {
+		return scheduler.ScheduleConfig{}, err
+	}
+	windows, err := quiet.Parse(cfg.RefreshQuietHours)
+	if err != nil {
+		return scheduler.ScheduleConfig{}, err
 	}
-	hours := minutes / 60
-	if hours*60 == minutes {
-		return fmt.Sprintf("@every %dh", hours)
+	return scheduler.ScheduleConfig{Specs: cfg.RefreshSchedules, Location: loc, Quiet: windows}, nil
+}
+
+// swaggerCSP - политика для страницы Swagger UI вместо строгой политики API
+const swaggerCSP = "default-src 'self'; script-src 'self' 'unsafe-inline' https://unpkg.com; " +
+	"style-src 'self' 'unsafe-inline' https://unpkg.com; img-src 'self' data: https://unpkg.com; frame-ancestors 'none'"
+
+// securityPolicy собирает заголовки безопасности из конфигурации
+func securityPolicy(cfg *config.Config) middleware.SecurityPolicy {
+	return middleware.SecurityPolicy{
+		CSP:            cfg.SecurityCSP,
+		HSTS:           cfg.SecurityHSTS,
+		FrameOptions:   cfg.SecurityFrameOptions,
+		ReferrerPolicy: cfg.SecurityReferrerPolicy,
 	}
-	// Если не кратно часам, используем минуты
-	return fmt.Sprintf("@every %dm", minutes)
 }
 
 // serveSwagger обрабатывает запросы к Swagger UI и JSON
@@ -264,45 +630,33 @@ func serveSwagger(c *gin.Context) {
 				"post": {
 					"tags": ["admin"],
 					"summary": "Ручное обновление данных",
-					"description": "Запускает конвертацию Excel файлов в JSON и загрузку в БД",
+					"description": "Ставит в очередь конвертацию Excel файлов в JSON и загрузку в БД, сразу возвращает ID задачи",
 					"responses": {
-						"200": {
-							"description": "Данные успешно обновлены",
+						"202": {
+							"description": "Задача поставлена в очередь",
 							"content": {
 								"application/json": {
 									"schema": {
 										"type": "object",
 										"properties": {
-											"status": {"type": "string", "example": "success"},
-											"message": {"type": "string", "example": "Данные успешно обновлены"},
-											"time": {"type": "string", "format": "date-time"}
+											"id": {"type": "string", "example": "9f2c4e1a7b3d5c60"},
+											"trigger": {"type": "string", "example": "manual"},
+											"status": {"type": "string", "example": "running"},
+											"progress": {"type": "integer", "example": 0},
+											"stages": {"type": "array", "items": {"type": "object"}}
 										}
 									}
 								}
 							}
 						},
 						"409": {
-							"description": "Обновление уже выполняется",
-							"content": {
-								"application/json": {
-									"schema": {
-										"type": "object",
-										"properties": {
-											"error": {"type": "string", "example": "Обновление уже выполняется"}
-										}
-									}
-								}
-							}
-						},
-						"500": {
-							"description": "Ошибка обновления данных",
+							"description": "Обновление уже выполняется, очередь заполнена",
 							"content": {
 								"application/json": {
 									"schema": {
 										"type": "object",
 										"properties": {
-											"error": {"type": "string"},
-											"details": {"type": "string"}
+											"error": {"type": "string", "example": "Обновление уже выполняется, очередь заполнена"}
 										}
 									}
 								}
@@ -428,6 +782,8 @@ func serveSwagger(c *gin.Context) {
 	</script>
 </body>
 </html>`
+		// Swagger UI загружает скрипты и стили с unpkg.com
+		c.Header("Content-Security-Policy", swaggerCSP)
 		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
 	} else {
 		c.Status(http.StatusNotFound)
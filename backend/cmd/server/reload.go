@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"dashboard/internal/config"
+)
+
+// watchReload перечитывает конфигурацию по SIGHUP. Ошибочная конфигурация
+// не применяется; изменения, требующие перезапуска, только попадают в лог.
+func watchReload(w *config.Watcher) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		applied, restart, err := w.Reload()
+		if err != nil {
+			logger.Error("Конфигурация не перезагружена", "error", err)
+			continue
+		}
+		if len(applied) > 0 {
+			logger.Info("Конфигурация перезагружена", "applied", strings.Join(applied, ", "))
+		} else {
+			logger.Info("Конфигурация перечитана, изменений нет")
+		}
+		if len(restart) > 0 {
+			logger.Warn("Изменения вступят в силу после перезапуска", "fields", strings.Join(restart, ", "))
+		}
+	}
+}
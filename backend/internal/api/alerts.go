@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"dashboard/internal/database"
+	"dashboard/internal/middleware"
+	"dashboard/internal/models"
+)
+
+// AlertsHandler обрабатывает запросы к алертам
+type AlertsHandler struct {
+	store *database.AlertStore
+}
+
+// NewAlertsHandler создаёт handler алертов
+func NewAlertsHandler(store *database.AlertStore) *AlertsHandler {
+	return &AlertsHandler{store: store}
+}
+
+// List возвращает алерты с фильтрацией
+// GET /api/alerts?status=open&severity=critical&department=...&group=...&limit=50&offset=0
+func (h *AlertsHandler) List(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	scope := middleware.ScopeFromContext(c)
+	alerts, err := h.store.List(database.AlertFilter{
+		Status:      strings.TrimSpace(c.Query("status")),
+		Severity:    strings.TrimSpace(c.Query("severity")),
+		SubjectType: strings.TrimSpace(c.Query("subject_type")),
+		Rule:        strings.TrimSpace(c.Query("rule")),
+		Department:  strings.TrimSpace(c.Query("department")),
+		Group:       strings.TrimSpace(c.Query("group")),
+		Student:     strings.TrimSpace(c.Query("student")),
+		Limit:       limit,
+		Offset:      offset,
+		Scope:       &scope,
+	})
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot load alerts", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+// Get возвращает алерт по ID
+// GET /api/alerts/:id
+func (h *AlertsHandler) Get(c *gin.Context) {
+	id, ok := alertID(c)
+	if !ok {
+		return
+	}
+
+	alert, err := h.store.Get(id)
+	if err == nil && !visible(c, alert) {
+		err = database.ErrNotFound
+	}
+	respondAlert(c, alert, err)
+}
+
+// Acknowledge подтверждает алерт
+// POST /api/alerts/:id/ack
+func (h *AlertsHandler) Acknowledge(c *gin.Context) {
+	id, ok := alertID(c)
+	if !ok {
+		return
+	}
+
+	if !h.checkVisible(c, id) {
+		return
+	}
+	alert, err := h.store.Acknowledge(id, actor(c))
+	if err == nil && alert.Status != models.AlertAcknowledged {
+		c.JSON(http.StatusConflict, gin.H{"error": "Alert is not open", "alert": alert})
+		return
+	}
+	respondAlert(c, alert, err)
+}
+
+// Resolve вручную закрывает алерт
+// POST /api/alerts/:id/resolve
+func (h *AlertsHandler) Resolve(c *gin.Context) {
+	id, ok := alertID(c)
+	if !ok {
+		return
+	}
+
+	if !h.checkVisible(c, id) {
+		return
+	}
+	alert, err := h.store.Resolve(id, actor(c))
+	respondAlert(c, alert, err)
+}
+
+// checkVisible не даёт менять алерты вне области видимости пользователя.
+// Чужой алерт выглядит как несуществующий.
+func (h *AlertsHandler) checkVisible(c *gin.Context, id int) bool {
+	alert, err := h.store.Get(id)
+	if err == nil && !visible(c, alert) {
+		err = database.ErrNotFound
+	}
+	if err != nil {
+		respondAlert(c, alert, err)
+		return false
+	}
+	return true
+}
+
+func visible(c *gin.Context, alert models.Alert) bool {
+	return middleware.ScopeFromContext(c).Allows(alert.Department, alert.Group)
+}
+
+// alertID разбирает ID алерта из пути
+func alertID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func respondAlert(c *gin.Context, alert models.Alert, err error) {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+	case err != nil:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot update alert", "details": err.Error()})
+	default:
+		c.JSON(http.StatusOK, alert)
+	}
+}
+
+// actor возвращает имя пользователя, выполняющего действие
+func actor(c *gin.Context) string {
+	if username := c.GetString("username"); username != "" {
+		return username
+	}
+	return c.GetString("role")
+}
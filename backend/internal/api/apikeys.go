@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"dashboard/internal/auth"
+	"dashboard/internal/database"
+	"dashboard/internal/middleware"
+	"dashboard/internal/models"
+)
+
+// APIKeysHandler выдаёт и отзывает ключи API для интеграций (только для admin)
+type APIKeysHandler struct {
+	store *database.APIKeyStore
+}
+
+// NewAPIKeysHandler создаёт handler ключей API
+func NewAPIKeysHandler(store *database.APIKeyStore) *APIKeysHandler {
+	return &APIKeysHandler{store: store}
+}
+
+// List возвращает ключи (без секретов)
+// GET /api/admin/api-keys
+func (h *APIKeysHandler) List(c *gin.Context) {
+	keys, err := h.store.List()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot load API keys", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// Create выпускает ключ. Сам ключ возвращается только в этом ответе.
+// POST /api/admin/api-keys
+// {"name": "nightly-export", "scopes": ["attendance:read"], "departments": ["ИТ"], "expires_at": "2027-01-01T00:00:00Z"}
+func (h *APIKeysHandler) Create(c *gin.Context) {
+	var body struct {
+		Name        string     `json:"name"`
+		Scopes      []string   `json:"scopes"`
+		Departments []string   `json:"departments"`
+		ExpiresAt   *time.Time `json:"expires_at"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+	if body.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return
+	}
+	if len(body.Scopes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one scope is required"})
+		return
+	}
+	for _, s := range body.Scopes {
+		if !models.ValidAPIScope(s) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope", "details": s})
+			return
+		}
+	}
+	if body.ExpiresAt != nil && !body.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	key, prefix, hash := auth.GenerateAPIKey()
+	created, err := h.store.Create(models.APIKey{
+		Name:        body.Name,
+		Prefix:      prefix,
+		KeyHash:     hash,
+		Scopes:      body.Scopes,
+		Departments: body.Departments,
+		ExpiresAt:   body.ExpiresAt,
+		CreatedBy:   actor(c),
+	})
+	if err != nil {
+		respondAPIKey(c, 0, created, err)
+		return
+	}
+	logger.InfoContext(c.Request.Context(), "Выпущен ключ API", "actor", actor(c), "name", created.Name, "prefix", created.Prefix)
+	middleware.AuditChange(c, "api_key:"+created.Prefix, nil, created)
+	c.JSON(http.StatusCreated, gin.H{"api_key": created, "key": key})
+}
+
+// Revoke отзывает ключ
+// DELETE /api/admin/api-keys/:id
+func (h *APIKeysHandler) Revoke(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid id"})
+		return
+	}
+	revoked, err := h.store.Revoke(id)
+	if err == nil {
+		logger.InfoContext(c.Request.Context(), "Ключ API отозван", "actor", actor(c), "name", revoked.Name, "prefix", revoked.Prefix)
+		middleware.AuditChange(c, "api_key:"+revoked.Prefix, nil, revoked)
+	}
+	respondAPIKey(c, http.StatusOK, revoked, err)
+}
+
+func respondAPIKey(c *gin.Context, status int, k models.APIKey, err error) {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+	case errors.Is(err, database.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "API key prefix collision, try again"})
+	case err != nil:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot save API key", "details": err.Error()})
+	default:
+		c.JSON(status, k)
+	}
+}
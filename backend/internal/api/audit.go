@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"dashboard/internal/database"
+	"dashboard/internal/models"
+)
+
+// AuditHandler показывает журнал аудита (только для admin)
+type AuditHandler struct {
+	store *database.AuditStore
+}
+
+// NewAuditHandler создаёт handler журнала аудита
+func NewAuditHandler(store *database.AuditStore) *AuditHandler {
+	return &AuditHandler{store: store}
+}
+
+// List возвращает записи журнала, новые первыми
+// GET /api/admin/audit?actor=&action=&target=&from=&to=&limit=&offset=
+func (h *AuditHandler) List(c *gin.Context) {
+	f, ok := auditFilter(c)
+	if !ok {
+		return
+	}
+	f.Limit, _ = strconv.Atoi(c.Query("limit"))
+	f.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	events, err := h.store.List(f)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot load audit log", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+// Export выгружает записи по тем же фильтрам в CSV, по порядку добавления
+// GET /api/admin/audit/export?actor=&action=&target=&from=&to=
+func (h *AuditHandler) Export(c *gin.Context) {
+	f, ok := auditFilter(c)
+	if !ok {
+		return
+	}
+	if !h.store.Available() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot load audit log", "details": "database is not connected"})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="audit-`+time.Now().Format("20060102-150405")+`.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"id", "time", "actor", "role", "ip", "action", "target", "status", "before", "after", "prev_hash", "hash"})
+	err := h.store.Each(f, func(e models.AuditEvent) error {
+		w.Write([]string{
+			strconv.FormatInt(e.ID, 10),
+			e.Time.UTC().Format(time.RFC3339Nano),
+			csvCell(e.Actor), csvCell(e.Role), csvCell(e.IP), csvCell(e.Action), csvCell(e.Target),
+			strconv.Itoa(e.Status),
+			csvCell(string(e.Before)), csvCell(string(e.After)),
+			e.PrevHash, e.Hash,
+		})
+		return w.Error()
+	})
+	w.Flush()
+	if err != nil {
+		// Заголовки уже отправлены: обрыв выгрузки виден только в логе
+		logger.ErrorContext(c.Request.Context(), "Ошибка выгрузки журнала аудита", "error", err)
+	}
+}
+
+// csvCell не даёт табличным редакторам принять значение за формулу:
+// логин и User-Agent при неудачном входе присылает кто угодно
+func csvCell(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// Verify проверяет цепочку хэшей журнала
+// GET /api/admin/audit/verify
+func (h *AuditHandler) Verify(c *gin.Context) {
+	st, err := h.store.Verify()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot verify audit log", "details": err.Error()})
+		return
+	}
+	if !st.Valid {
+		logger.ErrorContext(c.Request.Context(), "Цепочка журнала аудита нарушена", "broken_at", st.BrokenAt, "reason", st.Reason)
+	}
+	c.JSON(http.StatusOK, st)
+}
+
+// auditFilter разбирает фильтры журнала. Границы периода - RFC3339
+// или дата YYYY-MM-DD (to включает весь день).
+func auditFilter(c *gin.Context) (database.AuditFilter, bool) {
+	f := database.AuditFilter{
+		Actor:  strings.TrimSpace(c.Query("actor")),
+		Action: strings.TrimSpace(c.Query("action")),
+		Target: strings.TrimSpace(c.Query("target")),
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		raw := strings.TrimSpace(c.Query(p.name))
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			day, derr := time.ParseInLocation("2006-01-02", raw, time.Local)
+			if derr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + p.name + ", expected RFC3339 or YYYY-MM-DD"})
+				return f, false
+			}
+			if p.name == "to" {
+				day = day.AddDate(0, 0, 1)
+			}
+			t = day
+		}
+		*p.dst = &t
+	}
+	return f, true
+}
@@ -0,0 +1,19 @@
+package api
+
+import "testing"
+
+func TestCSVCell(t *testing.T) {
+	for v, want := range map[string]string{
+		`=HYPERLINK("http://evil")`: `'=HYPERLINK("http://evil")`,
+		"+7":                        "'+7",
+		"-1+1":                      "'-1+1",
+		"@SUM(A1)":                  "'@SUM(A1)",
+		"\tcmd":                     "'\tcmd",
+		"ivanov":                    "ivanov",
+		"":                          "",
+	} {
+		if got := csvCell(v); got != want {
+			t.Errorf("%q: получено %q, ожидалось %q", v, got, want)
+		}
+	}
+}
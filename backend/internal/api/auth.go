@@ -1,21 +1,41 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"dashboard/internal/audit"
+	"dashboard/internal/auth"
 	"dashboard/internal/config"
+	"dashboard/internal/database"
 	"dashboard/internal/middleware"
+	"dashboard/internal/models"
+	"dashboard/internal/ratelimit"
 )
 
+// fallbackTokenTTL - время жизни токена при входе без БД, когда сеансы недоступны
+const fallbackTokenTTL = 24 * time.Hour
+
 // AuthHandler обрабатывает запросы авторизации
 type AuthHandler struct {
-	cfg *config.Config
+	cfg      *config.Config
+	authn    auth.Authenticator
+	users    *database.UserStore
+	sessions *auth.SessionManager
+	keys     *auth.KeySet
+	guard    *ratelimit.LoginGuard
+	mfa      *auth.MFAManager
+	audit    *audit.Recorder
 }
 
-// NewAuthHandler создаёт новый handler авторизации
-func NewAuthHandler(cfg *config.Config) *AuthHandler {
-	return &AuthHandler{cfg: cfg}
+// NewAuthHandler создаёт новый handler авторизации.
+// authn проверяет пароль (LDAP, локальные учётные записи или их цепочка),
+// guard ограничивает частоту попыток входа, mfa запрашивает второй фактор,
+// rec записывает входы в журнал аудита.
+func NewAuthHandler(cfg *config.Config, authn auth.Authenticator, users *database.UserStore, sessions *auth.SessionManager, keys *auth.KeySet, guard *ratelimit.LoginGuard, mfa *auth.MFAManager, rec *audit.Recorder) *AuthHandler {
+	return &AuthHandler{cfg: cfg, authn: authn, users: users, sessions: sessions, keys: keys, guard: guard, mfa: mfa, audit: rec}
 }
 
 // Login обрабатывает POST /api/login
@@ -30,21 +50,143 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Проверяем учётные данные
-	if body.Username != h.cfg.LoginUser || body.Password != h.cfg.LoginPassword {
+	ip := c.ClientIP()
+	if ok, wait := h.guard.Check(ip, body.Username); !ok {
+		middleware.TooManyRequests(c, wait)
+		return
+	}
+
+	user, err := h.authn.Authenticate(body.Username, body.Password)
+	switch {
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		h.guard.Failure(ip, body.Username)
+		h.recordLogin(c, models.AuditLoginFailed, models.User{Username: body.Username}, "password")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
+	case errors.Is(err, auth.ErrUserDisabled):
+		h.recordLogin(c, models.AuditLoginFailed, models.User{Username: body.Username}, "disabled")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+		return
+	case errors.Is(err, auth.ErrNoRole):
+		c.JSON(http.StatusForbidden, gin.H{"error": "No dashboard role assigned"})
+		return
+	case errors.Is(err, auth.ErrLoginDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Login is not configured"})
+		return
+	case err != nil:
+		logger.ErrorContext(c.Request.Context(), "Ошибка входа", "username", body.Username, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	// Второй шаг: код из приложения-аутентификатора (POST /api/login/mfa).
+	// Счётчик неудач сбрасывается только после полного входа.
+	if h.mfa.Required(user) {
+		ch := h.mfa.Begin(user)
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required":        true,
+			"mfa_token":           ch.Token,
+			"enrollment_required": ch.Enroll,
+			"expires_in":          ch.ExpiresIn,
+		})
+		return
 	}
 
-	// Генерируем JWT токен
-	token, err := middleware.IssueJWT(h.cfg.JWTSecret, h.cfg.LoginRole)
+	h.guard.Success(ip, body.Username)
+	h.recordLogin(c, models.AuditLogin, user, "password")
+	h.completeLogin(c, user, nil)
+}
+
+// recordLogin записывает вход или неудачную попытку в журнал аудита.
+// method - способ входа или причина отказа.
+func (h *AuthHandler) recordLogin(c *gin.Context, action string, user models.User, method string) {
+	h.audit.Record(c.Request.Context(), models.AuditEvent{
+		Actor:  user.Username,
+		Role:   user.Role,
+		IP:     c.ClientIP(),
+		Action: action,
+		Target: "user:" + user.Username,
+		After:  audit.Payload(gin.H{"method": method, "user_agent": c.Request.UserAgent()}),
+	})
+}
+
+// completeLogin выдаёт токены вошедшему пользователю и отвечает клиенту.
+// extra добавляется в ответ (например, коды восстановления 2FA).
+func (h *AuthHandler) completeLogin(c *gin.Context, user models.User, extra gin.H) {
+	tokens, err := h.issueTokens(c, user)
 	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Ошибка создания сеанса", "username", user.Username, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"role":  h.cfg.LoginRole,
-	})
+	resp := gin.H{
+		"token":      tokens.AccessToken,
+		"expires_in": tokens.ExpiresIn,
+		"role":       user.Role,
+		"username":   user.Username,
+		"scope":      user.Scope(),
+	}
+	if tokens.RefreshToken != "" {
+		resp["refresh_token"] = tokens.RefreshToken
+	}
+	for k, v := range extra {
+		resp[k] = v
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// issueTokens выдаёт токены вошедшему пользователю. Без БД сеансов нет:
+// выдаётся только access-токен, как раньше.
+func (h *AuthHandler) issueTokens(c *gin.Context, user models.User) (auth.Tokens, error) {
+	if !h.users.Available() {
+		token, err := middleware.IssueJWT(h.keys, user, "", fallbackTokenTTL)
+		return auth.Tokens{AccessToken: token, ExpiresIn: int(fallbackTokenTTL.Seconds())}, err
+	}
+	return h.sessions.Start(user, c.Request.UserAgent(), c.ClientIP())
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов
+// POST /api/refresh {"refresh_token": "..."}
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	tokens, err := h.sessions.Refresh(body.RefreshToken)
+	switch {
+	case errors.Is(err, auth.ErrInvalidRefresh), errors.Is(err, auth.ErrRefreshReused):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	case errors.Is(err, auth.ErrUserDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+		return
+	case err != nil:
+		logger.ErrorContext(c.Request.Context(), "Ошибка обновления токена", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout завершает текущий сеанс: refresh-токен перестаёт действовать,
+// а access-токен отклоняется до истечения срока
+// POST /api/logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	sid := c.GetString("sid")
+	if sid == "" {
+		// Токен без сеанса (вход без БД) отозвать нельзя
+		c.Status(http.StatusNoContent)
+		return
+	}
+	if err := h.sessions.RevokeSession(sid); err != nil && !errors.Is(err, database.ErrNotFound) {
+		logger.ErrorContext(c.Request.Context(), "Ошибка завершения сеанса", "session", sid, "error", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot revoke session"})
+		return
+	}
+	c.Status(http.StatusNoContent)
 }
@@ -1,41 +1,91 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"dashboard/internal/middleware"
 	"dashboard/internal/services"
 )
 
 // DashboardHandler обрабатывает запросы дашборда
 type DashboardHandler struct {
 	attendanceService *services.AttendanceService
-	alertsThreshold  int
 }
 
 // NewDashboardHandler создаёт новый handler дашборда
-func NewDashboardHandler(attendanceService *services.AttendanceService, alertsThreshold int) *DashboardHandler {
+func NewDashboardHandler(attendanceService *services.AttendanceService) *DashboardHandler {
 	return &DashboardHandler{
 		attendanceService: attendanceService,
-		alertsThreshold:   alertsThreshold,
 	}
 }
 
-// List возвращает список записей посещаемости с фильтрацией
-// GET /api/attendance
-func (h *DashboardHandler) List(c *gin.Context) {
-	_, flat, err := h.attendanceService.LoadFromJSON()
+// filterParams разбирает параметры фильтрации и добавляет область
+// видимости пользователя, чтобы ограничение действовало во всех выборках
+func filterParams(c *gin.Context) services.FilterParams {
+	params := services.ParseFilterParams(c.Request)
+	scope := middleware.ScopeFromContext(c)
+	params.Scope = &scope
+	return params
+}
+
+// loadSnapshot возвращает текущий снимок данных и обрабатывает If-None-Match.
+// Возвращает false, если ответ уже отправлен (ошибка или 304 Not Modified).
+func (h *DashboardHandler) loadSnapshot(c *gin.Context, params services.FilterParams) (*services.Snapshot, bool) {
+	snap, err := h.attendanceService.Snapshot(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot load attendance"})
-		return
+		return nil, false
 	}
 
-	params := services.ParseFilterParams(c.Request)
-	filtered := h.attendanceService.Filter(flat, params)
+	// ETag зависит от версии снимка и области видимости пользователя,
+	// а для относительных периодов - ещё и от текущей даты
+	etag := snap.Version
+	if params.Scope != nil && !params.Scope.All {
+		raw, _ := json.Marshal(params.Scope)
+		sum := sha256.Sum256(raw)
+		etag += "-" + hex.EncodeToString(sum[:4])
+	}
+	if params.DependsOnToday() {
+		etag += "-" + time.Now().Format("20060102")
+	}
+	etag = `"` + etag + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("Vary", "Authorization, X-API-Key")
+	if match := c.GetHeader("If-None-Match"); match != "" && etagMatches(match, etag) {
+		c.AbortWithStatus(http.StatusNotModified)
+		return nil, false
+	}
+	return snap, true
+}
 
-	// Проверяем алерты
-	services.CheckAlerts(filtered, h.alertsThreshold)
+// etagMatches проверяет заголовок If-None-Match (список ETag через запятую или *)
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// List возвращает список записей посещаемости с фильтрацией
+// GET /api/attendance
+func (h *DashboardHandler) List(c *gin.Context) {
+	params := filterParams(c)
+	snap, ok := h.loadSnapshot(c, params)
+	if !ok {
+		return
+	}
+	filtered := h.attendanceService.Filter(c.Request.Context(), snap, params)
 
 	c.JSON(http.StatusOK, filtered)
 }
@@ -43,15 +93,13 @@ func (h *DashboardHandler) List(c *gin.Context) {
 // Summary возвращает сводку по посещаемости
 // GET /api/attendance/summary
 func (h *DashboardHandler) Summary(c *gin.Context) {
-	departments, flat, err := h.attendanceService.LoadFromJSON()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot load attendance"})
+	params := filterParams(c)
+	snap, ok := h.loadSnapshot(c, params)
+	if !ok {
 		return
 	}
-
-	params := services.ParseFilterParams(c.Request)
-	filtered := h.attendanceService.Filter(flat, params)
-	summary := h.attendanceService.BuildSummary(departments, filtered)
+	filtered := h.attendanceService.Filter(c.Request.Context(), snap, params)
+	summary := h.attendanceService.BuildSummary(c.Request.Context(), snap, filtered, params.Scope)
 
 	c.JSON(http.StatusOK, summary)
 }
@@ -59,15 +107,13 @@ func (h *DashboardHandler) Summary(c *gin.Context) {
 // DrillDepartments возвращает drill-down по отделениям
 // GET /api/attendance/drill/departments
 func (h *DashboardHandler) DrillDepartments(c *gin.Context) {
-	departments, flat, err := h.attendanceService.LoadFromJSON()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot load attendance"})
+	params := filterParams(c)
+	snap, ok := h.loadSnapshot(c, params)
+	if !ok {
 		return
 	}
-
-	params := services.ParseFilterParams(c.Request)
-	filtered := h.attendanceService.Filter(flat, params)
-	result := h.attendanceService.BuildDrillDepartments(departments, filtered)
+	filtered := h.attendanceService.Filter(c.Request.Context(), snap, params)
+	result := h.attendanceService.BuildDrillDepartments(c.Request.Context(), snap, filtered, params.Scope)
 
 	c.JSON(http.StatusOK, result)
 }
@@ -81,15 +127,13 @@ func (h *DashboardHandler) DrillGroups(c *gin.Context) {
 		return
 	}
 
-	departments, flat, err := h.attendanceService.LoadFromJSON()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot load attendance"})
+	params := filterParams(c)
+	snap, ok := h.loadSnapshot(c, params)
+	if !ok {
 		return
 	}
-
-	params := services.ParseFilterParams(c.Request)
-	filtered := h.attendanceService.Filter(flat, params)
-	result := h.attendanceService.BuildDrillGroups(departments, filtered, department)
+	filtered := h.attendanceService.Filter(c.Request.Context(), snap, params)
+	result := h.attendanceService.BuildDrillGroups(c.Request.Context(), snap, filtered, department, params.Scope)
 
 	c.JSON(http.StatusOK, result)
 }
@@ -105,15 +149,13 @@ func (h *DashboardHandler) DrillStudents(c *gin.Context) {
 		return
 	}
 
-	_, flat, err := h.attendanceService.LoadFromJSON()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot load attendance"})
+	params := filterParams(c)
+	snap, ok := h.loadSnapshot(c, params)
+	if !ok {
 		return
 	}
-
-	params := services.ParseFilterParams(c.Request)
-	filtered := h.attendanceService.Filter(flat, params)
-	result := h.attendanceService.BuildDrillStudents(filtered, department, group)
+	filtered := h.attendanceService.Filter(c.Request.Context(), snap, params)
+	result := h.attendanceService.BuildDrillStudents(c.Request.Context(), filtered, department, group)
 
 	c.JSON(http.StatusOK, result)
 }
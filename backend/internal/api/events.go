@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"dashboard/internal/events"
+	"dashboard/internal/middleware"
+	"dashboard/internal/models"
+)
+
+// heartbeatInterval - период комментариев-пингов, чтобы прокси не рвали соединение
+const heartbeatInterval = 25 * time.Second
+
+// EventsHandler отдаёт события клиентам через Server-Sent Events
+type EventsHandler struct {
+	broker *events.Broker
+}
+
+// NewEventsHandler создаёт handler событий
+func NewEventsHandler(broker *events.Broker) *EventsHandler {
+	return &EventsHandler{broker: broker}
+}
+
+// Stream открывает поток событий
+// GET /api/events?types=refresh.completed,snapshot.activated
+func (h *EventsHandler) Stream(c *gin.Context) {
+	role := c.GetString("role")
+	scope := middleware.ScopeFromContext(c)
+
+	var types map[string]struct{}
+	if raw := strings.TrimSpace(c.Query("types")); raw != "" {
+		types = make(map[string]struct{})
+		for _, t := range strings.Split(raw, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types[t] = struct{}{}
+			}
+		}
+	}
+
+	filter := func(ev events.Event) bool {
+		if !ev.VisibleTo(role) {
+			return false
+		}
+		// Алерты получают только пользователи, в чью область они попадают
+		if a, ok := ev.Data.(models.Alert); ok && !scope.Allows(a.Department, a.Group) {
+			return false
+		}
+		if types != nil {
+			if _, ok := types[ev.Type]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	// При переподключении EventSource присылает ID последнего полученного события
+	lastID, _ := strconv.ParseInt(c.GetHeader("Last-Event-ID"), 10, 64)
+
+	sub := h.broker.Subscribe(filter, lastID)
+	defer h.broker.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case ev, ok := <-sub.C:
+			if !ok {
+				return false
+			}
+			return writeEvent(w, ev) == nil
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
+
+// writeEvent записывает событие в формате text/event-stream
+func writeEvent(w io.Writer, ev events.Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "id: "+strconv.FormatInt(ev.ID, 10)+"\nevent: "+ev.Type+"\ndata: "+string(payload)+"\n\n")
+	return err
+}
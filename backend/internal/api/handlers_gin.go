@@ -1,105 +1,78 @@
 package api
 
 import (
-	"log"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"dashboard/internal/database"
+	"dashboard/internal/middleware"
 	"dashboard/internal/scheduler"
 )
 
 // GinHandler содержит обработчики API для Gin
 type GinHandler struct {
-	scheduler         *scheduler.Scheduler
-	dbLoader          *database.Loader
-	lastRefresh       time.Time
-	refreshInProgress bool
+	runner   *scheduler.Runner
+	schedule *scheduler.Schedule
 }
 
-func NewGinHandler(scheduler *scheduler.Scheduler, dbLoader *database.Loader) *GinHandler {
+func NewGinHandler(runner *scheduler.Runner, schedule *scheduler.Schedule) *GinHandler {
 	return &GinHandler{
-		scheduler: scheduler,
-		dbLoader:  dbLoader,
+		runner:   runner,
+		schedule: schedule,
 	}
 }
 
-// RefreshData запускает ручное обновление данных
+// RefreshData ставит задачу ручного обновления данных в очередь
 // @Summary Ручное обновление данных
-// @Description Запускает конвертацию Excel файлов в JSON и загрузку в БД
+// @Description Ставит в очередь конвертацию Excel файлов в JSON и загрузку в БД, сразу возвращает ID задачи
 // @Tags admin
 // @Accept json
 // @Produce json
-// @Success 200 {object} map[string]interface{} "Данные успешно обновлены"
-// @Failure 409 {object} map[string]string "Обновление уже выполняется"
-// @Failure 500 {object} map[string]string "Ошибка обновления данных"
+// @Success 202 {object} scheduler.Job "Задача поставлена в очередь"
+// @Failure 409 {object} map[string]string "Очередь обновлений заполнена"
+// @Failure 503 {object} map[string]string "Сервер останавливается"
 // @Router /admin/refresh-data [post]
 func (h *GinHandler) RefreshData(c *gin.Context) {
-	// Проверяем, не выполняется ли уже обновление
-	if h.refreshInProgress {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "Обновление уже выполняется",
+	job, err := h.runner.Submit(c.Request.Context(), scheduler.TriggerManual)
+	if errors.Is(err, scheduler.ErrShuttingDown) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Сервер останавливается, обновление не принято",
 		})
 		return
 	}
-
-	h.refreshInProgress = true
-	defer func() {
-		h.refreshInProgress = false
-	}()
-
-	log.Println("[API] Запуск ручного обновления данных...")
-
-	// Запускаем обновление
-	if err := h.scheduler.RefreshData(); err != nil {
-		log.Printf("[API] Ошибка обновления данных: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Ошибка обновления данных",
-			"details": err.Error(),
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Обновление уже выполняется, очередь заполнена",
 		})
 		return
 	}
 
-	// Загружаем в БД (пути из конфига)
-	attendancePath := c.GetString("attendance_output")
-	statementPath := c.GetString("statement_output")
-	
-	if attendancePath != "" {
-		if err := h.dbLoader.LoadAttendance(attendancePath); err != nil {
-			log.Printf("[API] Предупреждение при загрузке посещаемости в БД: %v", err)
-		}
-	}
-	if statementPath != "" {
-		if err := h.dbLoader.LoadStatement(statementPath); err != nil {
-			log.Printf("[API] Предупреждение при загрузке ведомости в БД: %v", err)
-		}
-	}
-
-	h.lastRefresh = time.Now()
-
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Данные успешно обновлены",
-		"time":    h.lastRefresh.Format(time.RFC3339),
-	})
+	logger.InfoContext(c.Request.Context(), "Ручное обновление поставлено в очередь", "job_id", job.ID)
+	middleware.AuditChange(c, "refresh_job:"+job.ID, nil, gin.H{"trigger": job.Trigger})
+	c.JSON(http.StatusAccepted, job)
 }
 
-// GetRefreshStatus возвращает статус последнего обновления
+// GetRefreshStatus возвращает статус обновления
 // @Summary Статус обновления данных
-// @Description Возвращает информацию о последнем обновлении данных
+// @Description Возвращает текущую задачу с прогрессом по этапам, очередь, последнюю завершённую задачу и расписание со временем следующего запуска
 // @Tags admin
 // @Produce json
 // @Success 200 {object} map[string]interface{} "Статус обновления"
 // @Router /admin/refresh-status [get]
 func (h *GinHandler) GetRefreshStatus(c *gin.Context) {
+	st := h.runner.Status()
 	status := gin.H{
-		"in_progress": h.refreshInProgress,
+		"in_progress": st.InProgress,
+		"current":     st.Current,
+		"queue":       st.Queue,
+		"last_job":    st.LastJob,
+		"schedule":    h.schedule.Status(),
 	}
 
-	if !h.lastRefresh.IsZero() {
-		status["last_refresh"] = h.lastRefresh.Format(time.RFC3339)
-		status["last_refresh_ago"] = time.Since(h.lastRefresh).String()
+	if !st.LastSuccess.IsZero() {
+		status["last_refresh"] = st.LastSuccess.Format(time.RFC3339)
+		status["last_refresh_ago"] = time.Since(st.LastSuccess).Round(time.Second).String()
 	} else {
 		status["last_refresh"] = nil
 		status["last_refresh_ago"] = nil
@@ -108,35 +81,99 @@ func (h *GinHandler) GetRefreshStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, status)
 }
 
+// PauseSchedule приостанавливает плановое обновление
+// @Summary Приостановка расписания
+// @Description Приостанавливает запуск обновлений по расписанию до возобновления или перезапуска сервера. Ручное обновление остаётся доступным.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} scheduler.ScheduleStatus "Расписание"
+// @Router /admin/refresh-schedule/pause [post]
+func (h *GinHandler) PauseSchedule(c *gin.Context) {
+	before := h.schedule.Status()
+	st := h.schedule.Pause(actor(c))
+	middleware.AuditChange(c, "refresh_schedule", gin.H{"paused": before.Paused}, gin.H{"paused": st.Paused})
+	c.JSON(http.StatusOK, st)
+}
+
+// ResumeSchedule возобновляет плановое обновление
+// @Summary Возобновление расписания
+// @Description Возобновляет запуск обновлений по расписанию
+// @Tags admin
+// @Produce json
+// @Success 200 {object} scheduler.ScheduleStatus "Расписание"
+// @Router /admin/refresh-schedule/resume [post]
+func (h *GinHandler) ResumeSchedule(c *gin.Context) {
+	before := h.schedule.Status()
+	st := h.schedule.Resume()
+	middleware.AuditChange(c, "refresh_schedule", gin.H{"paused": before.Paused}, gin.H{"paused": st.Paused})
+	c.JSON(http.StatusOK, st)
+}
+
+// GetRefreshJob возвращает задачу обновления по ID
+// @Summary Задача обновления
+// @Description Возвращает статус и прогресс задачи обновления
+// @Tags admin
+// @Produce json
+// @Param id path string true "ID задачи"
+// @Success 200 {object} scheduler.Job "Задача"
+// @Failure 404 {object} map[string]string "Задача не найдена"
+// @Router /admin/refresh-jobs/{id} [get]
+func (h *GinHandler) GetRefreshJob(c *gin.Context) {
+	job, ok := h.runner.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Задача не найдена"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelRefreshJob отменяет задачу обновления
+// @Summary Отмена задачи обновления
+// @Description Отменяет выполняющуюся задачу или убирает её из очереди
+// @Tags admin
+// @Produce json
+// @Param id path string true "ID задачи"
+// @Success 202 {object} scheduler.Job "Отмена запрошена"
+// @Failure 404 {object} map[string]string "Задача не найдена"
+// @Failure 409 {object} map[string]string "Задача уже завершена"
+// @Router /admin/refresh-jobs/{id}/cancel [post]
+func (h *GinHandler) CancelRefreshJob(c *gin.Context) {
+	job, err := h.runner.Cancel(c.Param("id"))
+	switch {
+	case errors.Is(err, scheduler.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Задача не найдена"})
+		return
+	case errors.Is(err, scheduler.ErrJobFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": "Задача уже завершена", "job": job})
+		return
+	}
+
+	logger.InfoContext(c.Request.Context(), "Запрошена отмена задачи обновления", "job_id", job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
+
 // GetRefreshHistory возвращает историю обновлений
 // @Summary История обновлений
-// @Description Возвращает историю обновлений данных
+// @Description Возвращает завершённые задачи обновления, начиная с самой свежей
 // @Tags admin
 // @Produce json
 // @Success 200 {object} map[string]interface{} "История обновлений"
 // @Router /admin/refresh-history [get]
 func (h *GinHandler) GetRefreshHistory(c *gin.Context) {
-	// TODO: Реальная история из БД
-	history := []gin.H{
-		{
-			"time":    h.lastRefresh.Format(time.RFC3339),
-			"status":  "success",
-			"message": "Обновление выполнено успешно",
-		},
-	}
-
 	c.JSON(http.StatusOK, gin.H{
-		"history": history,
+		"history": h.runner.History(),
 	})
 }
 
-// HealthCheck проверяет работоспособность сервера
-// @Summary Health Check
-// @Description Проверяет работоспособность сервера
+// HealthCheck - зонд живости: процесс запущен и обрабатывает запросы.
+// Состояние данных и БД проверяет HealthHandler.Ready.
+// @Summary Liveness probe
+// @Description Проверяет, что сервер запущен. Не проверяет БД и данные - для этого /health/ready.
 // @Tags system
 // @Produce json
 // @Success 200 {object} map[string]string "Сервер работает"
 // @Router /health [get]
+// @Router /health/live [get]
 func (h *GinHandler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "ok",
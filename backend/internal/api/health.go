@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"dashboard/internal/health"
+)
+
+// HealthHandler - зонды готовности и подробный отчёт о состоянии
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// Ready проверяет готовность сервера принимать трафик
+// @Summary Readiness probe
+// @Description Возвращает 503, если БД недоступна, схема не применена или обязательные снимки данных отсутствуют либо устарели. Подробности проверок доступны только администратору.
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Сервер готов"
+// @Failure 503 {object} map[string]interface{} "Сервер не готов"
+// @Router /health/ready [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.checker.Run(c.Request.Context())
+	checks := make(map[string]string, len(report.Checks))
+	for _, ch := range report.Checks {
+		checks[ch.Name] = ch.Status
+	}
+
+	code, status := http.StatusOK, "ready"
+	if !report.Ready {
+		code, status = http.StatusServiceUnavailable, "not_ready"
+	}
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// Report возвращает подробный отчёт о состоянии сервера
+// @Summary Подробная проверка состояния
+// @Description БД и схема, наличие и возраст снимков данных, возраст входных файлов, расписание и итог последней задачи обновления
+// @Tags admin
+// @Produce json
+// @Success 200 {object} health.Report "Отчёт о состоянии"
+// @Router /admin/health [get]
+func (h *HealthHandler) Report(c *gin.Context) {
+	c.JSON(http.StatusOK, h.checker.Run(c.Request.Context()))
+}
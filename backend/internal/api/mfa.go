@@ -0,0 +1,212 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"dashboard/internal/auth"
+	"dashboard/internal/database"
+	"dashboard/internal/middleware"
+	"dashboard/internal/models"
+)
+
+// LoginMFA завершает вход кодом второго фактора (TOTP или код восстановления).
+// Если 2FA подключалась при входе, в ответе будут коды восстановления.
+// POST /api/login/mfa {"mfa_token": "...", "code": "123456"}
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var body struct {
+		Token string `json:"mfa_token"`
+		Code  string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Token == "" || body.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mfa_token and code are required"})
+		return
+	}
+
+	username, ok := h.mfa.ChallengeUsername(body.Token)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+		return
+	}
+	// Коды подбираются так же, как пароли: общие лимиты и блокировки
+	ip := c.ClientIP()
+	if ok, wait := h.guard.Check(ip, username); !ok {
+		middleware.TooManyRequests(c, wait)
+		return
+	}
+
+	user, recovery, err := h.mfa.Complete(body.Token, body.Code)
+	switch {
+	case errors.Is(err, auth.ErrMFACode):
+		h.guard.Failure(ip, username)
+		h.recordLogin(c, models.AuditLoginFailed, models.User{Username: username}, "totp")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	case errors.Is(err, auth.ErrMFAChallenge):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+		return
+	case errors.Is(err, auth.ErrMFANotSetUp):
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not set up, call /api/login/mfa/setup first"})
+		return
+	case errors.Is(err, auth.ErrUserDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+		return
+	case err != nil:
+		logger.ErrorContext(c.Request.Context(), "Ошибка второго шага входа", "username", username, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	h.guard.Success(ip, username)
+	h.recordLogin(c, models.AuditLogin, user, "password+totp")
+	var extra gin.H
+	if len(recovery) > 0 {
+		logger.InfoContext(c.Request.Context(), "Двухфакторная аутентификация подключена при входе", "username", user.Username)
+		extra = gin.H{"recovery_codes": recovery}
+	}
+	h.completeLogin(c, user, extra)
+}
+
+// LoginMFASetup выдаёт секрет TOTP, когда 2FA обязательна для роли,
+// а пользователь её ещё не подключил
+// POST /api/login/mfa/setup {"mfa_token": "..."}
+func (h *AuthHandler) LoginMFASetup(c *gin.Context) {
+	var body struct {
+		Token string `json:"mfa_token"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mfa_token is required"})
+		return
+	}
+	setup, err := h.mfa.SetupChallenge(body.Token)
+	respondMFA(c, setup, err)
+}
+
+// MFAHandler управляет вторым фактором текущего пользователя
+// и сбрасывает его администратором
+type MFAHandler struct {
+	mfa      *auth.MFAManager
+	sessions *auth.SessionManager
+}
+
+// NewMFAHandler создаёт handler двухфакторной аутентификации
+func NewMFAHandler(mfa *auth.MFAManager, sessions *auth.SessionManager) *MFAHandler {
+	return &MFAHandler{mfa: mfa, sessions: sessions}
+}
+
+// Setup выдаёт новый секрет TOTP и QR-код для приложения-аутентификатора
+// POST /api/me/2fa/setup
+func (h *MFAHandler) Setup(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	setup, err := h.mfa.Setup(uid)
+	respondMFA(c, setup, err)
+}
+
+// Enable включает 2FA после проверки первого кода.
+// Коды восстановления показываются только в этом ответе.
+// POST /api/me/2fa/enable {"code": "123456"}
+func (h *MFAHandler) Enable(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	code, ok := bindCode(c)
+	if !ok {
+		return
+	}
+	recovery, err := h.mfa.Enable(uid, code)
+	if err != nil {
+		respondMFA(c, nil, err)
+		return
+	}
+	logger.InfoContext(c.Request.Context(), "Двухфакторная аутентификация включена", "actor", actor(c))
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "recovery_codes": recovery})
+}
+
+// Disable отключает 2FA по текущему коду (или коду восстановления)
+// POST /api/me/2fa/disable {"code": "123456"}
+func (h *MFAHandler) Disable(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	code, ok := bindCode(c)
+	if !ok {
+		return
+	}
+	if err := h.mfa.Verify(uid, code); err != nil {
+		respondMFA(c, nil, err)
+		return
+	}
+	if _, err := h.mfa.Disable(uid); err != nil {
+		respondMFA(c, nil, err)
+		return
+	}
+	logger.InfoContext(c.Request.Context(), "Двухфакторная аутентификация отключена", "actor", actor(c))
+	c.JSON(http.StatusOK, gin.H{"enabled": false})
+}
+
+// Reset сбрасывает 2FA пользователя (потерян телефон) и завершает его сеансы
+// POST /api/admin/users/:id/2fa/reset
+func (h *MFAHandler) Reset(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+	user, err := h.mfa.Reset(id)
+	if err == nil {
+		logger.InfoContext(c.Request.Context(), "Двухфакторная аутентификация сброшена", "actor", actor(c), "username", user.Username)
+		middleware.AuditChange(c, "user:"+user.Username, nil, nil)
+		if _, err := h.sessions.RevokeUser(user.ID); err != nil {
+			logger.WarnContext(c.Request.Context(), "Не удалось завершить сеансы", "username", user.Username, "error", err)
+		}
+	}
+	respondUser(c, http.StatusOK, user, err)
+}
+
+// currentUserID возвращает id вошедшего пользователя. У входа без БД
+// и ключей API учётной записи нет, и 2FA для них недоступна.
+func currentUserID(c *gin.Context) (int, bool) {
+	uid := c.GetInt("uid")
+	if uid == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication requires a user account"})
+		return 0, false
+	}
+	return uid, true
+}
+
+func bindCode(c *gin.Context) (string, bool) {
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return "", false
+	}
+	return body.Code, true
+}
+
+func respondMFA(c *gin.Context, v interface{}, err error) {
+	switch {
+	case errors.Is(err, auth.ErrMFAChallenge):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+	case errors.Is(err, auth.ErrMFACode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+	case errors.Is(err, auth.ErrMFANotSetUp):
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not set up"})
+	case errors.Is(err, auth.ErrMFAAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+	case errors.Is(err, auth.ErrMFAEnforced):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is required for your role"})
+	case errors.Is(err, database.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case err != nil:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot update two-factor authentication", "details": err.Error()})
+	default:
+		c.JSON(http.StatusOK, v)
+	}
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"dashboard/internal/database"
+	"dashboard/internal/models"
+	"dashboard/internal/notify"
+)
+
+// NotificationsHandler управляет получателями email уведомлений (только для admin)
+type NotificationsHandler struct {
+	store    *database.NotificationStore
+	notifier *notify.Notifier
+}
+
+// NewNotificationsHandler создаёт handler уведомлений.
+// notifier может быть nil, если SMTP не настроен.
+func NewNotificationsHandler(store *database.NotificationStore, notifier *notify.Notifier) *NotificationsHandler {
+	return &NotificationsHandler{store: store, notifier: notifier}
+}
+
+// ListRecipients возвращает получателей
+// GET /api/admin/notifications/recipients
+func (h *NotificationsHandler) ListRecipients(c *gin.Context) {
+	recipients, err := h.store.ListRecipients()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot load recipients", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recipients": recipients, "email_enabled": h.notifier != nil})
+}
+
+// CreateRecipient добавляет получателя
+// POST /api/admin/notifications/recipients
+func (h *NotificationsHandler) CreateRecipient(c *gin.Context) {
+	r, ok := bindRecipient(c)
+	if !ok {
+		return
+	}
+	created, err := h.store.CreateRecipient(r)
+	if err == nil {
+		logger.InfoContext(c.Request.Context(), "Добавлен получатель уведомлений", "email", created.Email)
+	}
+	respondRecipient(c, http.StatusCreated, created, err)
+}
+
+// UpdateRecipient заменяет данные получателя
+// PUT /api/admin/notifications/recipients/:id
+func (h *NotificationsHandler) UpdateRecipient(c *gin.Context) {
+	id, ok := recipientID(c)
+	if !ok {
+		return
+	}
+	r, ok := bindRecipient(c)
+	if !ok {
+		return
+	}
+	updated, err := h.store.UpdateRecipient(id, r)
+	respondRecipient(c, http.StatusOK, updated, err)
+}
+
+// DeleteRecipient удаляет получателя
+// DELETE /api/admin/notifications/recipients/:id
+func (h *NotificationsHandler) DeleteRecipient(c *gin.Context) {
+	id, ok := recipientID(c)
+	if !ok {
+		return
+	}
+	if err := h.store.DeleteRecipient(id); err != nil {
+		respondRecipient(c, 0, models.Recipient{}, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries возвращает журнал доставки
+// GET /api/admin/notifications/log?limit=&offset=
+func (h *NotificationsHandler) ListDeliveries(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	deliveries, err := h.store.ListDeliveries(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot load delivery log", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// SendDigest отправляет сводку вне расписания
+// POST /api/admin/notifications/digest
+func (h *NotificationsHandler) SendDigest(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	sent, err := h.notifier.SendDigest()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot send digest", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sent": sent})
+}
+
+// SendTest отправляет тестовое письмо
+// POST /api/admin/notifications/test {"email": "..."}
+func (h *NotificationsHandler) SendTest(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !validEmail(req.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+		return
+	}
+	if err := h.notifier.SendTest(req.Email); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Cannot send email", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+func (h *NotificationsHandler) enabled(c *gin.Context) bool {
+	if h.notifier == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Email notifications are disabled (SMTP_HOST is not set)"})
+		return false
+	}
+	return true
+}
+
+// bindRecipient разбирает и проверяет получателя из тела запроса
+func bindRecipient(c *gin.Context) (models.Recipient, bool) {
+	r := models.Recipient{Alerts: true, Digest: true, Enabled: true}
+	if err := c.ShouldBindJSON(&r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return r, false
+	}
+	r.Email = strings.TrimSpace(r.Email)
+	if !validEmail(r.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+		return r, false
+	}
+	if r.Group != "" && r.Department == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Group requires department"})
+		return r, false
+	}
+	return r, true
+}
+
+// validEmail - упрощённая проверка адреса: одна @ и непустые части
+func validEmail(email string) bool {
+	at := strings.Index(email, "@")
+	return at > 0 && at == strings.LastIndex(email, "@") && at < len(email)-1 && !strings.ContainsAny(email, " \r\n<>")
+}
+
+func recipientID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recipient id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func respondRecipient(c *gin.Context, status int, r models.Recipient, err error) {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipient not found"})
+	case errors.Is(err, database.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "Recipient with this email and scope already exists"})
+	case err != nil:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot save recipient", "details": err.Error()})
+	default:
+		c.JSON(status, r)
+	}
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"dashboard/internal/auth"
+	"dashboard/internal/models"
+)
+
+// OIDCHandler обрабатывает вход через OpenID Connect (authorization code + PKCE).
+// После входа выдаются собственные токены дашборда, как при POST /api/login.
+type OIDCHandler struct {
+	auth         *AuthHandler
+	provider     *auth.OIDCProvider
+	postLoginURL string
+}
+
+// NewOIDCHandler создаёт handler входа через OIDC. provider может быть nil,
+// если OIDC не настроен.
+func NewOIDCHandler(authHandler *AuthHandler, provider *auth.OIDCProvider, postLoginURL string) *OIDCHandler {
+	return &OIDCHandler{auth: authHandler, provider: provider, postLoginURL: postLoginURL}
+}
+
+// Login перенаправляет браузер на страницу входа провайдера
+// GET /api/oidc/login
+func (h *OIDCHandler) Login(c *gin.Context) {
+	if h.provider == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "OIDC is not configured"})
+		return
+	}
+	target, err := h.provider.AuthCodeURL(c.Request.Context())
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Ошибка начала входа OIDC", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider unavailable"})
+		return
+	}
+	c.Redirect(http.StatusFound, target)
+}
+
+// Callback принимает код от провайдера и возвращает браузер в дашборд.
+// Токены передаются во фрагменте URL (#token=...), чтобы не попадать
+// в журналы веб-серверов; при ошибке во фрагменте будет error=...,
+// если нужен второй фактор - mfa_required=true и mfa_token=...
+// GET /api/oidc/callback?code=...&state=...
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if h.provider == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "OIDC is not configured"})
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		logger.WarnContext(c.Request.Context(), "Провайдер OIDC отклонил вход", "error", e, "description", c.Query("error_description"))
+		h.finish(c, url.Values{"error": {"access_denied"}})
+		return
+	}
+
+	user, err := h.provider.Exchange(c.Request.Context(), c.Query("state"), c.Query("code"))
+	switch {
+	case errors.Is(err, auth.ErrInvalidState):
+		h.finish(c, url.Values{"error": {"invalid_state"}})
+		return
+	case errors.Is(err, auth.ErrNoRole):
+		h.finish(c, url.Values{"error": {"no_role"}})
+		return
+	case errors.Is(err, auth.ErrUserDisabled):
+		h.finish(c, url.Values{"error": {"account_disabled"}})
+		return
+	case err != nil:
+		logger.WarnContext(c.Request.Context(), "Ошибка входа OIDC", "error", err)
+		h.finish(c, url.Values{"error": {"login_failed"}})
+		return
+	}
+
+	h.complete(c, user)
+}
+
+// complete завершает вход после ответа провайдера. Вход через OIDC не
+// отменяет второй фактор: если он нужен, вместо токенов во фрагменте
+// передаётся mfa_token для POST /api/login/mfa, как при входе по паролю.
+func (h *OIDCHandler) complete(c *gin.Context, user models.User) {
+	if h.auth.mfa.Required(user) {
+		ch := h.auth.mfa.Begin(user)
+		h.finish(c, url.Values{
+			"mfa_required":        {"true"},
+			"mfa_token":           {ch.Token},
+			"enrollment_required": {strconv.FormatBool(ch.Enroll)},
+			"expires_in":          {strconv.Itoa(ch.ExpiresIn)},
+		})
+		return
+	}
+
+	tokens, err := h.auth.issueTokens(c, user)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "Ошибка создания сеанса", "username", user.Username, "error", err)
+		h.finish(c, url.Values{"error": {"login_failed"}})
+		return
+	}
+	logger.InfoContext(c.Request.Context(), "Вход через OIDC", "username", user.Username, "role", user.Role)
+	h.auth.recordLogin(c, models.AuditLogin, user, "oidc")
+
+	values := url.Values{
+		"token":      {tokens.AccessToken},
+		"expires_in": {strconv.Itoa(tokens.ExpiresIn)},
+	}
+	if tokens.RefreshToken != "" {
+		values.Set("refresh_token", tokens.RefreshToken)
+	}
+	h.finish(c, values)
+}
+
+// finish возвращает браузер в дашборд с результатом во фрагменте URL
+func (h *OIDCHandler) finish(c *gin.Context, values url.Values) {
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, h.postLoginURL+"#"+values.Encode())
+}
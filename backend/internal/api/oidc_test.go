@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"dashboard/internal/auth"
+	"dashboard/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOIDCCallback_RequiresMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mfa := auth.NewMFAManager(nil, "Dashboard", []string{"admin"})
+	h := NewOIDCHandler(&AuthHandler{mfa: mfa}, nil, "/dashboard")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/oidc/callback", nil)
+	h.complete(c, models.User{ID: 1, Username: "ivanov", Role: "admin"})
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("Ожидался редирект, получено %d", w.Code)
+	}
+	location := w.Header().Get("Location")
+	fragment, err := url.ParseQuery(location[strings.Index(location, "#")+1:])
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка разбора фрагмента: %v", err)
+	}
+	if fragment.Get("token") != "" {
+		t.Error("Токен не должен выдаваться до проверки второго фактора")
+	}
+	if fragment.Get("mfa_required") != "true" || fragment.Get("mfa_token") == "" {
+		t.Errorf("Ожидался второй шаг входа, получено %s", location)
+	}
+	if fragment.Get("enrollment_required") != "true" {
+		t.Errorf("Без подключённой 2FA ожидалось подключение, получено %s", location)
+	}
+	if name, ok := mfa.ChallengeUsername(fragment.Get("mfa_token")); !ok || name != "ivanov" {
+		t.Errorf("mfa_token должен вести ко второму шагу для ivanov, получено %q", name)
+	}
+}
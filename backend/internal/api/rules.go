@@ -0,0 +1,178 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"dashboard/internal/database"
+	"dashboard/internal/middleware"
+	"dashboard/internal/models"
+	"dashboard/internal/services"
+)
+
+// RulesHandler управляет правилами алертов (только для admin)
+type RulesHandler struct {
+	rules             *database.RuleStore
+	alertService      *services.AlertService
+	attendanceService *services.AttendanceService
+}
+
+// NewRulesHandler создаёт handler правил алертов
+func NewRulesHandler(rules *database.RuleStore, alertService *services.AlertService, attendanceService *services.AttendanceService) *RulesHandler {
+	return &RulesHandler{
+		rules:             rules,
+		alertService:      alertService,
+		attendanceService: attendanceService,
+	}
+}
+
+// List возвращает действующие правила
+// GET /api/admin/alert-rules
+func (h *RulesHandler) List(c *gin.Context) {
+	rules, err := h.rules.List()
+	if err != nil || len(rules) == 0 {
+		// Правил в БД нет - показываем встроенное правило
+		c.JSON(http.StatusOK, gin.H{"rules": h.alertService.Rules(), "builtin": true})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rules": rules, "builtin": false})
+}
+
+// Get возвращает правило по ID
+// GET /api/admin/alert-rules/:id
+func (h *RulesHandler) Get(c *gin.Context) {
+	id, ok := ruleID(c)
+	if !ok {
+		return
+	}
+	rule, err := h.rules.Get(id)
+	respondRule(c, http.StatusOK, rule, err)
+}
+
+// Create создаёт правило
+// POST /api/admin/alert-rules
+func (h *RulesHandler) Create(c *gin.Context) {
+	rule, ok := bindRule(c)
+	if !ok {
+		return
+	}
+	created, err := h.rules.Create(rule)
+	if err == nil {
+		logger.InfoContext(c.Request.Context(), "Создано правило алертов", "rule", created.Name, "type", created.Type)
+		middleware.AuditChange(c, "alert_rule:"+strconv.Itoa(created.ID), nil, created)
+	}
+	respondRule(c, http.StatusCreated, created, err)
+}
+
+// Update заменяет правило
+// PUT /api/admin/alert-rules/:id
+func (h *RulesHandler) Update(c *gin.Context) {
+	id, ok := ruleID(c)
+	if !ok {
+		return
+	}
+	rule, ok := bindRule(c)
+	if !ok {
+		return
+	}
+	before, _ := h.rules.Get(id)
+	updated, err := h.rules.Update(id, rule)
+	if err == nil {
+		middleware.AuditChange(c, "alert_rule:"+strconv.Itoa(id), before, updated)
+	}
+	respondRule(c, http.StatusOK, updated, err)
+}
+
+// Delete удаляет правило
+// DELETE /api/admin/alert-rules/:id
+func (h *RulesHandler) Delete(c *gin.Context) {
+	id, ok := ruleID(c)
+	if !ok {
+		return
+	}
+	before, _ := h.rules.Get(id)
+	if err := h.rules.Delete(id); err != nil {
+		respondRule(c, 0, models.AlertRule{}, err)
+		return
+	}
+	middleware.AuditChange(c, "alert_rule:"+strconv.Itoa(id), before, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// Preview проверяет правило по текущим данным без сохранения алертов
+// POST /api/admin/alert-rules/preview
+func (h *RulesHandler) Preview(c *gin.Context) {
+	rule, ok := bindRule(c)
+	if !ok {
+		return
+	}
+	snap, err := h.attendanceService.Snapshot(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot load attendance"})
+		return
+	}
+
+	matches := h.alertService.Preview(c.Request.Context(), rule, snap)
+	if matches == nil {
+		matches = []models.Alert{}
+	}
+	c.JSON(http.StatusOK, gin.H{"count": len(matches), "alerts": matches})
+}
+
+// Evaluate запускает проверку всех правил по текущим данным
+// POST /api/admin/alert-rules/evaluate
+func (h *RulesHandler) Evaluate(c *gin.Context) {
+	snap, err := h.attendanceService.Snapshot(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Cannot load attendance"})
+		return
+	}
+
+	created, err := h.alertService.Evaluate(c.Request.Context(), snap)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot evaluate alerts", "details": err.Error()})
+		return
+	}
+	if created == nil {
+		created = []models.Alert{}
+	}
+	c.JSON(http.StatusOK, gin.H{"created": created})
+}
+
+// bindRule разбирает и проверяет правило из тела запроса
+func bindRule(c *gin.Context) (models.AlertRule, bool) {
+	rule := models.AlertRule{Enabled: true}
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return rule, false
+	}
+	if err := services.ValidateRule(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule", "details": err.Error()})
+		return rule, false
+	}
+	return rule, true
+}
+
+func ruleID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func respondRule(c *gin.Context, status int, rule models.AlertRule, err error) {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rule not found"})
+	case errors.Is(err, database.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "Rule with this name already exists"})
+	case err != nil:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot save rule", "details": err.Error()})
+	default:
+		c.JSON(status, rule)
+	}
+}
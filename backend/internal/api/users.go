@@ -0,0 +1,370 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"dashboard/internal/auth"
+	"dashboard/internal/database"
+	"dashboard/internal/middleware"
+	"dashboard/internal/models"
+)
+
+// UsersHandler управляет учётными записями и их сеансами (только для admin)
+type UsersHandler struct {
+	users    *database.UserStore
+	sessions *database.SessionStore
+	manager  *auth.SessionManager
+}
+
+// NewUsersHandler создаёт handler пользователей
+func NewUsersHandler(users *database.UserStore, sessions *database.SessionStore, manager *auth.SessionManager) *UsersHandler {
+	return &UsersHandler{users: users, sessions: sessions, manager: manager}
+}
+
+// List возвращает пользователей
+// GET /api/admin/users
+func (h *UsersHandler) List(c *gin.Context) {
+	users, err := h.users.List()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot load users", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// Get возвращает пользователя по ID
+// GET /api/admin/users/:id
+func (h *UsersHandler) Get(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+	user, err := h.users.Get(id)
+	respondUser(c, http.StatusOK, user, err)
+}
+
+// Create создаёт пользователя
+// POST /api/admin/users {"username": "...", "password": "...", "role": "curator", "groups": ["11-ис"]}
+func (h *UsersHandler) Create(c *gin.Context) {
+	var body struct {
+		Username    string   `json:"username"`
+		Password    string   `json:"password"`
+		Role        string   `json:"role"`
+		Departments []string `json:"departments"`
+		Groups      []string `json:"groups"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+	body.Username = strings.TrimSpace(body.Username)
+	if body.Username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username is required"})
+		return
+	}
+	if body.Role == "" {
+		body.Role = models.RoleViewer
+	}
+	departments, groups, ok := validateScope(c, body.Role, body.Departments, body.Groups)
+	if !ok {
+		return
+	}
+	hash, ok := hashPassword(c, body.Password)
+	if !ok {
+		return
+	}
+
+	user, err := h.users.Create(models.User{
+		Username:     body.Username,
+		PasswordHash: hash,
+		Role:         body.Role,
+		Departments:  departments,
+		Groups:       groups,
+	})
+	if err == nil {
+		logger.InfoContext(c.Request.Context(), "Пользователь создан", "actor", actor(c), "username", user.Username, "role", user.Role)
+		middleware.AuditChange(c, "user:"+user.Username, nil, user)
+	}
+	respondUser(c, http.StatusCreated, user, err)
+}
+
+// Disable блокирует пользователя
+// POST /api/admin/users/:id/disable
+func (h *UsersHandler) Disable(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+	if id == c.GetInt("uid") {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot disable yourself"})
+		return
+	}
+	if !h.keepsAdmin(c, id) {
+		return
+	}
+	user, err := h.users.SetDisabled(id, true)
+	if err == nil {
+		logger.InfoContext(c.Request.Context(), "Пользователь заблокирован", "actor", actor(c), "username", user.Username)
+		middleware.AuditChange(c, "user:"+user.Username, nil, gin.H{"disabled": true})
+		h.revokeAll(c.Request.Context(), user)
+	}
+	respondUser(c, http.StatusOK, user, err)
+}
+
+// Enable разблокирует пользователя
+// POST /api/admin/users/:id/enable
+func (h *UsersHandler) Enable(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+	user, err := h.users.SetDisabled(id, false)
+	if err == nil {
+		middleware.AuditChange(c, "user:"+user.Username, nil, gin.H{"disabled": false})
+	}
+	respondUser(c, http.StatusOK, user, err)
+}
+
+// ResetPassword задаёт новый пароль
+// POST /api/admin/users/:id/password {"password": "..."}
+func (h *UsersHandler) ResetPassword(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+	current, err := h.users.Get(id)
+	if err != nil {
+		respondUser(c, http.StatusOK, current, err)
+		return
+	}
+	if current.Source != models.SourceLocal {
+		// Пароль таких пользователей проверяет каталог
+		c.JSON(http.StatusConflict, gin.H{"error": "Password is managed by the directory"})
+		return
+	}
+	hash, ok := hashPassword(c, body.Password)
+	if !ok {
+		return
+	}
+	user, err := h.users.SetPassword(id, hash)
+	if err == nil {
+		logger.InfoContext(c.Request.Context(), "Пароль пользователя изменён", "actor", actor(c), "username", user.Username)
+		h.revokeAll(c.Request.Context(), user)
+	}
+	respondUser(c, http.StatusOK, user, err)
+}
+
+// SetRole назначает роль и область видимости
+// PUT /api/admin/users/:id/role {"role": "department_head", "departments": ["Отделение 1"]}
+func (h *UsersHandler) SetRole(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		Role        string   `json:"role"`
+		Departments []string `json:"departments"`
+		Groups      []string `json:"groups"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+	departments, groups, ok := validateScope(c, body.Role, body.Departments, body.Groups)
+	if !ok {
+		return
+	}
+	if body.Role != models.RoleAdmin && !h.keepsAdmin(c, id) {
+		return
+	}
+	before, _ := h.users.Get(id)
+	user, err := h.users.SetRole(id, body.Role, departments, groups)
+	if err == nil {
+		logger.InfoContext(c.Request.Context(), "Роль пользователя изменена", "actor", actor(c), "username", user.Username, "role", user.Role)
+		middleware.AuditChange(c, "user:"+user.Username, before.Scope(), user.Scope())
+		// Роль и область видимости зашиты в access-токен: без отзыва сеансов
+		// прежние права действовали бы до его истечения
+		was, now := before.Scope(), user.Scope()
+		if before.Role != user.Role || !slices.Equal(was.Departments, now.Departments) ||
+			!slices.Equal(was.Groups, now.Groups) {
+			h.revokeAll(c.Request.Context(), user)
+		}
+	}
+	respondUser(c, http.StatusOK, user, err)
+}
+
+// Sessions возвращает активные сеансы пользователя
+// GET /api/admin/users/:id/sessions
+func (h *UsersHandler) Sessions(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+	sessions, err := h.sessions.ListActive(id)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot load sessions", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSessions завершает все сеансы пользователя
+// DELETE /api/admin/users/:id/sessions
+func (h *UsersHandler) RevokeSessions(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+	n, err := h.manager.RevokeUser(id)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot revoke sessions", "details": err.Error()})
+		return
+	}
+	logger.InfoContext(c.Request.Context(), "Сеансы пользователя завершены", "actor", actor(c), "user_id", id, "count", n)
+	c.JSON(http.StatusOK, gin.H{"revoked": n})
+}
+
+// RevokeSession завершает один сеанс пользователя
+// DELETE /api/admin/users/:id/sessions/:sid
+func (h *UsersHandler) RevokeSession(c *gin.Context) {
+	id, ok := userID(c)
+	if !ok {
+		return
+	}
+	sess, err := h.sessions.Get(c.Param("sid"))
+	if err == nil && (sess.UserID != id || sess.RevokedAt != nil) {
+		err = database.ErrNotFound
+	}
+	if err == nil {
+		err = h.manager.RevokeSession(sess.ID)
+	}
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+	case err != nil:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot revoke session", "details": err.Error()})
+	default:
+		logger.InfoContext(c.Request.Context(), "Сеанс пользователя завершён", "actor", actor(c), "session", sess.ID, "user_id", id)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// revokeAll завершает сеансы после блокировки или смены пароля
+func (h *UsersHandler) revokeAll(ctx context.Context, user models.User) {
+	if _, err := h.manager.RevokeUser(user.ID); err != nil {
+		logger.WarnContext(ctx, "Не удалось завершить сеансы", "username", user.Username, "error", err)
+	}
+}
+
+// keepsAdmin не даёт заблокировать или понизить последнего администратора
+func (h *UsersHandler) keepsAdmin(c *gin.Context, id int) bool {
+	user, err := h.users.Get(id)
+	if err != nil {
+		respondUser(c, 0, user, err)
+		return false
+	}
+	if user.Role != models.RoleAdmin || user.Disabled {
+		return true
+	}
+	n, err := h.users.CountActiveAdmins()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot check admins", "details": err.Error()})
+		return false
+	}
+	if n <= 1 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot remove the last active admin"})
+		return false
+	}
+	return true
+}
+
+// validateScope проверяет роль и её область видимости: заведующему нужны
+// отделения, куратору и преподавателю - группы. Для остальных ролей
+// область не хранится.
+func validateScope(c *gin.Context, role string, departments, groups []string) ([]string, []string, bool) {
+	if !models.ValidRole(role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role"})
+		return nil, nil, false
+	}
+	departments, groups = cleanList(departments), cleanList(groups)
+	switch role {
+	case models.RoleDepartmentHead:
+		if len(departments) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Role department_head requires departments"})
+			return nil, nil, false
+		}
+		groups = nil
+	case models.RoleCurator, models.RoleTeacher:
+		if len(groups) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Role " + role + " requires groups"})
+			return nil, nil, false
+		}
+		departments = nil
+	default:
+		departments, groups = nil, nil
+	}
+	return departments, groups, true
+}
+
+// cleanList убирает пустые значения и повторы
+func cleanList(values []string) []string {
+	var out []string
+	seen := make(map[string]struct{})
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if _, ok := seen[v]; v == "" || ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	return out
+}
+
+func hashPassword(c *gin.Context, password string) (string, bool) {
+	if err := auth.ValidatePassword(password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid password", "details": err.Error()})
+		return "", false
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return "", false
+	}
+	return hash, true
+}
+
+func userID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func respondUser(c *gin.Context, status int, user models.User, err error) {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, database.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "User with this username already exists"})
+	case err != nil:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot save user", "details": err.Error()})
+	default:
+		c.JSON(status, user)
+	}
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"dashboard/internal/database"
+	"dashboard/internal/models"
+	"dashboard/internal/webhooks"
+)
+
+// WebhooksHandler управляет подписками внешних систем на события (только для admin)
+type WebhooksHandler struct {
+	store      *database.WebhookStore
+	dispatcher *webhooks.Dispatcher
+}
+
+// NewWebhooksHandler создаёт handler webhooks
+func NewWebhooksHandler(store *database.WebhookStore, dispatcher *webhooks.Dispatcher) *WebhooksHandler {
+	return &WebhooksHandler{store: store, dispatcher: dispatcher}
+}
+
+// List возвращает подписки (без секретов)
+// GET /api/admin/webhooks
+func (h *WebhooksHandler) List(c *gin.Context) {
+	hooks, err := h.store.List()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot load webhooks", "details": err.Error()})
+		return
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": hooks, "event_types": webhooks.EventTypes})
+}
+
+// Get возвращает подписку по ID (без секрета)
+// GET /api/admin/webhooks/:id
+func (h *WebhooksHandler) Get(c *gin.Context) {
+	id, ok := webhookID(c, "id")
+	if !ok {
+		return
+	}
+	w, err := h.store.Get(id)
+	w.Secret = ""
+	respondWebhook(c, http.StatusOK, w, err)
+}
+
+// Create добавляет подписку. Если секрет не передан, он генерируется;
+// секрет возвращается только в ответе на создание.
+// POST /api/admin/webhooks
+func (h *WebhooksHandler) Create(c *gin.Context) {
+	w, ok := bindWebhook(c)
+	if !ok {
+		return
+	}
+	if w.Secret == "" {
+		w.Secret = webhooks.NewSecret()
+	}
+	created, err := h.store.Create(w)
+	if err == nil {
+		logger.InfoContext(c.Request.Context(), "Добавлен webhook", "name", created.Name, "url", created.URL)
+	}
+	respondWebhook(c, http.StatusCreated, created, err)
+}
+
+// Update заменяет подписку. Пустой секрет оставляет прежний.
+// PUT /api/admin/webhooks/:id
+func (h *WebhooksHandler) Update(c *gin.Context) {
+	id, ok := webhookID(c, "id")
+	if !ok {
+		return
+	}
+	w, ok := bindWebhook(c)
+	if !ok {
+		return
+	}
+	updated, err := h.store.Update(id, w)
+	updated.Secret = ""
+	respondWebhook(c, http.StatusOK, updated, err)
+}
+
+// Delete удаляет подписку
+// DELETE /api/admin/webhooks/:id
+func (h *WebhooksHandler) Delete(c *gin.Context) {
+	id, ok := webhookID(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.store.Delete(id); err != nil {
+		respondWebhook(c, 0, models.Webhook{}, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Deliveries возвращает историю доставки подписки
+// GET /api/admin/webhooks/:id/deliveries?limit=&offset=
+func (h *WebhooksHandler) Deliveries(c *gin.Context) {
+	id, ok := webhookID(c, "id")
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	deliveries, err := h.store.ListDeliveries(id, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot load deliveries", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// Replay повторно отправляет доставку
+// POST /api/admin/webhooks/deliveries/:delivery_id/replay
+func (h *WebhooksHandler) Replay(c *gin.Context) {
+	id, ok := webhookID(c, "delivery_id")
+	if !ok {
+		return
+	}
+	delivery, err := h.dispatcher.Replay(id)
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+	case err != nil:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot replay delivery", "details": err.Error()})
+	default:
+		c.JSON(http.StatusAccepted, delivery)
+	}
+}
+
+// bindWebhook разбирает и проверяет подписку из тела запроса
+func bindWebhook(c *gin.Context) (models.Webhook, bool) {
+	w := models.Webhook{Enabled: true}
+	if err := c.ShouldBindJSON(&w); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return w, false
+	}
+	if w.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		return w, false
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid URL"})
+		return w, false
+	}
+	for _, e := range w.Events {
+		if !knownEvent(e) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event type", "details": e})
+			return w, false
+		}
+	}
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+	return w, true
+}
+
+func knownEvent(eventType string) bool {
+	for _, t := range webhooks.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func webhookID(c *gin.Context, param string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(param))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid id"})
+		return 0, false
+	}
+	return id, true
+}
+
+func respondWebhook(c *gin.Context, status int, w models.Webhook, err error) {
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+	case errors.Is(err, database.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": "Webhook with this name already exists"})
+	case err != nil:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot save webhook", "details": err.Error()})
+	default:
+		c.JSON(status, w)
+	}
+}
@@ -0,0 +1,84 @@
+// Package audit записывает действия пользователей и сервисов в журнал аудита
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"unicode/utf8"
+
+	"dashboard/internal/logging"
+	"dashboard/internal/models"
+)
+
+var logger = logging.For("audit")
+
+// Размеры полей audit_events (в символах)
+const (
+	maxActor  = 150
+	maxTarget = 255
+	maxIP     = 64
+)
+
+// Store - хранилище журнала (database.AuditStore)
+type Store interface {
+	Available() bool
+	Append(e models.AuditEvent) (models.AuditEvent, error)
+}
+
+// Recorder добавляет события в журнал. Без БД события пишутся в лог
+// (component=audit), чтобы не потерять их совсем.
+type Recorder struct {
+	store Store
+}
+
+// NewRecorder создаёт запись журнала аудита. store может быть nil.
+func NewRecorder(store Store) *Recorder {
+	return &Recorder{store: store}
+}
+
+// Record сохраняет событие. Ошибки записи не прерывают действие
+// пользователя, но попадают в лог вместе с самим событием.
+func (r *Recorder) Record(ctx context.Context, e models.AuditEvent) {
+	if r == nil {
+		return
+	}
+	// Логин и адрес при неудачном входе присылает кто угодно: длинное
+	// значение не должно мешать записи в журнал
+	e.Actor = truncate(e.Actor, maxActor)
+	e.Target = truncate(e.Target, maxTarget)
+	e.IP = truncate(e.IP, maxIP)
+	if r.store == nil || !r.store.Available() {
+		logEvent(ctx, e)
+		return
+	}
+	if _, err := r.store.Append(e); err != nil {
+		logger.ErrorContext(ctx, "Ошибка записи в журнал аудита", "error", err)
+		logEvent(ctx, e)
+	}
+}
+
+// Payload сериализует состояние объекта до или после изменения.
+// nil и ошибки сериализации дают пустое значение.
+func Payload(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return nil
+	}
+	return b
+}
+
+func logEvent(ctx context.Context, e models.AuditEvent) {
+	logger.InfoContext(ctx, "Событие аудита", "actor", e.Actor, "action", e.Action, "target", e.Target,
+		"role", e.Role, "ip", e.IP, "status", e.Status)
+}
+
+// truncate обрезает строку до max символов
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyPrefix - начало всех ключей API, чтобы отличать их от JWT
+const APIKeyPrefix = "dk_"
+
+// GenerateAPIKey создаёт ключ вида dk_<prefix>_<secret>. Клиенту ключ
+// показывается один раз, в БД сохраняются только prefix и хэш.
+func GenerateAPIKey() (key, prefix, hash string) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand: " + err.Error())
+	}
+	prefix = hex.EncodeToString(b)
+	key = APIKeyPrefix + prefix + "_" + randomString(32)
+	return key, prefix, hashToken(key)
+}
+
+// ParseAPIKey возвращает открытую часть ключа для поиска в БД
+func ParseAPIKey(key string) (prefix string, ok bool) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(key[len(APIKeyPrefix):], "_")
+	if !ok || len(prefix) != 8 || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+// CheckAPIKey сравнивает ключ с сохранённым хэшем за постоянное время
+func CheckAPIKey(key, hash string) bool {
+	return equalHash(hashToken(key), hash)
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAPIKeyRoundTrip(t *testing.T) {
+	key, prefix, hash := GenerateAPIKey()
+	if !strings.HasPrefix(key, APIKeyPrefix+prefix+"_") {
+		t.Fatalf("неожиданный формат ключа: %s", key)
+	}
+	if strings.Contains(hash, key) || len(hash) != 64 {
+		t.Errorf("в БД должен храниться только SHA-256 ключа")
+	}
+
+	parsed, ok := ParseAPIKey(key)
+	if !ok || parsed != prefix {
+		t.Errorf("ParseAPIKey: получено %q %v, ожидалось %q", parsed, ok, prefix)
+	}
+	if !CheckAPIKey(key, hash) {
+		t.Error("ключ должен совпадать со своим хэшем")
+	}
+	if CheckAPIKey(key+"x", hash) {
+		t.Error("изменённый ключ не должен проходить проверку")
+	}
+
+	for _, bad := range []string{"", "eyJhbGciOi.x.y", "dk_short_secret", "dk_12345678"} {
+		if _, ok := ParseAPIKey(bad); ok {
+			t.Errorf("%q: ключ не должен разбираться", bad)
+		}
+	}
+}
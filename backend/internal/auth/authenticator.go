@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"errors"
+
+	"dashboard/internal/logging"
+	"dashboard/internal/models"
+)
+
+var logger = logging.For("auth")
+
+// Ошибки входа
+var (
+	ErrInvalidCredentials = errors.New("неверный логин или пароль")
+	ErrNoRole             = errors.New("пользователю не назначена роль в дашборде")
+	ErrLoginDisabled      = errors.New("вход не настроен")
+)
+
+// Authenticator проверяет логин и пароль в одном источнике учётных записей
+type Authenticator interface {
+	Name() string
+	Authenticate(username, password string) (models.User, error)
+}
+
+// Chain опрашивает источники по порядку, пока один из них не примет пароль.
+// Неверный пароль или недоступность источника передают проверку следующему,
+// поэтому локальные учётные записи работают и при отказе каталога.
+type Chain []Authenticator
+
+// Name возвращает имя цепочки
+func (c Chain) Name() string {
+	return "chain"
+}
+
+// Authenticate возвращает пользователя первого принявшего пароль источника.
+// При отказе всех источников возвращается наиболее информативная ошибка:
+// блокировка, отсутствие роли, неверный пароль, затем ошибка источника.
+func (c Chain) Authenticate(username, password string) (models.User, error) {
+	if len(c) == 0 {
+		return models.User{}, ErrLoginDisabled
+	}
+
+	var result error
+	for _, a := range c {
+		user, err := a.Authenticate(username, password)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, ErrInvalidCredentials) && !errors.Is(err, ErrUserDisabled) && !errors.Is(err, ErrNoRole) {
+			logger.Error("Ошибка источника учётных записей", "source", a.Name(), "error", err)
+		}
+		if result == nil || errorRank(err) < errorRank(result) {
+			result = err
+		}
+	}
+	return models.User{}, result
+}
+
+func errorRank(err error) int {
+	switch {
+	case errors.Is(err, ErrUserDisabled):
+		return 0
+	case errors.Is(err, ErrNoRole):
+		return 1
+	case errors.Is(err, ErrInvalidCredentials):
+		return 2
+	}
+	return 3
+}
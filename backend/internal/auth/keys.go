@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingMethod - единственный допустимый алгоритм подписи токенов
+var signingMethod = jwt.SigningMethodHS256
+
+// clockSkew - допустимое расхождение часов при проверке exp/nbf
+const clockSkew = 30 * time.Second
+
+// KeySet - набор ключей подписи JWT. Новые токены подписываются активным
+// ключом, а проверяются любым ключом из набора по заголовку kid. Так ключ
+// можно сменить, не разлогинивая пользователей: старый ключ остаётся
+// в наборе, пока не истекут подписанные им токены.
+type KeySet struct {
+	keys     map[string][]byte
+	active   string
+	issuer   string
+	audience string
+}
+
+// NewKeySet создаёт набор ключей. active - kid ключа для подписи.
+func NewKeySet(keys map[string]string, active, issuer, audience string) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("не задано ни одного ключа подписи JWT")
+	}
+	ks := &KeySet{
+		keys:     make(map[string][]byte, len(keys)),
+		active:   active,
+		issuer:   issuer,
+		audience: audience,
+	}
+	for kid, secret := range keys {
+		if kid == "" || secret == "" {
+			return nil, fmt.Errorf("пустой kid или ключ подписи JWT")
+		}
+		ks.keys[kid] = []byte(secret)
+	}
+	if _, ok := ks.keys[active]; !ok {
+		return nil, fmt.Errorf("активный ключ JWT %q не найден среди ключей", active)
+	}
+	return ks, nil
+}
+
+// Sign подписывает токен активным ключом, добавляя iss, aud и nbf
+func (ks *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	claims["iss"] = ks.issuer
+	claims["aud"] = ks.audience
+	if _, ok := claims["nbf"]; !ok {
+		claims["nbf"] = time.Now().Unix()
+	}
+	token := jwt.NewWithClaims(signingMethod, claims)
+	token.Header["kid"] = ks.active
+	return token.SignedString(ks.keys[ks.active])
+}
+
+// Parse проверяет подпись, алгоритм, kid, iss, aud, exp и nbf токена
+func (ks *KeySet) Parse(tokenStr string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenStr, ks.keyFunc,
+		jwt.WithValidMethods([]string{signingMethod.Alg()}),
+		jwt.WithIssuer(ks.issuer),
+		jwt.WithAudience(ks.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("недействительный токен")
+	}
+	return claims, nil
+}
+
+func (ks *KeySet) keyFunc(t *jwt.Token) (interface{}, error) {
+	// Алгоритм уже проверен WithValidMethods, проверяем ещё раз на случай
+	// вызова keyFunc в обход Parse
+	if t.Method != signingMethod {
+		return nil, fmt.Errorf("недопустимый алгоритм подписи: %v", t.Header["alg"])
+	}
+	kid, _ := t.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("неизвестный ключ подписи: %q", kid)
+	}
+	return key, nil
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func claimsFor(role string) jwt.MapClaims {
+	return jwt.MapClaims{"role": role, "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func TestKeySet_RotationKeepsOldTokensValid(t *testing.T) {
+	old, _ := NewKeySet(map[string]string{"k1": "first-secret-0123456789abcdef"}, "k1", "dashboard", "dashboard-api")
+	token, err := old.Sign(claimsFor("admin"))
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+
+	rotated, _ := NewKeySet(map[string]string{
+		"k1": "first-secret-0123456789abcdef",
+		"k2": "second-secret-0123456789abcdef",
+	}, "k2", "dashboard", "dashboard-api")
+	if _, err := rotated.Parse(token); err != nil {
+		t.Errorf("Токен старого ключа должен приниматься после ротации: %v", err)
+	}
+
+	fresh, _ := rotated.Sign(claimsFor("admin"))
+	if _, err := old.Parse(fresh); err == nil {
+		t.Error("Токен неизвестного ключа должен отклоняться")
+	}
+}
+
+func TestKeySet_RejectsForeignTokens(t *testing.T) {
+	ks, _ := NewKeySet(map[string]string{"k1": "secret-0123456789abcdef"}, "k1", "dashboard", "dashboard-api")
+
+	// Токен с другим алгоритмом
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+		"iss": "dashboard", "aud": "dashboard-api", "exp": time.Now().Add(time.Minute).Unix(),
+	})
+	none.Header["kid"] = "k1"
+	raw, _ := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if _, err := ks.Parse(raw); err == nil {
+		t.Error("Токен с alg=none должен отклоняться")
+	}
+
+	// Токен другого издателя
+	other, _ := NewKeySet(map[string]string{"k1": "secret-0123456789abcdef"}, "k1", "portal", "dashboard-api")
+	token, _ := other.Sign(claimsFor("admin"))
+	if _, err := ks.Parse(token); err == nil {
+		t.Error("Токен с чужим iss должен отклоняться")
+	}
+
+	// Токен, который ещё не действует
+	future := claimsFor("admin")
+	future["nbf"] = time.Now().Add(time.Hour).Unix()
+	token, _ = ks.Sign(future)
+	if _, err := ks.Parse(token); err == nil {
+		t.Error("Токен с nbf в будущем должен отклоняться")
+	}
+
+	// Токен без срока действия
+	token, _ = ks.Sign(jwt.MapClaims{"role": "admin"})
+	if _, err := ks.Parse(token); err == nil {
+		t.Error("Токен без exp должен отклоняться")
+	}
+}
+
+func TestNewKeySet_RequiresActiveKey(t *testing.T) {
+	if _, err := NewKeySet(map[string]string{"k1": "s"}, "k2", "", ""); err == nil {
+		t.Error("Ожидалась ошибка для отсутствующего активного ключа")
+	}
+}
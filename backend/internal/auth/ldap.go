@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"dashboard/internal/database"
+	"dashboard/internal/models"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAPConfig - параметры подключения к каталогу (OpenLDAP, glauth, Active Directory)
+type LDAPConfig struct {
+	URL                string // ldap://host:389 или ldaps://host:636
+	StartTLS           bool
+	InsecureSkipVerify bool
+	BindDN             string // сервисная учётная запись для поиска; пусто - анонимный поиск
+	BindPassword       string
+	BaseDN             string
+	UserFilter         string // %s заменяется экранированным логином, например (uid=%s) или (sAMAccountName=%s)
+	GroupAttribute     string // атрибут с группами пользователя, обычно memberOf
+	Timeout            time.Duration
+	Mappings           []GroupMapping
+}
+
+// ExternalUsers хранит учётные записи из каталога, чтобы для них
+// работали сеансы, блокировка и журнал входов
+type ExternalUsers interface {
+	SyncExternal(source, username, role string, departments, groups []string) (models.User, error)
+}
+
+// LDAPAuthenticator проверяет пароль привязкой (bind) к каталогу:
+// находит пользователя сервисной учётной записью, затем выполняет bind
+// с его DN и паролем. Роль и область видимости берутся из групп.
+type LDAPAuthenticator struct {
+	cfg   LDAPConfig
+	users ExternalUsers
+}
+
+// NewLDAPAuthenticator создаёт проверку через LDAP. users может быть nil,
+// если БД не подключена: тогда пользователь не сохраняется.
+func NewLDAPAuthenticator(cfg LDAPConfig, users ExternalUsers) (*LDAPAuthenticator, error) {
+	if cfg.URL == "" || cfg.BaseDN == "" {
+		return nil, fmt.Errorf("для LDAP нужны URL и базовый DN")
+	}
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = "(uid=%s)"
+	}
+	if !strings.Contains(cfg.UserFilter, "%s") {
+		return nil, fmt.Errorf("фильтр пользователей LDAP должен содержать %%s")
+	}
+	if cfg.GroupAttribute == "" {
+		cfg.GroupAttribute = "memberOf"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if len(cfg.Mappings) == 0 {
+		return nil, fmt.Errorf("не задано ни одного сопоставления групп LDAP и ролей")
+	}
+	for _, m := range cfg.Mappings {
+		if !models.ValidRole(m.Role) {
+			return nil, fmt.Errorf("неизвестная роль %q для группы %s", m.Role, m.Group)
+		}
+	}
+	return &LDAPAuthenticator{cfg: cfg, users: users}, nil
+}
+
+// Name возвращает имя источника
+func (a *LDAPAuthenticator) Name() string {
+	return models.SourceLDAP
+}
+
+// Authenticate проверяет пароль в каталоге и синхронизирует пользователя с БД
+func (a *LDAPAuthenticator) Authenticate(username, password string) (models.User, error) {
+	// Пустой пароль даёт в LDAP успешную анонимную привязку
+	if username == "" || password == "" {
+		return models.User{}, ErrInvalidCredentials
+	}
+
+	groups, err := a.lookup(username, password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	role, departments, scopeGroups, ok := ResolveRole(a.cfg.Mappings, groups)
+	if !ok {
+		return models.User{}, ErrNoRole
+	}
+
+	if a.users == nil {
+		return models.User{
+			Username:    username,
+			Role:        role,
+			Departments: departments,
+			Groups:      scopeGroups,
+			Source:      models.SourceLDAP,
+		}, nil
+	}
+
+	user, err := a.users.SyncExternal(models.SourceLDAP, username, role, departments, scopeGroups)
+	if errors.Is(err, database.ErrDuplicate) {
+		// Логин занят локальной учётной записью - её проверит следующий источник
+		return models.User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	if user.Disabled {
+		return models.User{}, ErrUserDisabled
+	}
+	return user, nil
+}
+
+// lookup находит пользователя, проверяет пароль и возвращает его группы
+func (a *LDAPAuthenticator) lookup(username, password string) ([]string, error) {
+	conn, err := a.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if a.cfg.BindDN != "" {
+		if err := conn.Bind(a.cfg.BindDN, a.cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("ошибка привязки сервисной учётной записи LDAP: %v", err)
+		}
+	}
+
+	req := ldap.NewSearchRequest(
+		a.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(a.cfg.Timeout.Seconds()), false,
+		fmt.Sprintf(a.cfg.UserFilter, ldap.EscapeFilter(username)),
+		[]string{a.cfg.GroupAttribute}, nil,
+	)
+	res, err := conn.Search(req)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("логину %s в LDAP соответствует несколько записей", username)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска пользователя в LDAP: %v", err)
+	}
+	if len(res.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	entry := res.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("ошибка проверки пароля в LDAP: %v", err)
+	}
+	return entry.GetAttributeValues(a.cfg.GroupAttribute), nil
+}
+
+func (a *LDAPAuthenticator) dial() (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: a.cfg.InsecureSkipVerify}
+	if u, err := url.Parse(a.cfg.URL); err == nil {
+		tlsConfig.ServerName = u.Hostname()
+	}
+
+	conn, err := ldap.DialURL(a.cfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: a.cfg.Timeout}),
+		ldap.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подключения к LDAP %s: %v", a.cfg.URL, err)
+	}
+	conn.SetTimeout(a.cfg.Timeout)
+
+	if a.cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("ошибка StartTLS для LDAP: %v", err)
+		}
+	}
+	return conn, nil
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"dashboard/internal/models"
+)
+
+type fakeAuthenticator struct {
+	name string
+	user models.User
+	err  error
+}
+
+func (f fakeAuthenticator) Name() string { return f.name }
+
+func (f fakeAuthenticator) Authenticate(username, password string) (models.User, error) {
+	return f.user, f.err
+}
+
+func TestChainFallback(t *testing.T) {
+	local := fakeAuthenticator{name: "local", user: models.User{Username: "admin", Source: models.SourceLocal}}
+
+	// Каталог недоступен - вход через локальные учётные записи
+	chain := Chain{fakeAuthenticator{name: "ldap", err: errors.New("connection refused")}, local}
+	if user, err := chain.Authenticate("admin", "pass"); err != nil || user.Username != "admin" {
+		t.Errorf("ожидался вход через локальный источник, получено %v %v", user, err)
+	}
+
+	// Отсутствие роли в каталоге важнее неверного локального пароля
+	chain = Chain{
+		fakeAuthenticator{name: "ldap", err: ErrNoRole},
+		fakeAuthenticator{name: "local", err: ErrInvalidCredentials},
+	}
+	if _, err := chain.Authenticate("sidorov", "pass"); !errors.Is(err, ErrNoRole) {
+		t.Errorf("ожидалась ErrNoRole, получено %v", err)
+	}
+
+	if _, err := (Chain{}).Authenticate("admin", "pass"); !errors.Is(err, ErrLoginDisabled) {
+		t.Errorf("пустая цепочка: ожидалась ErrLoginDisabled, получено %v", err)
+	}
+}
+
+type memoryExternal map[string]models.User
+
+func (m memoryExternal) SyncExternal(source, username, role string, departments, groups []string) (models.User, error) {
+	u, ok := m[username]
+	if !ok {
+		u = models.User{ID: len(m) + 1, Username: username, Source: source}
+	}
+	u.Role, u.Departments, u.Groups = role, departments, groups
+	m[username] = u
+	return u, nil
+}
+
+// TestLDAPAuthenticator проверяет вход на тестовом каталоге из testdata/glauth.cfg.
+// Запуск: make ldap-test (или LDAP_TEST_URL=ldap://localhost:3893 go test ./internal/auth)
+func TestLDAPAuthenticator(t *testing.T) {
+	url := os.Getenv("LDAP_TEST_URL")
+	if url == "" {
+		t.Skip("LDAP_TEST_URL не задан")
+	}
+
+	users := memoryExternal{}
+	a, err := NewLDAPAuthenticator(LDAPConfig{
+		URL:          url,
+		BindDN:       "cn=svc-dashboard,ou=svcaccts,ou=users,dc=college,dc=local",
+		BindPassword: "svc-secret",
+		BaseDN:       "dc=college,dc=local",
+		UserFilter:   "(&(objectClass=posixAccount)(uid=%s))",
+		Mappings: []GroupMapping{
+			{Group: "dashboard-admins", Role: models.RoleAdmin},
+			{Group: "curators-ip21", Role: models.RoleCurator, Groups: []string{"ИП-21"}},
+		},
+	}, users)
+	if err != nil {
+		t.Fatalf("ошибка настройки: %v", err)
+	}
+
+	user, err := a.Authenticate("ivanova", "ivanova-pass")
+	if err != nil || user.Role != models.RoleAdmin || user.Source != models.SourceLDAP {
+		t.Errorf("ivanova: получено %+v %v", user, err)
+	}
+
+	user, err = a.Authenticate("petrov", "petrov-pass")
+	if err != nil || user.Role != models.RoleCurator || !user.Scope().Allows("", "ИП-21") {
+		t.Errorf("petrov: получено %+v %v", user, err)
+	}
+
+	if _, err := a.Authenticate("petrov", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("неверный пароль: ожидалась ErrInvalidCredentials, получено %v", err)
+	}
+	if _, err := a.Authenticate("nobody", "pass"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("неизвестный логин: ожидалась ErrInvalidCredentials, получено %v", err)
+	}
+	if _, err := a.Authenticate("sidorov", "sidorov-pass"); !errors.Is(err, ErrNoRole) {
+		t.Errorf("без групп дашборда: ожидалась ErrNoRole, получено %v", err)
+	}
+	if _, err := a.Authenticate("ivanova", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("пустой пароль: ожидалась ErrInvalidCredentials, получено %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"dashboard/internal/database"
+	"dashboard/internal/models"
+)
+
+// LocalUsers - хранилище локальных учётных записей
+type LocalUsers interface {
+	GetByUsername(username string) (models.User, error)
+	TouchLogin(id int) error
+}
+
+// LocalAuthenticator проверяет bcrypt-пароли из таблицы users.
+// Учётные записи из каталога здесь не принимаются.
+type LocalAuthenticator struct {
+	users LocalUsers
+}
+
+// NewLocalAuthenticator создаёт проверку по локальным паролям
+func NewLocalAuthenticator(users LocalUsers) *LocalAuthenticator {
+	return &LocalAuthenticator{users: users}
+}
+
+// Name возвращает имя источника
+func (a *LocalAuthenticator) Name() string {
+	return models.SourceLocal
+}
+
+// Authenticate проверяет пароль локального пользователя
+func (a *LocalAuthenticator) Authenticate(username, password string) (models.User, error) {
+	user, err := a.users.GetByUsername(username)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return models.User{}, err
+	}
+	if user.Source != "" && user.Source != models.SourceLocal {
+		user.PasswordHash = ""
+	}
+	// Для несуществующего логина хэш пустой: проверка займёт то же время
+	if !CheckPassword(user.PasswordHash, password) {
+		return models.User{}, ErrInvalidCredentials
+	}
+	if user.Disabled {
+		return models.User{}, ErrUserDisabled
+	}
+
+	if err := a.users.TouchLogin(user.ID); err != nil {
+		logger.Warn("Не удалось обновить время входа", "user_id", user.ID, "error", err)
+	}
+	return user, nil
+}
+
+// StaticAuthenticator - резервный вход из LOGIN_USER/LOGIN_PASSWORD,
+// когда БД не подключена
+type StaticAuthenticator struct {
+	username string
+	password string
+	role     string
+}
+
+// NewStaticAuthenticator создаёт проверку по логину и паролю из конфигурации
+func NewStaticAuthenticator(username, password, role string) *StaticAuthenticator {
+	return &StaticAuthenticator{username: username, password: password, role: role}
+}
+
+// Name возвращает имя источника
+func (a *StaticAuthenticator) Name() string {
+	return "static"
+}
+
+// Authenticate сравнивает учётные данные за постоянное время
+func (a *StaticAuthenticator) Authenticate(username, password string) (models.User, error) {
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(a.username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(password), []byte(a.password)) == 1
+	if !userOK || !passOK {
+		return models.User{}, ErrInvalidCredentials
+	}
+	return models.User{Username: a.username, Role: a.role, Source: models.SourceLocal}, nil
+}
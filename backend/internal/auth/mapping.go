@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"dashboard/internal/models"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// GroupMapping сопоставляет группу каталога (или значение claim OIDC) роли
+// дашборда. Group - полный DN группы, её имя (значение первого RDN,
+// например CN) или просто строка из claim.
+type GroupMapping struct {
+	Group       string
+	Role        string
+	Departments []string
+	Groups      []string
+}
+
+// rolePriority - порядок выбора роли, если пользователь входит в несколько групп
+var rolePriority = []string{
+	models.RoleAdmin,
+	models.RoleViewer,
+	models.RoleDepartmentHead,
+	models.RoleCurator,
+	models.RoleTeacher,
+}
+
+// ResolveRole выбирает по группам пользователя самую широкую роль.
+// Области видимости всех групп с этой ролью объединяются.
+func ResolveRole(mappings []GroupMapping, memberOf []string) (role string, departments, groups []string, ok bool) {
+	matched := make(map[string][]GroupMapping)
+	for _, m := range mappings {
+		for _, g := range memberOf {
+			if groupMatches(m.Group, g) {
+				matched[m.Role] = append(matched[m.Role], m)
+				break
+			}
+		}
+	}
+
+	for _, r := range rolePriority {
+		ms, found := matched[r]
+		if !found {
+			continue
+		}
+		for _, m := range ms {
+			departments = appendUnique(departments, m.Departments...)
+			groups = appendUnique(groups, m.Groups...)
+		}
+		return r, departments, groups, true
+	}
+	return "", nil, nil, false
+}
+
+// groupMatches сравнивает группу из сопоставления со значением memberOf:
+// по полному DN или по значению первого RDN (cn=teachers,ou=groups,... -> teachers)
+func groupMatches(want, dn string) bool {
+	if strings.EqualFold(want, dn) {
+		return true
+	}
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+		return false
+	}
+	return strings.EqualFold(want, parsed.RDNs[0].Attributes[0].Value)
+}
+
+func appendUnique(dst []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, d := range dst {
+			if d == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
+
+// ParseGroupMappings разбирает сопоставления из LDAP_GROUP_ROLES и OIDC_CLAIM_ROLES:
+// "группа:роль[:область|область];..." - для заведующего область
+// задаёт отделения, для куратора и преподавателя - группы. Например:
+// "dashboard-admins:admin;heads-it:department_head:ИТ;curators-ip21:curator:ИП-21"
+func ParseGroupMappings(raw string) ([]GroupMapping, error) {
+	var out []GroupMapping
+	for _, part := range strings.Split(raw, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.SplitN(part, ":", 3)
+		if len(fields) < 2 || strings.TrimSpace(fields[0]) == "" {
+			return nil, fmt.Errorf("некорректное сопоставление %q: ожидается группа:роль[:область]", part)
+		}
+		m := GroupMapping{Group: strings.TrimSpace(fields[0]), Role: strings.TrimSpace(fields[1])}
+		if !models.ValidRole(m.Role) {
+			return nil, fmt.Errorf("неизвестная роль %q в сопоставлении", m.Role)
+		}
+
+		var scope []string
+		if len(fields) == 3 {
+			for _, s := range strings.Split(fields[2], "|") {
+				if s = strings.TrimSpace(s); s != "" {
+					scope = append(scope, s)
+				}
+			}
+		}
+		switch m.Role {
+		case models.RoleDepartmentHead:
+			m.Departments = scope
+		case models.RoleCurator, models.RoleTeacher:
+			m.Groups = scope
+		}
+		if models.ScopedRole(m.Role) && len(scope) == 0 {
+			return nil, fmt.Errorf("для роли %s группы %s нужна область видимости", m.Role, m.Group)
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+
+	"dashboard/internal/models"
+)
+
+func TestParseGroupMappings(t *testing.T) {
+	mappings, err := ParseGroupMappings("dashboard-admins:admin; heads:department_head:ИТ|Экономика ;curators-ip21:curator:ИП-21")
+	if err != nil {
+		t.Fatalf("ошибка разбора: %v", err)
+	}
+	want := []GroupMapping{
+		{Group: "dashboard-admins", Role: models.RoleAdmin},
+		{Group: "heads", Role: models.RoleDepartmentHead, Departments: []string{"ИТ", "Экономика"}},
+		{Group: "curators-ip21", Role: models.RoleCurator, Groups: []string{"ИП-21"}},
+	}
+	if !reflect.DeepEqual(mappings, want) {
+		t.Errorf("получено %+v, ожидалось %+v", mappings, want)
+	}
+
+	for _, raw := range []string{"admins", "admins:root", "curators:curator"} {
+		if _, err := ParseGroupMappings(raw); err == nil {
+			t.Errorf("%q: ожидалась ошибка разбора", raw)
+		}
+	}
+}
+
+func TestResolveRole(t *testing.T) {
+	mappings := []GroupMapping{
+		{Group: "curators-ip21", Role: models.RoleCurator, Groups: []string{"ИП-21"}},
+		{Group: "cn=curators-ip22,ou=groups,dc=college,dc=local", Role: models.RoleCurator, Groups: []string{"ИП-22"}},
+		{Group: "dashboard-admins", Role: models.RoleAdmin},
+	}
+
+	role, _, groups, ok := ResolveRole(mappings, []string{
+		"cn=Curators-IP21,ou=groups,dc=college,dc=local",
+		"CN=curators-ip22,OU=groups,DC=college,DC=local",
+		"cn=staff,ou=groups,dc=college,dc=local",
+	})
+	if !ok || role != models.RoleCurator || !reflect.DeepEqual(groups, []string{"ИП-21", "ИП-22"}) {
+		t.Errorf("куратор: получено %s %v %v", role, groups, ok)
+	}
+
+	// Самая широкая роль побеждает
+	role, _, _, _ = ResolveRole(mappings, []string{"ou=curators-ip21,ou=groups", "ou=dashboard-admins,ou=groups"})
+	if role != models.RoleAdmin {
+		t.Errorf("ожидалась роль admin, получено %s", role)
+	}
+
+	if _, _, _, ok := ResolveRole(mappings, []string{"cn=staff,ou=groups"}); ok {
+		t.Error("пользователь без сопоставленных групп не должен получить роль")
+	}
+}
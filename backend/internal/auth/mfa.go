@@ -0,0 +1,356 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image/png"
+	"strings"
+	"sync"
+	"time"
+
+	"dashboard/internal/models"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// Ошибки второго фактора
+var (
+	ErrMFAChallenge      = errors.New("недействительный или просроченный токен второго шага входа")
+	ErrMFACode           = errors.New("неверный код подтверждения")
+	ErrMFANotSetUp       = errors.New("второй фактор не настроен")
+	ErrMFAAlreadyEnabled = errors.New("второй фактор уже включён")
+	ErrMFAEnforced       = errors.New("второй фактор обязателен для роли")
+)
+
+const (
+	// mfaChallengeTTL - сколько действует токен второго шага входа
+	mfaChallengeTTL = 5 * time.Minute
+	// mfaMaxAttempts - попыток ввода кода на один токен
+	mfaMaxAttempts = 5
+	// recoveryCodeCount - сколько кодов восстановления выдаётся при включении
+	recoveryCodeCount = 10
+)
+
+var totpOpts = totp.ValidateOpts{Period: 30, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+
+// MFAUsers - хранилище пользователей с секретами второго фактора
+type MFAUsers interface {
+	Get(id int) (models.User, error)
+	SetTOTP(id int, secret string, enabled bool, recoveryHashes []string) (models.User, error)
+	UseRecoveryCode(id int, hash string) (bool, error)
+}
+
+// MFAChallenge - второй шаг входа, который клиент должен пройти кодом
+type MFAChallenge struct {
+	Token string `json:"mfa_token"`
+	// Enroll - 2FA обязательна для роли, но ещё не настроена:
+	// сначала POST /api/login/mfa/setup, затем код из приложения
+	Enroll    bool `json:"enrollment_required"`
+	ExpiresIn int  `json:"expires_in"`
+}
+
+// TOTPSetup - данные для подключения приложения-аутентификатора
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+	QRCode string `json:"qr_code"` // PNG в формате data URI
+}
+
+type mfaChallenge struct {
+	userID    int
+	username  string
+	enroll    bool
+	attempts  int
+	expiresAt time.Time
+}
+
+// MFAManager управляет вторым фактором (TOTP): подключением, кодами
+// восстановления и вторым шагом входа. Для ролей из requiredRoles
+// второй фактор обязателен, остальные включают его по желанию.
+type MFAManager struct {
+	users    MFAUsers
+	issuer   string
+	required map[string]bool
+	now      func() time.Time
+
+	mu         sync.Mutex
+	challenges map[string]*mfaChallenge
+	usedSteps  map[string]time.Time // защита от повторного использования кода
+}
+
+// NewMFAManager создаёт менеджер второго фактора. issuer отображается
+// в приложении-аутентификаторе.
+func NewMFAManager(users MFAUsers, issuer string, requiredRoles []string) *MFAManager {
+	required := make(map[string]bool)
+	for _, r := range requiredRoles {
+		required[r] = true
+	}
+	return &MFAManager{
+		users:      users,
+		issuer:     issuer,
+		required:   required,
+		now:        time.Now,
+		challenges: make(map[string]*mfaChallenge),
+		usedSteps:  make(map[string]time.Time),
+	}
+}
+
+// Enforced сообщает, обязателен ли второй фактор для роли
+func (m *MFAManager) Enforced(role string) bool {
+	return m.required[role]
+}
+
+// Required сообщает, нужен ли пользователю второй шаг входа
+func (m *MFAManager) Required(user models.User) bool {
+	return user.ID != 0 && (user.TOTPEnabled || m.Enforced(user.Role))
+}
+
+// Begin создаёт второй шаг входа после проверки пароля
+func (m *MFAManager) Begin(user models.User) MFAChallenge {
+	now := m.now()
+	token := randomString(24)
+
+	m.mu.Lock()
+	for t, ch := range m.challenges {
+		if now.After(ch.expiresAt) {
+			delete(m.challenges, t)
+		}
+	}
+	m.challenges[token] = &mfaChallenge{
+		userID:    user.ID,
+		username:  user.Username,
+		enroll:    !user.TOTPEnabled,
+		expiresAt: now.Add(mfaChallengeTTL),
+	}
+	m.mu.Unlock()
+
+	return MFAChallenge{Token: token, Enroll: !user.TOTPEnabled, ExpiresIn: int(mfaChallengeTTL.Seconds())}
+}
+
+// ChallengeUsername возвращает логин, для которого создан второй шаг входа
+func (m *MFAManager) ChallengeUsername(token string) (string, bool) {
+	ch, ok := m.challenge(token)
+	return ch.username, ok
+}
+
+// SetupChallenge выдаёт секрет для подключения в рамках входа,
+// когда 2FA обязательна, а пользователь её ещё не настроил
+func (m *MFAManager) SetupChallenge(token string) (TOTPSetup, error) {
+	ch, ok := m.challenge(token)
+	if !ok || !ch.enroll {
+		return TOTPSetup{}, ErrMFAChallenge
+	}
+	return m.Setup(ch.userID)
+}
+
+// Complete проверяет код второго шага входа (TOTP или код восстановления).
+// Если шаг был подключением 2FA, возвращаются новые коды восстановления.
+func (m *MFAManager) Complete(token, code string) (models.User, []string, error) {
+	m.mu.Lock()
+	ch, ok := m.challenges[token]
+	if ok && m.now().After(ch.expiresAt) {
+		delete(m.challenges, token)
+		ok = false
+	}
+	if ok {
+		ch.attempts++
+		if ch.attempts > mfaMaxAttempts {
+			delete(m.challenges, token)
+			ok = false
+		}
+	}
+	m.mu.Unlock()
+	if !ok {
+		return models.User{}, nil, ErrMFAChallenge
+	}
+
+	var recovery []string
+	var err error
+	if ch.enroll {
+		recovery, err = m.Enable(ch.userID, code)
+	} else {
+		err = m.Verify(ch.userID, code)
+	}
+	if err != nil {
+		return models.User{}, nil, err
+	}
+
+	m.mu.Lock()
+	delete(m.challenges, token)
+	m.mu.Unlock()
+
+	user, err := m.users.Get(ch.userID)
+	if err != nil {
+		return models.User{}, nil, err
+	}
+	if user.Disabled {
+		return models.User{}, nil, ErrUserDisabled
+	}
+	return user, recovery, nil
+}
+
+// Setup создаёт новый секрет TOTP. Пока код не подтверждён через Enable,
+// второй фактор не действует.
+func (m *MFAManager) Setup(userID int) (TOTPSetup, error) {
+	user, err := m.users.Get(userID)
+	if err != nil {
+		return TOTPSetup{}, err
+	}
+	if user.TOTPEnabled {
+		return TOTPSetup{}, ErrMFAAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: m.issuer, AccountName: user.Username})
+	if err != nil {
+		return TOTPSetup{}, fmt.Errorf("ошибка создания секрета TOTP: %v", err)
+	}
+	if _, err := m.users.SetTOTP(userID, key.Secret(), false, nil); err != nil {
+		return TOTPSetup{}, err
+	}
+
+	setup := TOTPSetup{Secret: key.Secret(), URI: key.URL()}
+	if img, err := key.Image(200, 200); err == nil {
+		var buf bytes.Buffer
+		if png.Encode(&buf, img) == nil {
+			setup.QRCode = "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+		}
+	}
+	return setup, nil
+}
+
+// Enable включает второй фактор после проверки первого кода
+// и возвращает коды восстановления (показываются один раз)
+func (m *MFAManager) Enable(userID int, code string) ([]string, error) {
+	user, err := m.users.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotSetUp
+	}
+	if !m.checkTOTP(user.ID, user.TOTPSecret, code) {
+		return nil, ErrMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.users.SetTOTP(userID, user.TOTPSecret, true, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify проверяет код TOTP или погашает код восстановления
+func (m *MFAManager) Verify(userID int, code string) error {
+	user, err := m.users.Get(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrMFANotSetUp
+	}
+	if m.checkTOTP(user.ID, user.TOTPSecret, code) {
+		return nil
+	}
+	if normalized := normalizeRecoveryCode(code); len(normalized) == 10 {
+		used, err := m.users.UseRecoveryCode(userID, hashToken(normalized))
+		if err != nil {
+			return err
+		}
+		if used {
+			return nil
+		}
+	}
+	return ErrMFACode
+}
+
+// Disable отключает второй фактор. Для ролей с обязательной 2FA
+// отключение запрещено, пока не сменится роль.
+func (m *MFAManager) Disable(userID int) (models.User, error) {
+	user, err := m.users.Get(userID)
+	if err != nil {
+		return models.User{}, err
+	}
+	if m.Enforced(user.Role) {
+		return models.User{}, ErrMFAEnforced
+	}
+	return m.users.SetTOTP(userID, "", false, nil)
+}
+
+// Reset сбрасывает второй фактор (администратором, при потере телефона).
+// Если 2FA обязательна, при следующем входе пользователь подключит её заново.
+func (m *MFAManager) Reset(userID int) (models.User, error) {
+	return m.users.SetTOTP(userID, "", false, nil)
+}
+
+func (m *MFAManager) challenge(token string) (mfaChallenge, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch, ok := m.challenges[token]
+	if !ok || m.now().After(ch.expiresAt) {
+		return mfaChallenge{}, false
+	}
+	return *ch, true
+}
+
+// checkTOTP проверяет код с допуском в один шаг (30 с) в обе стороны.
+// Принятый код нельзя использовать повторно.
+func (m *MFAManager) checkTOTP(userID int, secret, code string) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != 6 {
+		return false
+	}
+	now := m.now()
+	for _, skew := range []int{0, -1, 1} {
+		t := now.Add(time.Duration(skew) * 30 * time.Second)
+		expected, err := totp.GenerateCodeCustom(secret, t, totpOpts)
+		if err != nil || !equalHash(expected, code) {
+			continue
+		}
+
+		step := fmt.Sprintf("%d:%d", userID, t.Unix()/30)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for s, at := range m.usedSteps {
+			if now.Sub(at) > 2*time.Minute {
+				delete(m.usedSteps, s)
+			}
+		}
+		if _, used := m.usedSteps[step]; used {
+			return false
+		}
+		m.usedSteps[step] = now
+		return true
+	}
+	return false
+}
+
+// generateRecoveryCodes создаёт коды вида xxxxx-xxxxx и их хэши
+func generateRecoveryCodes() (codes, hashes []string, err error) {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz234567" // 32 символа: без смещения по модулю
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("ошибка генерации кодов восстановления: %v", err)
+		}
+		for j := range b {
+			b[j] = alphabet[int(b[j])%len(alphabet)]
+		}
+		codes = append(codes, string(b[:5])+"-"+string(b[5:]))
+		hashes = append(hashes, hashToken(string(b)))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"dashboard/internal/database"
+	"dashboard/internal/models"
+
+	"github.com/pquerna/otp/totp"
+)
+
+// fakeMFAUsers - хранилище пользователей в памяти
+type fakeMFAUsers struct {
+	users map[int]models.User
+}
+
+func (f *fakeMFAUsers) Get(id int) (models.User, error) {
+	u, ok := f.users[id]
+	if !ok {
+		return models.User{}, database.ErrNotFound
+	}
+	return u, nil
+}
+
+func (f *fakeMFAUsers) SetTOTP(id int, secret string, enabled bool, recoveryHashes []string) (models.User, error) {
+	u, ok := f.users[id]
+	if !ok {
+		return models.User{}, database.ErrNotFound
+	}
+	u.TOTPSecret, u.TOTPEnabled, u.RecoveryCodes = secret, enabled, recoveryHashes
+	f.users[id] = u
+	return u, nil
+}
+
+func (f *fakeMFAUsers) UseRecoveryCode(id int, hash string) (bool, error) {
+	u := f.users[id]
+	for i, h := range u.RecoveryCodes {
+		if h == hash {
+			u.RecoveryCodes = append(u.RecoveryCodes[:i:i], u.RecoveryCodes[i+1:]...)
+			f.users[id] = u
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newTestMFA(roles ...string) (*MFAManager, *fakeMFAUsers, *time.Time) {
+	users := &fakeMFAUsers{users: map[int]models.User{
+		1: {ID: 1, Username: "ivanov", Role: models.RoleViewer},
+		2: {ID: 2, Username: "admin", Role: models.RoleAdmin},
+	}}
+	m := NewMFAManager(users, "Dashboard", roles)
+	now := time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m, users, &now
+}
+
+func code(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	c, err := totp.GenerateCode(secret, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestMFASetupEnableVerify(t *testing.T) {
+	m, users, now := newTestMFA()
+
+	setup, err := m.Setup(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setup.Secret == "" || setup.URI == "" || setup.QRCode == "" {
+		t.Fatalf("неполные данные подключения: %+v", setup)
+	}
+	if m.Required(users.users[1]) {
+		t.Error("до подтверждения кода 2FA не должна действовать")
+	}
+
+	if _, err := m.Enable(1, "000000"); !errors.Is(err, ErrMFACode) {
+		t.Errorf("неверный код: получено %v", err)
+	}
+	recovery, err := m.Enable(1, code(t, setup.Secret, *now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recovery) != recoveryCodeCount || len(users.users[1].RecoveryCodes) != recoveryCodeCount {
+		t.Errorf("ожидалось %d кодов восстановления", recoveryCodeCount)
+	}
+	if !m.Required(users.users[1]) {
+		t.Error("после включения вход должен требовать второй шаг")
+	}
+
+	// Тот же код повторно не принимается, следующий - принимается
+	if err := m.Verify(1, code(t, setup.Secret, *now)); !errors.Is(err, ErrMFACode) {
+		t.Errorf("повторное использование кода: получено %v", err)
+	}
+	*now = now.Add(30 * time.Second)
+	if err := m.Verify(1, code(t, setup.Secret, *now)); err != nil {
+		t.Errorf("код следующего шага: %v", err)
+	}
+}
+
+func TestMFARecoveryCodeSingleUse(t *testing.T) {
+	m, users, now := newTestMFA()
+	setup, _ := m.Setup(1)
+	recovery, err := m.Enable(1, code(t, setup.Secret, *now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Verify(1, " "+recovery[0]+" "); err != nil {
+		t.Fatalf("код восстановления: %v", err)
+	}
+	if err := m.Verify(1, recovery[0]); !errors.Is(err, ErrMFACode) {
+		t.Errorf("код восстановления должен гаситься: получено %v", err)
+	}
+	if len(users.users[1].RecoveryCodes) != recoveryCodeCount-1 {
+		t.Errorf("осталось %d кодов", len(users.users[1].RecoveryCodes))
+	}
+}
+
+func TestMFAEnforcedRole(t *testing.T) {
+	m, users, now := newTestMFA(models.RoleAdmin)
+
+	admin := users.users[2]
+	if !m.Required(admin) || m.Required(users.users[1]) {
+		t.Fatal("2FA обязательна только для admin")
+	}
+
+	// Вход с подключением: секрет выдаётся по токену, первый код включает 2FA
+	ch := m.Begin(admin)
+	if !ch.Enroll {
+		t.Fatal("ожидалось подключение 2FA при входе")
+	}
+	setup, err := m.SetupChallenge(ch.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, recovery, err := m.Complete(ch.Token, code(t, setup.Secret, *now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 2 || !user.TOTPEnabled || len(recovery) == 0 {
+		t.Errorf("неожиданный результат входа: %+v, кодов %d", user, len(recovery))
+	}
+	if _, _, err := m.Complete(ch.Token, "123456"); !errors.Is(err, ErrMFAChallenge) {
+		t.Errorf("токен второго шага одноразовый: получено %v", err)
+	}
+
+	if _, err := m.Disable(2); !errors.Is(err, ErrMFAEnforced) {
+		t.Errorf("отключение обязательной 2FA: получено %v", err)
+	}
+	if _, err := m.Reset(2); err != nil || users.users[2].TOTPEnabled {
+		t.Errorf("сброс администратором: %v", err)
+	}
+}
+
+func TestMFAChallengeLimits(t *testing.T) {
+	m, _, now := newTestMFA()
+	setup, _ := m.Setup(1)
+	if _, err := m.Enable(1, code(t, setup.Secret, *now)); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(time.Minute)
+
+	ch := m.Begin(models.User{ID: 1, Username: "ivanov", TOTPEnabled: true})
+	for i := 0; i < mfaMaxAttempts; i++ {
+		if _, _, err := m.Complete(ch.Token, "000000"); !errors.Is(err, ErrMFACode) {
+			t.Fatalf("попытка %d: получено %v", i+1, err)
+		}
+	}
+	if _, _, err := m.Complete(ch.Token, code(t, setup.Secret, *now)); !errors.Is(err, ErrMFAChallenge) {
+		t.Errorf("после исчерпания попыток токен недействителен: получено %v", err)
+	}
+
+	ch = m.Begin(models.User{ID: 1, Username: "ivanov", TOTPEnabled: true})
+	*now = now.Add(mfaChallengeTTL + time.Second)
+	if _, _, err := m.Complete(ch.Token, code(t, setup.Secret, *now)); !errors.Is(err, ErrMFAChallenge) {
+		t.Errorf("просроченный токен: получено %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"dashboard/internal/database"
+	"dashboard/internal/models"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// ErrInvalidState - неизвестный или просроченный параметр state в ответе провайдера
+var ErrInvalidState = errors.New("недействительный или просроченный state OIDC")
+
+const (
+	// oidcStateTTL - сколько ждём возврата пользователя от провайдера
+	oidcStateTTL = 10 * time.Minute
+	// oidcMaxPending ограничивает число незавершённых входов в памяти
+	oidcMaxPending = 10000
+)
+
+// OIDCConfig - параметры клиента OpenID Connect
+type OIDCConfig struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string // адрес /api/oidc/callback, зарегистрированный у провайдера
+	Scopes        []string
+	UsernameClaim string // обычно preferred_username или email
+	RoleClaim     string // claim со списком групп или ролей, обычно groups
+	Mappings      []GroupMapping
+}
+
+// oidcPending - состояние незавершённого входа: код PKCE и nonce
+type oidcPending struct {
+	verifier  string
+	nonce     string
+	expiresAt time.Time
+}
+
+// OIDCProvider выполняет вход по authorization code с PKCE. Провайдер
+// определяется через discovery при первом входе, чтобы недоступность
+// портала не мешала запуску сервера. Пользователи создаются в БД при
+// первом входе, роль обновляется из claim при каждом.
+type OIDCProvider struct {
+	cfg   OIDCConfig
+	users ExternalUsers
+
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	pending  map[string]oidcPending
+}
+
+// NewOIDCProvider создаёт клиента OIDC. users может быть nil, если БД не подключена.
+func NewOIDCProvider(cfg OIDCConfig, users ExternalUsers) (*OIDCProvider, error) {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("для OIDC нужны issuer, client_id и redirect URL")
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = "preferred_username"
+	}
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "groups"
+	}
+	if len(cfg.Mappings) == 0 {
+		return nil, fmt.Errorf("не задано ни одного сопоставления claim OIDC и ролей")
+	}
+	return &OIDCProvider{cfg: cfg, users: users, pending: make(map[string]oidcPending)}, nil
+}
+
+// discover загружает настройки провайдера при первом обращении
+func (p *OIDCProvider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth != nil {
+		return p.oauth, p.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.cfg.Issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка discovery OIDC %s: %v", p.cfg.Issuer, err)
+	}
+	p.oauth = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  p.cfg.RedirectURL,
+		Scopes:       p.cfg.Scopes,
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID})
+	return p.oauth, p.verifier, nil
+}
+
+// AuthCodeURL начинает вход: запоминает state, nonce и код PKCE
+// и возвращает адрес страницы входа провайдера
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context) (string, error) {
+	conf, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	state := randomString(16)
+	pending := oidcPending{
+		verifier:  oauth2.GenerateVerifier(),
+		nonce:     randomString(16),
+		expiresAt: time.Now().Add(oidcStateTTL),
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	for s, pe := range p.pending {
+		if now.After(pe.expiresAt) {
+			delete(p.pending, s)
+		}
+	}
+	if len(p.pending) >= oidcMaxPending {
+		p.mu.Unlock()
+		return "", fmt.Errorf("слишком много незавершённых входов OIDC")
+	}
+	p.pending[state] = pending
+	p.mu.Unlock()
+
+	return conf.AuthCodeURL(state, oidc.Nonce(pending.nonce), oauth2.S256ChallengeOption(pending.verifier)), nil
+}
+
+// Exchange завершает вход: обменивает код на токены, проверяет ID-токен
+// и nonce, определяет роль по claim и создаёт или обновляет пользователя
+func (p *OIDCProvider) Exchange(ctx context.Context, state, code string) (models.User, error) {
+	p.mu.Lock()
+	pending, ok := p.pending[state]
+	delete(p.pending, state)
+	p.mu.Unlock()
+	if !ok || time.Now().After(pending.expiresAt) {
+		return models.User{}, ErrInvalidState
+	}
+
+	conf, verifier, err := p.discover(ctx)
+	if err != nil {
+		return models.User{}, err
+	}
+	token, err := conf.Exchange(ctx, code, oauth2.VerifierOption(pending.verifier))
+	if err != nil {
+		return models.User{}, fmt.Errorf("ошибка обмена кода OIDC: %v", err)
+	}
+	rawID, ok := token.Extra("id_token").(string)
+	if !ok || rawID == "" {
+		return models.User{}, fmt.Errorf("провайдер не вернул id_token")
+	}
+	idToken, err := verifier.Verify(ctx, rawID)
+	if err != nil {
+		return models.User{}, fmt.Errorf("недействительный id_token: %v", err)
+	}
+	if idToken.Nonce != pending.nonce {
+		return models.User{}, fmt.Errorf("nonce в id_token не совпадает")
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return models.User{}, fmt.Errorf("ошибка чтения claims: %v", err)
+	}
+	username, _ := claims[p.cfg.UsernameClaim].(string)
+	if username == "" {
+		return models.User{}, fmt.Errorf("в id_token нет claim %s", p.cfg.UsernameClaim)
+	}
+
+	role, departments, groups, ok := ResolveRole(p.cfg.Mappings, claimValues(claims[p.cfg.RoleClaim]))
+	if !ok {
+		return models.User{}, ErrNoRole
+	}
+
+	if p.users == nil {
+		return models.User{
+			Username:    username,
+			Role:        role,
+			Departments: departments,
+			Groups:      groups,
+			Source:      models.SourceOIDC,
+		}, nil
+	}
+	user, err := p.users.SyncExternal(models.SourceOIDC, username, role, departments, groups)
+	if errors.Is(err, database.ErrDuplicate) {
+		return models.User{}, fmt.Errorf("логин %s уже занят учётной записью другого источника", username)
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	if user.Disabled {
+		return models.User{}, ErrUserDisabled
+	}
+	return user, nil
+}
+
+// claimValues приводит claim к списку строк: провайдеры отдают группы
+// массивом или строкой через пробел/запятую
+func claimValues(v interface{}) []string {
+	switch val := v.(type) {
+	case string:
+		return strings.FieldsFunc(val, func(r rune) bool { return r == ' ' || r == ',' })
+	case []interface{}:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
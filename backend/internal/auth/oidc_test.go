@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"dashboard/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIdP - минимальный провайдер OIDC: discovery, JWKS, authorize и token с PKCE
+type mockIdP struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]interface{}
+
+	mu    sync.Mutex
+	codes map[string]url.Values // код -> параметры запроса authorize
+}
+
+func newMockIdP(t *testing.T, claims map[string]interface{}) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{key: key, claims: claims, codes: make(map[string]url.Values)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                idp.URL,
+			"authorization_endpoint":                idp.URL + "/authorize",
+			"token_endpoint":                        idp.URL + "/token",
+			"jwks_uri":                              idp.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "test", "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		code := randomString(8)
+		idp.mu.Lock()
+		idp.codes[code] = q
+		idp.mu.Unlock()
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code="+code+"&state="+q.Get("state"), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		idp.mu.Lock()
+		q, ok := idp.codes[r.PostForm.Get("code")]
+		delete(idp.codes, r.PostForm.Get("code"))
+		idp.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || q.Get("code_challenge_method") != "S256" ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != q.Get("code_challenge") {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":   idp.URL,
+			"aud":   q.Get("client_id"),
+			"sub":   "user-1",
+			"nonce": q.Get("nonce"),
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		}
+		for k, v := range idp.claims {
+			claims[k] = v
+		}
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		tok.Header["kid"] = "test"
+		idToken, _ := tok.SignedString(key)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access", "token_type": "Bearer", "expires_in": 60, "id_token": idToken,
+		})
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// login проходит вход как браузер: страница провайдера сразу возвращает код
+func (idp *mockIdP) login(t *testing.T, p *OIDCProvider) (state, code string) {
+	authURL, err := p.AuthCodeURL(context.Background())
+	if err != nil {
+		t.Fatalf("ошибка AuthCodeURL: %v", err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	loc, _ := url.Parse(resp.Header.Get("Location"))
+	return loc.Query().Get("state"), loc.Query().Get("code")
+}
+
+func TestOIDCProviderLogin(t *testing.T) {
+	idp := newMockIdP(t, map[string]interface{}{
+		"preferred_username": "kuznetsova",
+		"groups":             []string{"portal-staff", "curators-ip21"},
+	})
+	users := memoryExternal{}
+	p, err := NewOIDCProvider(OIDCConfig{
+		Issuer:      idp.URL,
+		ClientID:    "dashboard",
+		RedirectURL: "http://localhost/api/oidc/callback",
+		Mappings:    []GroupMapping{{Group: "curators-ip21", Role: models.RoleCurator, Groups: []string{"ИП-21"}}},
+	}, users)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, code := idp.login(t, p)
+	user, err := p.Exchange(context.Background(), state, code)
+	if err != nil {
+		t.Fatalf("ошибка входа: %v", err)
+	}
+	if user.Username != "kuznetsova" || user.Role != models.RoleCurator || user.Source != models.SourceOIDC {
+		t.Errorf("неверный пользователь: %+v", user)
+	}
+	if _, ok := users["kuznetsova"]; !ok {
+		t.Error("пользователь должен быть создан при первом входе")
+	}
+
+	// state одноразовый
+	if _, err := p.Exchange(context.Background(), state, code); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("повторный state: ожидалась ErrInvalidState, получено %v", err)
+	}
+}
+
+func TestOIDCProviderNoRole(t *testing.T) {
+	idp := newMockIdP(t, map[string]interface{}{"preferred_username": "guest", "groups": "portal-staff"})
+	p, _ := NewOIDCProvider(OIDCConfig{
+		Issuer:      idp.URL,
+		ClientID:    "dashboard",
+		RedirectURL: "http://localhost/api/oidc/callback",
+		Mappings:    []GroupMapping{{Group: "dashboard-admins", Role: models.RoleAdmin}},
+	}, nil)
+
+	state, code := idp.login(t, p)
+	if _, err := p.Exchange(context.Background(), state, code); !errors.Is(err, ErrNoRole) {
+		t.Errorf("ожидалась ErrNoRole, получено %v", err)
+	}
+}
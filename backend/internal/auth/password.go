@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinPasswordLength - минимальная длина пароля
+const MinPasswordLength = 8
+
+// dummyHash сравнивается с паролем, когда пользователь не найден,
+// чтобы время ответа не выдавало существование логина
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// ValidatePassword проверяет требования к паролю
+func ValidatePassword(password string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return fmt.Errorf("пароль должен быть не короче %d символов", MinPasswordLength)
+	}
+	// bcrypt учитывает только первые 72 байта
+	if len(password) > 72 {
+		return fmt.Errorf("пароль не должен быть длиннее 72 байт")
+	}
+	return nil
+}
+
+// HashPassword возвращает bcrypt-хэш пароля
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("ошибка хэширования пароля: %v", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword сравнивает пароль с хэшем. Пустой хэш (пользователь
+// не найден) проверяется по фиктивному хэшу и всегда даёт false.
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,31 @@
+package auth
+
+import "testing"
+
+func TestHashAndCheckPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if hash == "correct horse" {
+		t.Fatal("Пароль не должен храниться в открытом виде")
+	}
+	if !CheckPassword(hash, "correct horse") {
+		t.Error("Верный пароль не прошёл проверку")
+	}
+	if CheckPassword(hash, "wrong horse") {
+		t.Error("Неверный пароль прошёл проверку")
+	}
+	if CheckPassword("", "") {
+		t.Error("Пустой хэш не должен проходить проверку")
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	if err := ValidatePassword("short"); err == nil {
+		t.Error("Ожидалась ошибка для короткого пароля")
+	}
+	if err := ValidatePassword("длинный пароль"); err != nil {
+		t.Errorf("Неожиданная ошибка: %v", err)
+	}
+}
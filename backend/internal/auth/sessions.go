@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"dashboard/internal/models"
+)
+
+// Ошибки обновления токенов
+var (
+	ErrInvalidRefresh = errors.New("недействительный refresh-токен")
+	ErrRefreshReused  = errors.New("refresh-токен использован повторно, сеанс завершён")
+	ErrUserDisabled   = errors.New("учётная запись заблокирована")
+)
+
+// SessionStore - хранилище сеансов
+type SessionStore interface {
+	Create(sess models.Session) error
+	Get(id string) (models.Session, error)
+	Rotate(id, oldHash, newHash string, expiresAt time.Time) (bool, error)
+	Revoke(id string) error
+	RevokeUser(userID int) ([]string, error)
+	RevokedSince(since time.Time) ([]string, error)
+}
+
+// UserSource возвращает актуальные данные пользователя при обновлении токена
+type UserSource interface {
+	Get(id int) (models.User, error)
+}
+
+// TokenIssuer выпускает access-токен для сеанса
+type TokenIssuer func(user models.User, sessionID string, ttl time.Duration) (string, error)
+
+// Tokens - пара токенов, которую получает клиент
+type Tokens struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// SessionManager выдаёт короткоживущие access-токены и ротируемые
+// refresh-токены. Отозванные сеансы хранятся в памяти, пока не истекут
+// выданные для них access-токены, и проверяются в JWTAuth.
+type SessionManager struct {
+	store      SessionStore
+	users      UserSource
+	issue      TokenIssuer
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+// NewSessionManager создаёт менеджер сеансов
+func NewSessionManager(store SessionStore, users UserSource, issue TokenIssuer, accessTTL, refreshTTL time.Duration) *SessionManager {
+	return &SessionManager{
+		store:      store,
+		users:      users,
+		issue:      issue,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		revoked:    make(map[string]time.Time),
+	}
+}
+
+// LoadRevoked восстанавливает список отзыва после перезапуска:
+// access-токены сеансов, завершённых недавно, ещё могут быть в обращении
+func (m *SessionManager) LoadRevoked() error {
+	ids, err := m.store.RevokedSince(time.Now().Add(-m.accessTTL))
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		m.markRevoked(id)
+	}
+	return nil
+}
+
+// Start открывает сеанс после успешного входа
+func (m *SessionManager) Start(user models.User, userAgent, ip string) (Tokens, error) {
+	sess := models.Session{
+		ID:        randomString(16),
+		UserID:    user.ID,
+		UserAgent: userAgent,
+		IP:        ip,
+		ExpiresAt: time.Now().Add(m.refreshTTL),
+	}
+	secret := randomString(32)
+	sess.RefreshHash = hashToken(secret)
+	if err := m.store.Create(sess); err != nil {
+		return Tokens{}, err
+	}
+	return m.tokens(user, sess.ID, secret)
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов. Старый
+// refresh-токен перестаёт действовать; его повторное предъявление
+// считается кражей и завершает сеанс.
+func (m *SessionManager) Refresh(refreshToken string) (Tokens, error) {
+	sid, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || sid == "" || secret == "" {
+		return Tokens{}, ErrInvalidRefresh
+	}
+	sess, err := m.store.Get(sid)
+	if err != nil {
+		return Tokens{}, ErrInvalidRefresh
+	}
+
+	hash := hashToken(secret)
+	if sess.RevokedAt != nil || time.Now().After(sess.ExpiresAt) {
+		return Tokens{}, ErrInvalidRefresh
+	}
+	if !equalHash(hash, sess.RefreshHash) {
+		if sess.PreviousHash != "" && equalHash(hash, sess.PreviousHash) {
+			logger.Warn("Повторное использование refresh-токена, сеанс завершён", "session", sid, "user_id", sess.UserID)
+			m.RevokeSession(sid)
+			return Tokens{}, ErrRefreshReused
+		}
+		return Tokens{}, ErrInvalidRefresh
+	}
+
+	user, err := m.users.Get(sess.UserID)
+	if err != nil {
+		return Tokens{}, ErrInvalidRefresh
+	}
+	if user.Disabled {
+		m.RevokeSession(sid)
+		return Tokens{}, ErrUserDisabled
+	}
+
+	next := randomString(32)
+	rotated, err := m.store.Rotate(sid, hash, hashToken(next), time.Now().Add(m.refreshTTL))
+	if err != nil {
+		return Tokens{}, err
+	}
+	if !rotated {
+		// Параллельный запрос уже обменял этот токен
+		return Tokens{}, ErrInvalidRefresh
+	}
+	return m.tokens(user, sid, next)
+}
+
+// RevokeSession завершает сеанс и отзывает его access-токены
+func (m *SessionManager) RevokeSession(sid string) error {
+	m.markRevoked(sid)
+	return m.store.Revoke(sid)
+}
+
+// RevokeUser завершает все сеансы пользователя
+func (m *SessionManager) RevokeUser(userID int) (int, error) {
+	ids, err := m.store.RevokeUser(userID)
+	for _, id := range ids {
+		m.markRevoked(id)
+	}
+	return len(ids), err
+}
+
+// IsRevoked проверяет, отозван ли сеанс (вызывается из JWTAuth на каждый запрос)
+func (m *SessionManager) IsRevoked(sid string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	until, ok := m.revoked[sid]
+	return ok && time.Now().Before(until)
+}
+
+// AccessTTL - время жизни access-токена
+func (m *SessionManager) AccessTTL() time.Duration {
+	return m.accessTTL
+}
+
+func (m *SessionManager) markRevoked(sid string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for id, until := range m.revoked {
+		if now.After(until) {
+			delete(m.revoked, id)
+		}
+	}
+	m.revoked[sid] = now.Add(m.accessTTL)
+}
+
+func (m *SessionManager) tokens(user models.User, sid, secret string) (Tokens, error) {
+	access, err := m.issue(user, sid, m.accessTTL)
+	if err != nil {
+		return Tokens{}, fmt.Errorf("ошибка выпуска токена: %v", err)
+	}
+	return Tokens{
+		AccessToken:  access,
+		RefreshToken: sid + "." + secret,
+		ExpiresIn:    int(m.accessTTL.Seconds()),
+	}, nil
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func equalHash(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// randomString возвращает n случайных байт в base64. Из него делаются
+// идентификаторы сеансов и секреты, поэтому без источника случайности
+// продолжать нельзя.
+func randomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
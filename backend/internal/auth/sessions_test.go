@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"dashboard/internal/models"
+)
+
+type memorySessions struct {
+	sessions map[string]models.Session
+}
+
+func (s *memorySessions) Create(sess models.Session) error {
+	s.sessions[sess.ID] = sess
+	return nil
+}
+
+func (s *memorySessions) Get(id string) (models.Session, error) {
+	sess, ok := s.sessions[id]
+	if !ok {
+		return sess, errors.New("не найден")
+	}
+	return sess, nil
+}
+
+func (s *memorySessions) Rotate(id, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	sess := s.sessions[id]
+	if sess.RefreshHash != oldHash || sess.RevokedAt != nil {
+		return false, nil
+	}
+	sess.PreviousHash, sess.RefreshHash, sess.ExpiresAt = sess.RefreshHash, newHash, expiresAt
+	s.sessions[id] = sess
+	return true, nil
+}
+
+func (s *memorySessions) Revoke(id string) error {
+	sess := s.sessions[id]
+	now := time.Now()
+	sess.RevokedAt = &now
+	s.sessions[id] = sess
+	return nil
+}
+
+func (s *memorySessions) RevokeUser(userID int) ([]string, error) {
+	var ids []string
+	for id, sess := range s.sessions {
+		if sess.UserID == userID && sess.RevokedAt == nil {
+			s.Revoke(id)
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (s *memorySessions) RevokedSince(since time.Time) ([]string, error) { return nil, nil }
+
+type memoryUsers map[int]models.User
+
+func (u memoryUsers) Get(id int) (models.User, error) { return u[id], nil }
+
+func newTestManager() (*SessionManager, *memorySessions, memoryUsers) {
+	store := &memorySessions{sessions: make(map[string]models.Session)}
+	users := memoryUsers{1: {ID: 1, Username: "curator", Role: models.RoleCurator}}
+	issue := func(user models.User, sid string, ttl time.Duration) (string, error) {
+		return user.Username + "@" + sid, nil
+	}
+	return NewSessionManager(store, users, issue, time.Minute, time.Hour), store, users
+}
+
+func TestSessionManager_RefreshRotatesAndDetectsReuse(t *testing.T) {
+	m, store, _ := newTestManager()
+
+	first, err := m.Start(models.User{ID: 1, Username: "curator"}, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	for _, sess := range store.sessions {
+		if sess.RefreshHash == first.RefreshToken {
+			t.Fatal("Refresh-токен не должен храниться в открытом виде")
+		}
+	}
+
+	second, err := m.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка обновления: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("Refresh-токен должен меняться при каждом обновлении")
+	}
+
+	// Старый токен предъявлен повторно - сеанс завершается целиком
+	if _, err := m.Refresh(first.RefreshToken); !errors.Is(err, ErrRefreshReused) {
+		t.Fatalf("Ожидалась ошибка повторного использования, получено %v", err)
+	}
+	if _, err := m.Refresh(second.RefreshToken); !errors.Is(err, ErrInvalidRefresh) {
+		t.Errorf("После завершения сеанса новый токен тоже не должен работать: %v", err)
+	}
+
+	var sid string
+	for id := range store.sessions {
+		sid = id
+	}
+	if !m.IsRevoked(sid) {
+		t.Error("Access-токены завершённого сеанса должны попасть в список отзыва")
+	}
+}
+
+func TestSessionManager_DisabledUserCannotRefresh(t *testing.T) {
+	m, _, users := newTestManager()
+
+	tokens, _ := m.Start(users[1], "", "")
+	u := users[1]
+	u.Disabled = true
+	users[1] = u
+
+	if _, err := m.Refresh(tokens.RefreshToken); !errors.Is(err, ErrUserDisabled) {
+		t.Errorf("Ожидалась ошибка блокировки, получено %v", err)
+	}
+	if _, err := m.Refresh("garbage"); !errors.Is(err, ErrInvalidRefresh) {
+		t.Errorf("Некорректный токен должен отклоняться: %v", err)
+	}
+}
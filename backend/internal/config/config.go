@@ -7,14 +7,32 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"dashboard/internal/logging"
+	"dashboard/internal/models"
+	"dashboard/internal/origin"
+	"dashboard/internal/quiet"
+
+	"github.com/robfig/cron/v3"
 )
 
+// DefaultJWTSecret - секрет по умолчанию, допустим только при APP_ENV=development
+const DefaultJWTSecret = "change-me-in-production"
+
 // Config содержит конфигурацию приложения
 type Config struct {
-	// Интервал обновления данных
+	// Файл, из которого загружена конфигурация ("" - только окружение)
+	File string
+
+	// Интервал обновления данных, если расписание не задано
 	RefreshInterval time.Duration
+	// Расписание обновления: cron-выражения (REFRESH_SCHEDULE через ";"),
+	// часовой пояс расписания и окна тишины вида "22:00-06:00"
+	RefreshSchedules  []string
+	RefreshTimezone   string
+	RefreshQuietHours []string
 
-	// Пути к файлам
+	// Пути к файлам (ATTENDANCE_INPUT, STATEMENT_INPUT и т.д., по умолчанию в корне проекта)
 	ProjectRoot      string
 	AttendanceInput  string
 	AttendanceOutput string
@@ -34,132 +52,213 @@ type Config struct {
 	DatabasePassword string
 	DatabaseName     string
 
+	// Окружение: development разрешает небезопасные значения по умолчанию
+	Env string
+
 	// JWT авторизация (из attendance-backend)
 	JWTSecret string
+	// Ключи подписи по kid и kid активного ключа (JWT_KEYS, JWT_ACTIVE_KID).
+	// Если JWT_KEYS не задан, используется JWT_SECRET с kid "default".
+	JWTKeys      map[string]string
+	JWTActiveKID string
+	JWTIssuer    string
+	JWTAudience  string
+	// Время жизни access-токена и refresh-токена (сеанса)
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// CORS: точные адреса, "*", поддомены "https://*.college.edu" и любой
+	// порт "http://localhost:*". Без CORS_ORIGINS в production разрешены
+	// только запросы с того же адреса, в development - локальные dev-серверы.
+	CORSOrigins          []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
 
-	// CORS (из attendance-backend)
-	CORSOrigins []string
+	// Заголовки безопасности; "off" отключает заголовок
+	SecurityCSP            string
+	SecurityHSTS           string
+	SecurityFrameOptions   string
+	SecurityReferrerPolicy string
 
 	// Алерты (из attendance-backend)
 	AbsenceThreshold int
 
-	// Логин (из attendance-backend)
+	// Резервный логин из окружения, когда БД с пользователями недоступна
 	LoginUser     string
 	LoginPassword string
 	LoginRole     string
+
+	// Защита от подбора паролей и квоты API. APIRateLimit = 0 отключает квоты.
+	LoginRateLimit   int // попыток входа в минуту с адреса и для логина
+	LoginMaxFailures int // неудач подряд до блокировки логина
+	LoginLockout     time.Duration
+	LoginLockoutMax  time.Duration
+	APIRateLimit     int // запросов в минуту на токен или ключ API
+
+	// Двухфакторная аутентификация (TOTP). Для ролей из MFARequiredRoles
+	// она обязательна, остальные подключают её по желанию.
+	MFARequiredRoles []string
+	MFAIssuer        string // название в приложении-аутентификаторе
+
+	// Вход через LDAP/Active Directory. Пустой LDAPURL отключает каталог,
+	// локальные учётные записи работают всегда.
+	LDAPURL                string
+	LDAPStartTLS           bool
+	LDAPInsecureSkipVerify bool
+	LDAPBindDN             string
+	LDAPBindPassword       string
+	LDAPBaseDN             string
+	LDAPUserFilter         string
+	LDAPGroupAttribute     string
+	// Сопоставление групп ролям: "группа:роль[:область|область];..."
+	LDAPGroupRoles string
+
+	// Вход через OpenID Connect. Пустой OIDCIssuer отключает SSO.
+	OIDCIssuer        string
+	OIDCClientID      string
+	OIDCClientSecret  string
+	OIDCRedirectURL   string
+	OIDCScopes        []string
+	OIDCUsernameClaim string
+	OIDCRoleClaim     string
+	// Сопоставление значений claim ролям, формат как у LDAPGroupRoles
+	OIDCClaimRoles string
+	// Куда вернуть браузер после входа (токены передаются во фрагменте URL)
+	OIDCPostLoginURL string
+
+	// Email уведомления (SMTP). Пустой SMTPHost отключает отправку писем.
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUser     string
+	SMTPPassword string
+	SMTPFrom     string
+	DigestCron   string
+	DashboardURL string
+
+	// Метрики Prometheus на /metrics. Если задан MetricsToken, запрос
+	// должен содержать "Authorization: Bearer <token>". Вне development
+	// токен обязателен.
+	MetricsEnabled bool
+	MetricsToken   string
+
+	// Логи: уровень (debug, info, warn, error) и формат (json, text)
+	LogLevel  string
+	LogFormat string
+
+	// Проверка готовности: допустимый возраст снимков данных (с последнего
+	// успешного обновления) и входных файлов. Ноль отключает проверку.
+	// Устаревшие снимки снимают готовность, входные файлы - только предупреждение.
+	HealthMaxDataAge  time.Duration
+	HealthMaxInputAge time.Duration
+
+	// Трассировка OpenTelemetry (по умолчанию выключена): спаны уходят
+	// по OTLP/HTTP в коллектор TracingEndpoint (host:port)
+	TracingEnabled     bool
+	TracingEndpoint    string
+	TracingInsecure    bool
+	TracingSampleRatio float64
+
+	// Остановка сервера: сколько ждать завершения текущей задачи обновления,
+	// прежде чем отменить её. Ноль - отменять сразу.
+	ShutdownDrainTimeout time.Duration
 }
 
-// Load загружает конфигурацию из переменных окружения или использует значения по умолчанию
+// Load загружает конфигурацию: файл из CONFIG_FILE (или config.yaml в корне
+// проекта), поверх него - переменные окружения
 func Load() (*Config, error) {
+	return LoadFile(os.Getenv("CONFIG_FILE"))
+}
+
+// LoadFile загружает конфигурацию из указанного файла. Пустой path -
+// файл ищется в корне проекта и в backend/, его отсутствие не ошибка.
+// Некорректные значения не заменяются молча значениями по умолчанию:
+// возвращается ошибка со списком всех проблем.
+func LoadFile(path string) (*Config, error) {
 	// Получаем корневую директорию проекта
 	// Используем рабочую директорию при запуске сервера
 	wd, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения рабочей директории: %v", err)
 	}
+	projectRoot := findProjectRoot(wd)
 
-	var projectRoot string
-	// Если запускаем из backend/, поднимаемся на уровень выше
-	if filepath.Base(wd) == "backend" {
-		projectRoot = filepath.Dir(wd)
-	} else {
-		// Ищем директорию с папкой public/ и backend/
-		current := wd
-		for {
-			// Проверяем наличие папок public/ и backend/ в текущей директории
-			publicExists := false
-			backendExists := false
-			
-			if _, err := os.Stat(filepath.Join(current, "public")); err == nil {
-				publicExists = true
-			}
-			if _, err := os.Stat(filepath.Join(current, "backend")); err == nil {
-				backendExists = true
-			}
-			
-			// Если есть обе папки - это корень проекта
-			if publicExists && backendExists {
-				projectRoot = current
-				break
-			}
-			
-			// Поднимаемся на уровень выше
-			parent := filepath.Dir(current)
-			if parent == current || parent == "/" {
-				// Дошли до корня, не нашли
+	// Проверяем, что директория существует и содержит public/
+	if _, err := os.Stat(filepath.Join(projectRoot, "public")); os.IsNotExist(err) {
+		return nil, fmt.Errorf("не найдена директория проекта (ожидается папка 'public' в %s)", projectRoot)
+	}
+
+	src := &source{}
+	if path == "" {
+		for _, candidate := range []string{
+			filepath.Join(projectRoot, "config.yaml"),
+			filepath.Join(projectRoot, "backend", "config.yaml"),
+		} {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
 				break
 			}
-			current = parent
-		}
-		
-		// Если не нашли, пробуем найти по наличию public/
-		if projectRoot == "" {
-			current := wd
-			for {
-				if _, err := os.Stat(filepath.Join(current, "public")); err == nil {
-					projectRoot = current
-					break
-				}
-				parent := filepath.Dir(current)
-				if parent == current || parent == "/" {
-					break
-				}
-				current = parent
-			}
 		}
-		
-		// Если всё ещё не нашли, используем текущую директорию
-		if projectRoot == "" {
-			projectRoot = wd
+	}
+	if path != "" {
+		if src.file, err = readFile(path); err != nil {
+			return nil, err
 		}
+		src.path = path
 	}
 
-	// Проверяем, что директория существует и содержит public/
-	if _, err := os.Stat(filepath.Join(projectRoot, "public")); os.IsNotExist(err) {
-		return nil, fmt.Errorf("не найдена директория проекта (ожидается папка 'public' в %s)", projectRoot)
+	// Расписание обновления: без REFRESH_SCHEDULE - каждые REFRESH_INTERVAL
+	// (по умолчанию 90 минут)
+	refreshInterval := src.duration("REFRESH_INTERVAL", 90*time.Minute, time.Minute)
+	refreshSchedules := src.schedules("REFRESH_SCHEDULE")
+	if len(refreshSchedules) == 0 {
+		refreshSchedules = []string{fmt.Sprintf("@every %v", refreshInterval)}
 	}
-
-	// Интервал обновления (по умолчанию 90 минут)
-	refreshInterval := 90 * time.Minute
-	if intervalStr := os.Getenv("REFRESH_INTERVAL"); intervalStr != "" {
-		if parsed, err := time.ParseDuration(intervalStr); err == nil {
-			refreshInterval = parsed
+	for _, spec := range refreshSchedules {
+		if _, err := cron.ParseStandard(spec); err != nil {
+			src.fail("REFRESH_SCHEDULE", "некорректное cron-выражение %q: %v", spec, err)
 		}
 	}
-
-	// Порт сервера (по умолчанию 8080)
-	serverPort := os.Getenv("SERVER_PORT")
-	if serverPort == "" {
-		serverPort = "8080"
+	refreshTimezone := src.str("REFRESH_TIMEZONE", "Local")
+	if _, err := time.LoadLocation(refreshTimezone); err != nil {
+		src.fail("REFRESH_TIMEZONE", "неизвестный часовой пояс %q", refreshTimezone)
+	}
+	refreshQuiet := src.list("REFRESH_QUIET_HOURS")
+	if _, err := quiet.Parse(refreshQuiet); err != nil {
+		src.fail("REFRESH_QUIET_HOURS", "%v", err)
 	}
 
-	// Хост сервера (по умолчанию localhost)
-	serverHost := os.Getenv("SERVER_HOST")
-	if serverHost == "" {
-		serverHost = "localhost"
+	// Сервер (по умолчанию localhost:8080)
+	serverPort := strconv.Itoa(src.integer("SERVER_PORT", 8080, 1, 65535))
+	serverHost := src.str("SERVER_HOST", "localhost")
+
+	// Пути к файлам: относительные пути считаются от корня проекта
+	resolve := func(name, def string) string {
+		p := src.str(name, def)
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(projectRoot, p)
+		}
+		return p
+	}
+	attendanceInput := resolve("ATTENDANCE_INPUT", "Посещаемость.xlsx")
+	attendanceOutput := resolve("ATTENDANCE_OUTPUT", filepath.Join("public", "attendance.json"))
+	statementInput := resolve("STATEMENT_INPUT", "ведомость.xls")
+	statementOutput := resolve("STATEMENT_OUTPUT", filepath.Join("public", "summary.json"))
+	pythonScript := resolve("PYTHON_SCRIPT", filepath.Join("statement-converter", "xls_to_xlsx.py"))
+	for name, p := range map[string]string{"ATTENDANCE_OUTPUT": attendanceOutput, "STATEMENT_OUTPUT": statementOutput} {
+		if st, err := os.Stat(filepath.Dir(p)); err != nil || !st.IsDir() {
+			src.fail(name, "директория %s не существует", filepath.Dir(p))
+		}
 	}
 
 	// Настройки БД
-	databaseURL := os.Getenv("DATABASE_URL")
+	databaseURL := src.get("DATABASE_URL")
 	if databaseURL == "" {
 		// Формируем URL из отдельных параметров, если DATABASE_URL не указан
-		dbHost := os.Getenv("DB_HOST")
-		if dbHost == "" {
-			dbHost = "localhost"
-		}
-		dbPort := os.Getenv("DB_PORT")
-		if dbPort == "" {
-			dbPort = "5432"
-		}
-		dbUser := os.Getenv("DB_USER")
-		if dbUser == "" {
-			dbUser = "postgres"
-		}
-		dbPassword := os.Getenv("DB_PASSWORD")
-		dbName := os.Getenv("DB_NAME")
-		if dbName == "" {
-			dbName = "dashboard"
-		}
+		dbHost := src.str("DB_HOST", "localhost")
+		dbPort := strconv.Itoa(src.integer("DB_PORT", 5432, 1, 65535))
+		dbUser := src.str("DB_USER", "postgres")
+		dbPassword := src.get("DB_PASSWORD")
+		dbName := src.str("DB_NAME", "dashboard")
 
 		if dbPassword != "" {
 			databaseURL = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
@@ -168,74 +267,356 @@ func Load() (*Config, error) {
 	}
 
 	// JWT Secret (из attendance-backend)
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "change-me-in-production"
-	}
-
-	// CORS Origins (из attendance-backend)
-	corsEnv := strings.TrimSpace(os.Getenv("CORS_ORIGINS"))
-	var corsOrigins []string
-	if corsEnv == "" || corsEnv == "*" {
-		corsOrigins = []string{"*"}
-	} else {
-		parts := strings.Split(corsEnv, ",")
-		trimmed := make([]string, 0, len(parts))
-		for _, o := range parts {
-			s := strings.TrimSpace(o)
-			if s != "" {
-				trimmed = append(trimmed, s)
+	jwtSecret := src.str("JWT_SECRET", DefaultJWTSecret)
+
+	// Окружение (по умолчанию production)
+	env := strings.ToLower(src.str("APP_ENV", "production"))
+
+	// Ключи подписи JWT: JWT_KEYS="kid1:secret1,kid2:secret2", JWT_ACTIVE_KID=kid2
+	jwtKeys, err := parseKeys(src.get("JWT_KEYS"))
+	if err != nil {
+		src.fail("JWT_KEYS", "%v", err)
+	}
+	activeKID := src.str("JWT_ACTIVE_KID", "")
+	if len(jwtKeys) == 0 {
+		jwtKeys = map[string]string{"default": jwtSecret}
+		if activeKID == "" {
+			activeKID = "default"
+		}
+	}
+	if activeKID == "" && len(jwtKeys) == 1 {
+		for kid := range jwtKeys {
+			activeKID = kid
+		}
+	}
+	if activeKID == "" {
+		src.fail("JWT_ACTIVE_KID", "не указан, а в JWT_KEYS несколько ключей")
+	} else if _, ok := jwtKeys[activeKID]; !ok {
+		src.fail("JWT_ACTIVE_KID", "ключ %s отсутствует в JWT_KEYS", activeKID)
+	}
+	if env != "development" {
+		for kid, secret := range jwtKeys {
+			if secret == DefaultJWTSecret {
+				src.fail("JWT_SECRET", "ключ JWT %q имеет значение по умолчанию: задайте JWT_SECRET или JWT_KEYS (или APP_ENV=development для локальной разработки)", kid)
 			}
 		}
-		if len(trimmed) == 0 {
-			trimmed = []string{"http://localhost:3000", "http://localhost:5173"}
+	}
+	jwtIssuer := src.str("JWT_ISSUER", "dashboard")
+	jwtAudience := src.str("JWT_AUDIENCE", "dashboard-api")
+
+	// Время жизни токенов: короткий access-токен и длинный сеанс с ротацией refresh-токена
+	accessTTL := src.duration("ACCESS_TOKEN_TTL", 15*time.Minute, time.Minute)
+	refreshTTL := src.duration("REFRESH_TOKEN_TTL", 30*24*time.Hour, time.Minute)
+	if refreshTTL <= accessTTL {
+		src.fail("REFRESH_TOKEN_TTL", "должно быть больше ACCESS_TOKEN_TTL (%s)", accessTTL)
+	}
+
+	// CORS: политика зависит от окружения
+	corsOrigins := src.list("CORS_ORIGINS")
+	if len(corsOrigins) == 0 && env == "development" {
+		corsOrigins = []string{
+			"http://localhost:5173", // Vite dev server
+			"http://localhost:3000", // React dev server
+			"http://localhost:8080", // Тот же сервер
 		}
-		corsOrigins = trimmed
 	}
+	if _, err := origin.Parse(corsOrigins); err != nil {
+		src.fail("CORS_ORIGINS", "%v", err)
+	}
+	for _, o := range corsOrigins {
+		if o == "*" && env != "development" {
+			src.fail("CORS_ORIGINS", "\"*\" допустим только при APP_ENV=development, перечислите адреса дашборда")
+		}
+	}
+	corsCredentials := true
+	if src.get("CORS_ALLOW_CREDENTIALS") != "" {
+		corsCredentials = src.boolean("CORS_ALLOW_CREDENTIALS")
+	}
+	corsMaxAge := src.duration("CORS_MAX_AGE", 12*time.Hour, 0)
+
+	// Заголовки безопасности. API отдаёт только JSON, поэтому CSP запрещает всё;
+	// HSTS по умолчанию только вне development (локально нет HTTPS).
+	securityHSTS := "max-age=31536000; includeSubDomains"
+	if env == "development" {
+		securityHSTS = "off"
+	}
+	securityHSTS = src.str("SECURITY_HSTS", securityHSTS)
+	securityFrame := strings.ToUpper(src.str("SECURITY_FRAME_OPTIONS", "DENY"))
+	if securityFrame != "DENY" && securityFrame != "SAMEORIGIN" && securityFrame != "OFF" {
+		src.fail("SECURITY_FRAME_OPTIONS", "ожидается DENY, SAMEORIGIN или off, получено %q", securityFrame)
+	}
+
+	// Порог пропусков для встроенного правила алертов, %
+	threshold := src.integer("ABSENCE_THRESHOLD", 10, 1, 100)
 
-	// Absence Threshold (из attendance-backend)
-	threshold, _ := strconv.Atoi(os.Getenv("ABSENCE_THRESHOLD"))
-	if threshold <= 0 || threshold > 100 {
-		threshold = 10
+	// Login credentials (из attendance-backend). Используются только без БД,
+	// пароля по умолчанию нет: без LOGIN_PASSWORD такой вход отключён.
+	loginUser := src.str("LOGIN_USER", "admin")
+	loginPassword := src.get("LOGIN_PASSWORD")
+	loginRole := src.str("LOGIN_ROLE", models.RoleAdmin)
+	if !models.ValidRole(loginRole) {
+		src.fail("LOGIN_ROLE", "неизвестная роль %q", loginRole)
 	}
 
-	// Login credentials (из attendance-backend)
-	loginUser := os.Getenv("LOGIN_USER")
-	if loginUser == "" {
-		loginUser = "admin"
+	// Защита входа: 10 попыток в минуту, блокировка после 5 неудач на 1 минуту,
+	// повторные блокировки вдвое дольше, но не больше часа
+	loginRate := src.integer("LOGIN_RATE_LIMIT", 10, 1, 10000)
+	loginMaxFailures := src.integer("LOGIN_MAX_FAILURES", 5, 1, 1000)
+	loginLockout := src.duration("LOGIN_LOCKOUT", time.Minute, time.Second)
+	loginLockoutMax := src.duration("LOGIN_LOCKOUT_MAX", time.Hour, time.Second)
+	if loginLockoutMax < loginLockout {
+		src.fail("LOGIN_LOCKOUT_MAX", "должно быть не меньше LOGIN_LOCKOUT (%s)", loginLockout)
 	}
-	loginPassword := os.Getenv("LOGIN_PASSWORD")
-	if loginPassword == "" {
-		loginPassword = "admin"
+	apiRate := src.integer("API_RATE_LIMIT", 600, 0, 1000000)
+
+	// 2FA: MFA_REQUIRED_ROLES="admin,department_head"
+	mfaRoles := src.list("MFA_REQUIRED_ROLES")
+	for _, r := range mfaRoles {
+		if !models.ValidRole(r) {
+			src.fail("MFA_REQUIRED_ROLES", "неизвестная роль %q", r)
+		}
+	}
+	mfaIssuer := src.str("MFA_ISSUER", "Dashboard")
+
+	// LDAP (по умолчанию схема OpenLDAP/glauth: uid и memberOf)
+	ldapURL := src.get("LDAP_URL")
+	ldapStartTLS := src.boolean("LDAP_START_TLS")
+	ldapInsecure := src.boolean("LDAP_INSECURE_SKIP_VERIFY")
+	ldapUserFilter := src.str("LDAP_USER_FILTER", "(uid=%s)")
+	if !strings.Contains(ldapUserFilter, "%s") {
+		src.fail("LDAP_USER_FILTER", "фильтр должен содержать %%s на месте логина")
+	}
+	ldapGroupAttr := src.str("LDAP_GROUP_ATTRIBUTE", "memberOf")
+	if ldapURL != "" && src.get("LDAP_BASE_DN") == "" {
+		src.fail("LDAP_BASE_DN", "обязателен, если задан LDAP_URL")
+	}
+
+	// OIDC (адрес возврата после входа по умолчанию - сам дашборд)
+	oidcIssuer := src.get("OIDC_ISSUER")
+	if oidcIssuer != "" {
+		for _, name := range []string{"OIDC_CLIENT_ID", "OIDC_REDIRECT_URL"} {
+			if src.get(name) == "" {
+				src.fail(name, "обязателен, если задан OIDC_ISSUER")
+			}
+		}
+	}
+	oidcPostLogin := src.str("OIDC_POST_LOGIN_URL", src.str("DASHBOARD_URL", "/"))
+
+	// SMTP (по умолчанию порт 1025 - локальный MailHog)
+	smtpPort := strconv.Itoa(src.integer("SMTP_PORT", 1025, 1, 65535))
+	smtpFrom := src.str("SMTP_FROM", "dashboard@localhost")
+	// Еженедельная сводка (по умолчанию понедельник, 8:00)
+	digestCron := src.str("NOTIFY_DIGEST_CRON", "0 8 * * 1")
+	if _, err := cron.ParseStandard(digestCron); err != nil {
+		src.fail("NOTIFY_DIGEST_CRON", "некорректное cron-выражение %q: %v", digestCron, err)
+	}
+
+	// Метрики: вне development /metrics отдаётся только с токеном, поэтому
+	// по умолчанию включены в development или если токен задан
+	metricsToken := src.get("METRICS_TOKEN")
+	metricsEnabled := env == "development" || metricsToken != ""
+	if src.get("METRICS_ENABLED") != "" {
+		metricsEnabled = src.boolean("METRICS_ENABLED")
+	}
+	if metricsEnabled && metricsToken == "" && env != "development" {
+		src.fail("METRICS_ENABLED", "вне development для /metrics нужен METRICS_TOKEN")
+	}
+
+	// Логи: в разработке по умолчанию текст, иначе JSON для сборщика логов
+	logLevel := strings.ToLower(src.str("LOG_LEVEL", "info"))
+	if _, err := logging.ParseLevel(logLevel); err != nil {
+		src.fail("LOG_LEVEL", "%v", err)
+	}
+	logFormat := "json"
+	if env == "development" {
+		logFormat = "text"
+	}
+	logFormat = strings.ToLower(src.str("LOG_FORMAT", logFormat))
+	if logFormat != "json" && logFormat != "text" {
+		src.fail("LOG_FORMAT", "ожидается json или text, получено %q", logFormat)
+	}
+
+	// Готовность (по умолчанию данные не старше суток, входные файлы - недели)
+	healthMaxDataAge := src.duration("HEALTH_MAX_DATA_AGE", 24*time.Hour, 0)
+	healthMaxInputAge := src.duration("HEALTH_MAX_INPUT_AGE", 7*24*time.Hour, 0)
+
+	// Трассировка: по умолчанию выключена, коллектор на localhost без TLS
+	tracingEnabled := src.boolean("TRACING_ENABLED")
+	tracingSampleRatio := src.ratio("TRACING_SAMPLE_RATIO", 1)
+	tracingEndpoint := src.str("TRACING_ENDPOINT", "localhost:4318")
+	if strings.Contains(tracingEndpoint, "://") {
+		src.fail("TRACING_ENDPOINT", "ожидается host:port без схемы, получено %q", tracingEndpoint)
 	}
-	loginRole := os.Getenv("LOGIN_ROLE")
-	if loginRole == "" {
-		loginRole = "admin"
+	tracingInsecure := true
+	if src.get("TRACING_INSECURE") != "" {
+		tracingInsecure = src.boolean("TRACING_INSECURE")
+	}
+
+	shutdownDrain := src.duration("SHUTDOWN_DRAIN_TIMEOUT", 25*time.Second, 0)
+
+	if err := src.err(); err != nil {
+		return nil, err
 	}
 
 	cfg := &Config{
-		RefreshInterval:  refreshInterval,
-		ProjectRoot:      projectRoot,
-		AttendanceInput:  filepath.Join(projectRoot, "Посещаемость.xlsx"),
-		AttendanceOutput: filepath.Join(projectRoot, "public", "attendance.json"),
-		StatementInput:   filepath.Join(projectRoot, "ведомость.xls"),
-		StatementOutput:  filepath.Join(projectRoot, "public", "summary.json"),
-		PythonScript:     filepath.Join(projectRoot, "statement-converter", "xls_to_xlsx.py"),
-		ServerPort:       serverPort,
-		ServerHost:       serverHost,
-		DatabaseURL:      databaseURL,
-		DatabaseHost:     os.Getenv("DB_HOST"),
-		DatabasePort:     os.Getenv("DB_PORT"),
-		DatabaseUser:     os.Getenv("DB_USER"),
-		DatabasePassword: os.Getenv("DB_PASSWORD"),
-		DatabaseName:     os.Getenv("DB_NAME"),
-		JWTSecret:        jwtSecret,
-		CORSOrigins:      corsOrigins,
-		AbsenceThreshold: threshold,
-		LoginUser:        loginUser,
-		LoginPassword:    loginPassword,
-		LoginRole:        loginRole,
+		File:                   src.path,
+		RefreshInterval:        refreshInterval,
+		RefreshSchedules:       refreshSchedules,
+		RefreshTimezone:        refreshTimezone,
+		RefreshQuietHours:      refreshQuiet,
+		ProjectRoot:            projectRoot,
+		AttendanceInput:        attendanceInput,
+		AttendanceOutput:       attendanceOutput,
+		StatementInput:         statementInput,
+		StatementOutput:        statementOutput,
+		PythonScript:           pythonScript,
+		ServerPort:             serverPort,
+		ServerHost:             serverHost,
+		DatabaseURL:            databaseURL,
+		DatabaseHost:           src.get("DB_HOST"),
+		DatabasePort:           src.get("DB_PORT"),
+		DatabaseUser:           src.get("DB_USER"),
+		DatabasePassword:       src.get("DB_PASSWORD"),
+		DatabaseName:           src.get("DB_NAME"),
+		Env:                    env,
+		JWTSecret:              jwtSecret,
+		JWTKeys:                jwtKeys,
+		JWTActiveKID:           activeKID,
+		JWTIssuer:              jwtIssuer,
+		JWTAudience:            jwtAudience,
+		AccessTokenTTL:         accessTTL,
+		RefreshTokenTTL:        refreshTTL,
+		CORSOrigins:            corsOrigins,
+		CORSAllowCredentials:   corsCredentials,
+		CORSMaxAge:             corsMaxAge,
+		SecurityCSP:            src.str("SECURITY_CSP", "default-src 'none'; frame-ancestors 'none'"),
+		SecurityHSTS:           securityHSTS,
+		SecurityFrameOptions:   strings.ToLower(securityFrame),
+		SecurityReferrerPolicy: src.str("SECURITY_REFERRER_POLICY", "strict-origin-when-cross-origin"),
+		AbsenceThreshold:       threshold,
+		LoginUser:              loginUser,
+		LoginPassword:          loginPassword,
+		LoginRole:              loginRole,
+		LoginRateLimit:         loginRate,
+		LoginMaxFailures:       loginMaxFailures,
+		LoginLockout:           loginLockout,
+		LoginLockoutMax:        loginLockoutMax,
+		APIRateLimit:           apiRate,
+		MFARequiredRoles:       mfaRoles,
+		MFAIssuer:              mfaIssuer,
+		LDAPURL:                ldapURL,
+		LDAPStartTLS:           ldapStartTLS,
+		LDAPInsecureSkipVerify: ldapInsecure,
+		LDAPBindDN:             src.get("LDAP_BIND_DN"),
+		LDAPBindPassword:       src.get("LDAP_BIND_PASSWORD"),
+		LDAPBaseDN:             src.get("LDAP_BASE_DN"),
+		LDAPUserFilter:         ldapUserFilter,
+		LDAPGroupAttribute:     ldapGroupAttr,
+		LDAPGroupRoles:         src.get("LDAP_GROUP_ROLES"),
+		OIDCIssuer:             oidcIssuer,
+		OIDCClientID:           src.get("OIDC_CLIENT_ID"),
+		OIDCClientSecret:       src.get("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:        src.get("OIDC_REDIRECT_URL"),
+		OIDCScopes:             src.list("OIDC_SCOPES"),
+		OIDCUsernameClaim:      src.get("OIDC_USERNAME_CLAIM"),
+		OIDCRoleClaim:          src.get("OIDC_ROLE_CLAIM"),
+		OIDCClaimRoles:         src.get("OIDC_CLAIM_ROLES"),
+		OIDCPostLoginURL:       oidcPostLogin,
+		SMTPHost:               src.get("SMTP_HOST"),
+		SMTPPort:               smtpPort,
+		SMTPUser:               src.get("SMTP_USER"),
+		SMTPPassword:           src.get("SMTP_PASSWORD"),
+		SMTPFrom:               smtpFrom,
+		DigestCron:             digestCron,
+		DashboardURL:           src.get("DASHBOARD_URL"),
+		MetricsEnabled:         metricsEnabled,
+		MetricsToken:           metricsToken,
+		LogLevel:               logLevel,
+		LogFormat:              logFormat,
+		HealthMaxDataAge:       healthMaxDataAge,
+		HealthMaxInputAge:      healthMaxInputAge,
+		TracingEnabled:         tracingEnabled,
+		TracingEndpoint:        tracingEndpoint,
+		TracingInsecure:        tracingInsecure,
+		TracingSampleRatio:     tracingSampleRatio,
+		ShutdownDrainTimeout:   shutdownDrain,
 	}
 
 	return cfg, nil
 }
+
+// Warnings возвращает проблемы, не мешающие запуску: например, входных
+// файлов ещё нет, и обновление данных будет завершаться ошибкой
+func (c *Config) Warnings() []string {
+	var out []string
+	for _, p := range []string{c.AttendanceInput, c.StatementInput, c.PythonScript} {
+		if _, err := os.Stat(p); err != nil {
+			out = append(out, fmt.Sprintf("файл %s не найден", p))
+		}
+	}
+	if c.DatabaseURL == "" {
+		out = append(out, "БД не настроена: пользователи, алерты и журнал аудита недоступны")
+	}
+	return out
+}
+
+// findProjectRoot ищет корень проекта: директорию с public/ и backend/
+func findProjectRoot(wd string) string {
+	// Если запускаем из backend/, поднимаемся на уровень выше
+	if filepath.Base(wd) == "backend" {
+		return filepath.Dir(wd)
+	}
+
+	// Ищем директорию с папкой public/ и backend/
+	current := wd
+	for {
+		_, publicErr := os.Stat(filepath.Join(current, "public"))
+		_, backendErr := os.Stat(filepath.Join(current, "backend"))
+		// Если есть обе папки - это корень проекта
+		if publicErr == nil && backendErr == nil {
+			return current
+		}
+		// Поднимаемся на уровень выше
+		parent := filepath.Dir(current)
+		if parent == current || parent == "/" {
+			break
+		}
+		current = parent
+	}
+
+	// Если не нашли, пробуем найти по наличию public/
+	current = wd
+	for {
+		if _, err := os.Stat(filepath.Join(current, "public")); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current || parent == "/" {
+			break
+		}
+		current = parent
+	}
+
+	// Если всё ещё не нашли, используем текущую директорию
+	return wd
+}
+
+// parseKeys разбирает список ключей вида "kid1:secret1,kid2:secret2"
+func parseKeys(raw string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kid, secret, ok := strings.Cut(part, ":")
+		if !ok || kid == "" || secret == "" {
+			return nil, fmt.Errorf("некорректный элемент: ожидается kid:secret")
+		}
+		if _, dup := keys[kid]; dup {
+			return nil, fmt.Errorf("повторяющийся kid %s", kid)
+		}
+		keys[kid] = secret
+	}
+	return keys, nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte(`
+server:
+  port: 9090
+alerts:
+  absence_threshold: 25
+cors:
+  origins: [https://a.example.edu, https://b.example.edu]
+jwt:
+  keys: {k2: two, k1: one}
+refresh:
+  schedule: ["*/30 8-17 * * 1-5", "0 2 * * *"]
+`), 0o600)
+
+	values, err := readFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"SERVER_PORT":       "9090",
+		"ABSENCE_THRESHOLD": "25",
+		"CORS_ORIGINS":      "https://a.example.edu,https://b.example.edu",
+		"JWT_KEYS":          "k1:one,k2:two",
+		"REFRESH_SCHEDULE":  "*/30 8-17 * * 1-5;0 2 * * *",
+	} {
+		if values[name] != want {
+			t.Errorf("%s: получено %q, ожидалось %q", name, values[name], want)
+		}
+	}
+
+	os.WriteFile(path, []byte("server:\n  prot: 9090\nalerts:\n  treshold: 5\n"), 0o600)
+	if _, err := readFile(path); err == nil || !strings.Contains(err.Error(), "server.prot, alerts.treshold") &&
+		!strings.Contains(err.Error(), "alerts.treshold, server.prot") {
+		t.Errorf("опечатки в ключах должны быть ошибкой: %v", err)
+	}
+}
+
+func TestSourceValidation(t *testing.T) {
+	src := &source{path: "config.yaml", file: map[string]string{
+		"REFRESH_INTERVAL":  "90 минут",
+		"ABSENCE_THRESHOLD": "150",
+		"SERVER_PORT":       "8081",
+	}}
+	t.Setenv("SERVER_PORT", "9000")
+
+	if d := src.duration("REFRESH_INTERVAL", time.Hour, time.Minute); d != time.Hour {
+		t.Errorf("при ошибке возвращается значение по умолчанию, получено %v", d)
+	}
+	src.integer("ABSENCE_THRESHOLD", 10, 1, 100)
+	if port := src.integer("SERVER_PORT", 8080, 1, 65535); port != 9000 {
+		t.Errorf("окружение должно переопределять файл, получено %d", port)
+	}
+
+	err := src.err()
+	if err == nil {
+		t.Fatal("ожидались ошибки конфигурации")
+	}
+	for _, want := range []string{"REFRESH_INTERVAL (refresh.interval)", "ABSENCE_THRESHOLD (alerts.absence_threshold)", `"150"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("в ошибке нет %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestWatcherAppliesOnlyReloadable(t *testing.T) {
+	cfg := &Config{AbsenceThreshold: 10, CORSOrigins: []string{"*"}, ServerPort: "8080"}
+	next := &Config{AbsenceThreshold: 20, CORSOrigins: []string{"https://a.example.edu"}, ServerPort: "9090"}
+	w := NewWatcher(cfg, func() (*Config, error) { return next, nil })
+
+	var got *Config
+	w.OnReload(func(c *Config) { got = c })
+
+	applied, restart, err := w.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 || len(restart) != 1 || restart[0] != "ServerPort" {
+		t.Errorf("неверное разделение изменений: %v / %v", applied, restart)
+	}
+	if got == nil || got.AbsenceThreshold != 20 || got.CORSOrigins[0] != "https://a.example.edu" {
+		t.Fatalf("подписчик не получил новые значения: %+v", got)
+	}
+	if got.ServerPort != "8080" || w.Current().ServerPort != "8080" {
+		t.Error("порт меняется только после перезапуска")
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"reflect"
+	"sync"
+)
+
+// reloadable - поля Config, которые применяются без перезапуска (SIGHUP).
+// Остальные изменения вступают в силу только после перезапуска сервера.
+var reloadable = map[string]bool{
+	"AbsenceThreshold":       true,
+	"RefreshInterval":        true,
+	"RefreshSchedules":       true,
+	"RefreshTimezone":        true,
+	"RefreshQuietHours":      true,
+	"CORSOrigins":            true,
+	"SecurityCSP":            true,
+	"SecurityHSTS":           true,
+	"SecurityFrameOptions":   true,
+	"SecurityReferrerPolicy": true,
+	"LogLevel":               true,
+	"HealthMaxDataAge":       true,
+	"HealthMaxInputAge":      true,
+}
+
+// Changes сравнивает две конфигурации и возвращает имена изменившихся
+// полей: применимых на лету и требующих перезапуска
+func Changes(old, updated *Config) (applied, restart []string) {
+	ov, nv := reflect.ValueOf(*old), reflect.ValueOf(*updated)
+	t := ov.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		if name == "File" || reflect.DeepEqual(ov.Field(i).Interface(), nv.Field(i).Interface()) {
+			continue
+		}
+		if reloadable[name] {
+			applied = append(applied, name)
+		} else {
+			restart = append(restart, name)
+		}
+	}
+	return applied, restart
+}
+
+// Watcher хранит действующую конфигурацию и перечитывает её по запросу.
+// Подписчики получают новую конфигурацию, в которой изменены только
+// безопасные для перезагрузки поля.
+type Watcher struct {
+	load func() (*Config, error)
+
+	mu        sync.Mutex
+	current   *Config
+	listeners []func(*Config)
+}
+
+// NewWatcher создаёт наблюдатель за конфигурацией. load перечитывает
+// файл и окружение (обычно config.Load).
+func NewWatcher(cfg *Config, load func() (*Config, error)) *Watcher {
+	return &Watcher{load: load, current: cfg}
+}
+
+// OnReload подписывает функцию на применённые изменения
+func (w *Watcher) OnReload(fn func(*Config)) {
+	w.mu.Lock()
+	w.listeners = append(w.listeners, fn)
+	w.mu.Unlock()
+}
+
+// Current возвращает действующую конфигурацию
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Reload перечитывает конфигурацию. При ошибке действующая конфигурация
+// не меняется. Возвращает применённые поля и поля, ждущие перезапуска.
+func (w *Watcher) Reload() (applied, restart []string, err error) {
+	updated, err := w.load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w.mu.Lock()
+	applied, restart = Changes(w.current, updated)
+	if len(applied) == 0 {
+		w.mu.Unlock()
+		return nil, restart, nil
+	}
+	next := *w.current
+	nv, dst := reflect.ValueOf(*updated), reflect.ValueOf(&next).Elem()
+	for _, name := range applied {
+		dst.FieldByName(name).Set(nv.FieldByName(name))
+	}
+	w.current = &next
+	listeners := append([]func(*Config){}, w.listeners...)
+	w.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(&next)
+	}
+	return applied, restart, nil
+}
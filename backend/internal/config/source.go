@@ -0,0 +1,308 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileKeys сопоставляет переменные окружения ключам файла конфигурации.
+// Переменная окружения, если задана, переопределяет значение из файла.
+var fileKeys = map[string]string{
+	"APP_ENV":          "env",
+	"SERVER_HOST":      "server.host",
+	"SERVER_PORT":      "server.port",
+	"REFRESH_INTERVAL": "refresh.interval",
+
+	"REFRESH_SCHEDULE":    "refresh.schedule",
+	"REFRESH_TIMEZONE":    "refresh.timezone",
+	"REFRESH_QUIET_HOURS": "refresh.quiet_hours",
+
+	"ATTENDANCE_INPUT":  "paths.attendance_input",
+	"ATTENDANCE_OUTPUT": "paths.attendance_output",
+	"STATEMENT_INPUT":   "paths.statement_input",
+	"STATEMENT_OUTPUT":  "paths.statement_output",
+	"PYTHON_SCRIPT":     "paths.python_script",
+
+	"DATABASE_URL": "database.url",
+	"DB_HOST":      "database.host",
+	"DB_PORT":      "database.port",
+	"DB_USER":      "database.user",
+	"DB_PASSWORD":  "database.password",
+	"DB_NAME":      "database.name",
+
+	"JWT_SECRET":        "jwt.secret",
+	"JWT_KEYS":          "jwt.keys",
+	"JWT_ACTIVE_KID":    "jwt.active_kid",
+	"JWT_ISSUER":        "jwt.issuer",
+	"JWT_AUDIENCE":      "jwt.audience",
+	"ACCESS_TOKEN_TTL":  "jwt.access_token_ttl",
+	"REFRESH_TOKEN_TTL": "jwt.refresh_token_ttl",
+
+	"CORS_ORIGINS":           "cors.origins",
+	"CORS_ALLOW_CREDENTIALS": "cors.allow_credentials",
+	"CORS_MAX_AGE":           "cors.max_age",
+
+	"SECURITY_CSP":             "security.content_security_policy",
+	"SECURITY_HSTS":            "security.hsts",
+	"SECURITY_FRAME_OPTIONS":   "security.frame_options",
+	"SECURITY_REFERRER_POLICY": "security.referrer_policy",
+
+	"ABSENCE_THRESHOLD": "alerts.absence_threshold",
+
+	"LOGIN_USER":         "login.user",
+	"LOGIN_PASSWORD":     "login.password",
+	"LOGIN_ROLE":         "login.role",
+	"LOGIN_RATE_LIMIT":   "login.rate_limit",
+	"LOGIN_MAX_FAILURES": "login.max_failures",
+	"LOGIN_LOCKOUT":      "login.lockout",
+	"LOGIN_LOCKOUT_MAX":  "login.lockout_max",
+	"API_RATE_LIMIT":     "api.rate_limit",
+
+	"MFA_REQUIRED_ROLES": "mfa.required_roles",
+	"MFA_ISSUER":         "mfa.issuer",
+
+	"LDAP_URL":                  "ldap.url",
+	"LDAP_START_TLS":            "ldap.start_tls",
+	"LDAP_INSECURE_SKIP_VERIFY": "ldap.insecure_skip_verify",
+	"LDAP_BIND_DN":              "ldap.bind_dn",
+	"LDAP_BIND_PASSWORD":        "ldap.bind_password",
+	"LDAP_BASE_DN":              "ldap.base_dn",
+	"LDAP_USER_FILTER":          "ldap.user_filter",
+	"LDAP_GROUP_ATTRIBUTE":      "ldap.group_attribute",
+	"LDAP_GROUP_ROLES":          "ldap.group_roles",
+
+	"OIDC_ISSUER":         "oidc.issuer",
+	"OIDC_CLIENT_ID":      "oidc.client_id",
+	"OIDC_CLIENT_SECRET":  "oidc.client_secret",
+	"OIDC_REDIRECT_URL":   "oidc.redirect_url",
+	"OIDC_SCOPES":         "oidc.scopes",
+	"OIDC_USERNAME_CLAIM": "oidc.username_claim",
+	"OIDC_ROLE_CLAIM":     "oidc.role_claim",
+	"OIDC_CLAIM_ROLES":    "oidc.claim_roles",
+	"OIDC_POST_LOGIN_URL": "oidc.post_login_url",
+
+	"SMTP_HOST":          "smtp.host",
+	"SMTP_PORT":          "smtp.port",
+	"SMTP_USER":          "smtp.user",
+	"SMTP_PASSWORD":      "smtp.password",
+	"SMTP_FROM":          "smtp.from",
+	"NOTIFY_DIGEST_CRON": "notify.digest_cron",
+	"DASHBOARD_URL":      "dashboard_url",
+
+	"METRICS_ENABLED": "metrics.enabled",
+	"METRICS_TOKEN":   "metrics.token",
+
+	"LOG_LEVEL":  "log.level",
+	"LOG_FORMAT": "log.format",
+
+	"HEALTH_MAX_DATA_AGE":  "health.max_data_age",
+	"HEALTH_MAX_INPUT_AGE": "health.max_input_age",
+
+	"TRACING_ENABLED":      "tracing.enabled",
+	"TRACING_ENDPOINT":     "tracing.endpoint",
+	"TRACING_INSECURE":     "tracing.insecure",
+	"TRACING_SAMPLE_RATIO": "tracing.sample_ratio",
+
+	"SHUTDOWN_DRAIN_TIMEOUT": "shutdown.drain_timeout",
+}
+
+// source - значения настроек из файла и окружения. Ошибки разбора
+// накапливаются, чтобы при запуске показать их все сразу.
+type source struct {
+	path string            // файл конфигурации, "" - только окружение
+	file map[string]string // значения из файла по имени переменной
+	errs []string
+}
+
+// listSeparators - разделители элементов списка для переменных, значения
+// которых содержат запятые и пробелы (cron-выражения). По умолчанию ",".
+var listSeparators = map[string]string{
+	"REFRESH_SCHEDULE": ";",
+}
+
+// readFile разбирает YAML-файл конфигурации. Неизвестные ключи - ошибка:
+// опечатка в имени настройки не должна молча игнорироваться.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла конфигурации: %v", err)
+	}
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("ошибка разбора %s: %v", path, err)
+	}
+
+	byKey := make(map[string]string, len(fileKeys))
+	for env, key := range fileKeys {
+		byKey[key] = env
+	}
+	values := make(map[string]string)
+	var unknown []string
+	var walk func(prefix string, m map[string]interface{})
+	walk = func(prefix string, m map[string]interface{}) {
+		for k, v := range m {
+			key := prefix + k
+			if env, ok := byKey[key]; ok {
+				sep := listSeparators[env]
+				if sep == "" {
+					sep = ","
+				}
+				values[env] = scalar(v, sep)
+				continue
+			}
+			if nested, ok := v.(map[string]interface{}); ok {
+				walk(key+".", nested)
+				continue
+			}
+			unknown = append(unknown, key)
+		}
+	}
+	walk("", root)
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("неизвестные ключи в %s: %s", path, strings.Join(unknown, ", "))
+	}
+	return values, nil
+}
+
+// scalar приводит значение YAML к строке в формате переменной окружения:
+// списки - через sep, словари (jwt.keys) - "ключ:значение,..."
+func scalar(v interface{}, sep string) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		parts := make([]string, 0, len(t))
+		for _, item := range t {
+			parts = append(parts, scalar(item, sep))
+		}
+		return strings.Join(parts, sep)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, len(keys))
+		for _, k := range keys {
+			parts = append(parts, k+":"+scalar(t[k], sep))
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// get возвращает значение переменной: из окружения, иначе из файла
+func (s *source) get(name string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return s.file[name]
+}
+
+// str возвращает значение или def, если настройка не задана
+func (s *source) str(name, def string) string {
+	if v := strings.TrimSpace(s.get(name)); v != "" {
+		return v
+	}
+	return def
+}
+
+// list разбирает список через запятую или пробел
+func (s *source) list(name string) []string {
+	return strings.Fields(strings.ReplaceAll(s.get(name), ",", " "))
+}
+
+// schedules разбирает список cron-выражений через ";" или перевод строки
+func (s *source) schedules(name string) []string {
+	var out []string
+	for _, spec := range strings.FieldsFunc(s.get(name), func(r rune) bool { return r == ';' || r == '\n' }) {
+		if spec = strings.TrimSpace(spec); spec != "" {
+			out = append(out, spec)
+		}
+	}
+	return out
+}
+
+// duration разбирает длительность не меньше min
+func (s *source) duration(name string, def, min time.Duration) time.Duration {
+	raw := strings.TrimSpace(s.get(name))
+	if raw == "" {
+		return def
+	}
+	v, err := time.ParseDuration(raw)
+	if err != nil {
+		s.fail(name, "ожидается длительность вида 90m или 1h30m, получено %q", raw)
+		return def
+	}
+	if v < min {
+		s.fail(name, "значение %s меньше минимального %s", v, min)
+		return def
+	}
+	return v
+}
+
+// integer разбирает целое в пределах [min, max]
+func (s *source) integer(name string, def, min, max int) int {
+	raw := strings.TrimSpace(s.get(name))
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < min || v > max {
+		s.fail(name, "ожидается целое число от %d до %d, получено %q", min, max, raw)
+		return def
+	}
+	return v
+}
+
+// ratio разбирает долю от 0 до 1
+func (s *source) ratio(name string, def float64) float64 {
+	raw := strings.TrimSpace(s.get(name))
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 || v > 1 {
+		s.fail(name, "ожидается число от 0 до 1, получено %q", raw)
+		return def
+	}
+	return v
+}
+
+// boolean разбирает флаг (true/false, 1/0)
+func (s *source) boolean(name string) bool {
+	raw := strings.TrimSpace(s.get(name))
+	if raw == "" {
+		return false
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		s.fail(name, "ожидается true или false, получено %q", raw)
+	}
+	return v
+}
+
+// fail запоминает ошибку настройки с именем переменной и ключа файла
+func (s *source) fail(name, format string, args ...interface{}) {
+	where := name
+	if key, ok := fileKeys[name]; ok && s.path != "" {
+		where += " (" + key + ")"
+	}
+	s.errs = append(s.errs, where+": "+fmt.Sprintf(format, args...))
+}
+
+// err объединяет накопленные ошибки в одну
+func (s *source) err() error {
+	if len(s.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("некорректная конфигурация:\n  - %s", strings.Join(s.errs, "\n  - "))
+}
@@ -1,17 +1,21 @@
 package converter
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
+	"dashboard/internal/logging"
+
 	"github.com/xuri/excelize/v2"
 )
 
+var logger = logging.For("converter")
+
 // Типы данных для посещаемости
 
 type AttendanceRecord struct {
@@ -34,24 +38,33 @@ type Department struct {
 	Groups     []Group  `json:"groups"`
 }
 
+// Stats - итог конвертации по строкам листа
+type Stats struct {
+	Imported int // строки с данными, попавшие в JSON
+	Skipped  int // пустые строки, заголовки, итоги и строки структуры
+	Failed   int // строки с данными, которые не удалось отнести к группе/студенту
+}
+
 // ConvertAttendance конвертирует файл посещаемости Excel в JSON
 // inputFile - путь к файлу Посещаемость.xlsx
 // outputFile - путь к выходному JSON файлу
-func ConvertAttendance(inputFile, outputFile string) error {
+// При отмене ctx конвертация прерывается, выходной файл не меняется.
+func ConvertAttendance(ctx context.Context, inputFile, outputFile string) (Stats, error) {
+	var stats Stats
 	f, err := excelize.OpenFile(inputFile)
 	if err != nil {
-		return fmt.Errorf("ошибка открытия файла: %v", err)
+		return stats, fmt.Errorf("ошибка открытия файла: %v", err)
 	}
 	defer f.Close()
 
 	sheetName := f.GetSheetName(0)
 	if sheetName == "" {
-		return fmt.Errorf("не найден лист в файле")
+		return stats, fmt.Errorf("не найден лист в файле")
 	}
 
 	rows, err := f.GetRows(sheetName)
 	if err != nil {
-		return fmt.Errorf("ошибка чтения строк: %v", err)
+		return stats, fmt.Errorf("ошибка чтения строк: %v", err)
 	}
 
 	var currentDepartment string
@@ -61,7 +74,11 @@ func ConvertAttendance(inputFile, outputFile string) error {
 	var records []map[string]interface{}
 
 	for rowIdx, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
 		if len(row) == 0 {
+			stats.Skipped++
 			continue
 		}
 
@@ -115,10 +132,15 @@ func ConvertAttendance(inputFile, outputFile string) error {
 					"date":       dateStr,
 					"missed":     int(hoursValue),
 				})
+				stats.Imported++
+			} else {
+				stats.Failed++
 			}
 			continue
 		}
 
+		stats.Skipped++
+
 		if firstCell != "" {
 			if strings.HasPrefix(firstCell, "Отделение") {
 				currentDepartment = firstCell
@@ -198,21 +220,23 @@ func ConvertAttendance(inputFile, outputFile string) error {
 
 	outputPath, err := filepath.Abs(outputFile)
 	if err != nil {
-		return fmt.Errorf("ошибка получения пути: %v", err)
+		return stats, fmt.Errorf("ошибка получения пути: %v", err)
 	}
 
 	jsonData, err := json.MarshalIndent(departments, "", "  ")
 	if err != nil {
-		return fmt.Errorf("ошибка серилизации JSON: %v", err)
+		return stats, fmt.Errorf("ошибка серилизации JSON: %v", err)
 	}
 
-	if err := os.WriteFile(outputPath, jsonData, 0644); err != nil {
-		return fmt.Errorf("ошибка записи файла: %v", err)
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+	if err := writeFileAtomic(outputPath, jsonData); err != nil {
+		return stats, fmt.Errorf("ошибка записи файла: %v", err)
 	}
 
-	fmt.Printf(" Конвертация посещаемости завершена. Отделений: %d\n", len(departments))
-	fmt.Printf("   Файл сохранён: %s\n", outputPath)
-	return nil
+	logger.InfoContext(ctx, "Конвертация посещаемости завершена", "departments", len(departments), "output", outputPath)
+	return stats, nil
 }
 
 func parseDateValue(value string) string {
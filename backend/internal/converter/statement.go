@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -44,16 +45,20 @@ type DepartmentSummary struct {
 // inputFileXLS - путь к файлу ведомость.xls (или .xlsx)
 // outputFile - путь к выходному JSON файлу
 // pythonScriptPath - путь к Python скрипту для конвертации XLS → XLSX
-func ConvertStatement(inputFileXLS, outputFile, pythonScriptPath string) error {
+// При отмене ctx конвертация (и скрипт Python) прерывается, выходной файл не меняется.
+func ConvertStatement(ctx context.Context, inputFileXLS, outputFile, pythonScriptPath string) (Stats, error) {
+	var stats Stats
 	// Определяем имя XLSX файла
 	inputFileXLSX := strings.TrimSuffix(inputFileXLS, ".xls") + ".xlsx"
 
 	// Шаг 1: Конвертируем XLS в XLSX (если нужно)
 	if strings.HasSuffix(strings.ToLower(inputFileXLS), ".xls") {
 		if _, err := os.Stat(inputFileXLS); err == nil {
-			if err := convertXLSToXLSX(inputFileXLS, inputFileXLSX, pythonScriptPath); err != nil {
-				fmt.Printf("Предупреждение при конвертации %s: %v\n", inputFileXLS, err)
-				fmt.Println("Продолжаем с XLSX файлом, если он существует...")
+			if err := convertXLSToXLSX(ctx, inputFileXLS, inputFileXLSX, pythonScriptPath); err != nil {
+				if ctx.Err() != nil {
+					return stats, ctx.Err()
+				}
+				logger.WarnContext(ctx, "XLS не сконвертирован, продолжаем с существующим XLSX", "input", inputFileXLS, "error", err)
 			}
 		}
 	} else {
@@ -64,19 +69,19 @@ func ConvertStatement(inputFileXLS, outputFile, pythonScriptPath string) error {
 	// Шаг 2: Открываем XLSX файл через excelize
 	f, err := excelize.OpenFile(inputFileXLSX)
 	if err != nil {
-		return fmt.Errorf("ошибка открытия файла %s: %v\nУбедитесь, что файл конвертирован в XLSX формат", inputFileXLSX, err)
+		return stats, fmt.Errorf("ошибка открытия файла %s: %v\nУбедитесь, что файл конвертирован в XLSX формат", inputFileXLSX, err)
 	}
 	defer f.Close()
 
 	// Берём первый лист
 	sheetName := f.GetSheetName(0)
 	if sheetName == "" {
-		return fmt.Errorf("не найден лист в файле")
+		return stats, fmt.Errorf("не найден лист в файле")
 	}
 
 	rows, err := f.GetRows(sheetName)
 	if err != nil {
-		return fmt.Errorf("ошибка чтения строк: %v", err)
+		return stats, fmt.Errorf("ошибка чтения строк: %v", err)
 	}
 
 	departmentsMap := make(map[string]*DepartmentSummary)
@@ -87,16 +92,22 @@ func ConvertStatement(inputFileXLS, outputFile, pythonScriptPath string) error {
 
 	// Перебираем все строки листа
 	for _, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
 		if len(row) == 0 {
+			stats.Skipped++
 			continue
 		}
 
 		label := strings.TrimSpace(row[0])
 		if label == "" {
+			stats.Skipped++
 			continue
 		}
 
 		if isHeaderOrTotal(label) {
+			stats.Skipped++
 			continue
 		}
 
@@ -148,6 +159,7 @@ func ConvertStatement(inputFileXLS, outputFile, pythonScriptPath string) error {
 			if total > 0 {
 				departmentsMap[currentDepartment].TotalMissed = total
 			}
+			stats.Skipped++
 			continue
 		}
 
@@ -174,6 +186,7 @@ func ConvertStatement(inputFileXLS, outputFile, pythonScriptPath string) error {
 					spec.TotalMissed = total
 				}
 			}
+			stats.Skipped++
 			continue
 		}
 
@@ -208,15 +221,19 @@ func ConvertStatement(inputFileXLS, outputFile, pythonScriptPath string) error {
 					}
 				}
 			}
+			stats.Skipped++
 			continue
 		}
 
 		// Остальное считаем строками со студентами
-		if currentDepartment == "" || currentSpecialty == "" || currentGroup == "" {
+		if total == 0 && bad == 0 && excused == 0 {
+			stats.Skipped++
 			continue
 		}
 
-		if total == 0 && bad == 0 && excused == 0 {
+		// Строка с часами вне отделения/специальности/группы
+		if currentDepartment == "" || currentSpecialty == "" || currentGroup == "" {
+			stats.Failed++
 			continue
 		}
 
@@ -264,6 +281,7 @@ func ConvertStatement(inputFileXLS, outputFile, pythonScriptPath string) error {
 			MissedExcused: excused,
 		}
 		group.Students = append(group.Students, student)
+		stats.Imported++
 
 		// Обновляем суммы
 		group.TotalMissed += total
@@ -279,25 +297,27 @@ func ConvertStatement(inputFileXLS, outputFile, pythonScriptPath string) error {
 
 	outputPath, err := filepath.Abs(outputFile)
 	if err != nil {
-		return fmt.Errorf("ошибка получения пути: %v", err)
+		return stats, fmt.Errorf("ошибка получения пути: %v", err)
 	}
 
 	data, err := json.MarshalIndent(departments, "", "  ")
 	if err != nil {
-		return fmt.Errorf("ошибка сериализации JSON: %v", err)
+		return stats, fmt.Errorf("ошибка сериализации JSON: %v", err)
 	}
 
-	if err := os.WriteFile(outputPath, data, 0644); err != nil {
-		return fmt.Errorf("ошибка записи файла: %v", err)
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+	if err := writeFileAtomic(outputPath, data); err != nil {
+		return stats, fmt.Errorf("ошибка записи файла: %v", err)
 	}
 
-	fmt.Printf(" Конвертация ведомости завершена. Отделений: %d\n", len(departments))
-	fmt.Printf("   Файл сохранён: %s\n", outputPath)
-	return nil
+	logger.InfoContext(ctx, "Конвертация ведомости завершена", "departments", len(departments), "output", outputPath)
+	return stats, nil
 }
 
 // convertXLSToXLSX конвертирует XLS файл в XLSX формат через Python скрипт
-func convertXLSToXLSX(xlsFile, xlsxFile, pythonScriptPath string) error {
+func convertXLSToXLSX(ctx context.Context, xlsFile, xlsxFile, pythonScriptPath string) error {
 	// Проверяем, существует ли уже XLSX файл и он новее XLS
 	if info, err := os.Stat(xlsxFile); err == nil {
 		if xlsInfo, err2 := os.Stat(xlsFile); err2 == nil {
@@ -309,29 +329,51 @@ func convertXLSToXLSX(xlsFile, xlsxFile, pythonScriptPath string) error {
 	}
 
 	// Используем Python скрипт для конвертации
-	return convertXLSToXLSXPython(xlsFile, xlsxFile, pythonScriptPath)
+	return convertXLSToXLSXPython(ctx, xlsFile, xlsxFile, pythonScriptPath)
 }
 
 // convertXLSToXLSXPython использует Python скрипт для конвертации
-func convertXLSToXLSXPython(xlsFile, xlsxFile, pythonScriptPath string) error {
+func convertXLSToXLSXPython(ctx context.Context, xlsFile, xlsxFile, pythonScriptPath string) error {
 	// Проверяем наличие Python скрипта
 	if _, err := os.Stat(pythonScriptPath); os.IsNotExist(err) {
 		return fmt.Errorf("Python скрипт %s не найден", pythonScriptPath)
 	}
 
 	// Запускаем Python скрипт для конвертации
-	cmd := exec.Command("python3", pythonScriptPath, xlsFile, xlsxFile)
+	cmd := exec.CommandContext(ctx, "python3", pythonScriptPath, xlsFile, xlsxFile)
 	cmd.Dir = filepath.Dir(xlsFile)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("ошибка конвертации XLS → XLSX через Python: %v\nВывод: %s", err, string(output))
 	}
-	fmt.Printf("   Конвертировано через Python: %s → %s\n", xlsFile, xlsxFile)
+	logger.InfoContext(ctx, "XLS сконвертирован через Python", "input", xlsFile, "output", xlsxFile)
 	return nil
 }
 
 // Вспомогательные функции
 
+// writeFileAtomic записывает файл через временный файл и переименование:
+// прерванная запись не оставляет обрезанный JSON, который прочитает дашборд
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
 func parseIntCell(value string) int {
 	value = strings.TrimSpace(value)
 	if value == "" {
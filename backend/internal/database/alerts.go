@@ -0,0 +1,244 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"dashboard/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrNotFound возвращается, если запись не найдена
+var ErrNotFound = errors.New("запись не найдена")
+
+// AlertStore хранит алерты в БД
+type AlertStore struct {
+	db *sql.DB
+}
+
+func NewAlertStore(db *sql.DB) *AlertStore {
+	return &AlertStore{db: db}
+}
+
+// AlertFilter параметры выборки алертов
+type AlertFilter struct {
+	Status      string
+	Severity    string
+	SubjectType string
+	Rule        string
+	Department  string
+	Group       string
+	Student     string
+	Limit       int
+	Offset      int
+
+	// Scope ограничивает выборку областью видимости пользователя (nil - без ограничений)
+	Scope *models.Scope
+}
+
+const alertColumns = `id, rule, fingerprint, severity, subject_type, department, group_name, student,
+	metric_value, threshold, message, status, first_seen_at, last_seen_at,
+	acknowledged_at, acknowledged_by, resolved_at, resolved_by`
+
+// Sync сверяет активные алерты с результатом очередной проверки.
+// Новые срабатывания создаются со статусом open, уже известные обновляют
+// значение метрики (статус acknowledged сохраняется), а активные алерты,
+// которые больше не срабатывают, закрываются. Возвращает созданные алерты.
+// Если rules не пуст, закрываются только алерты перечисленных правил.
+func (s *AlertStore) Sync(detected []models.Alert, rules []string) (created []models.Alert, resolved int, err error) {
+	if s.db == nil {
+		return nil, 0, fmt.Errorf("БД не подключена")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	seen := make([]string, 0, len(detected))
+
+	for _, a := range detected {
+		a.Fingerprint = a.MakeFingerprint()
+		seen = append(seen, a.Fingerprint)
+
+		res, err := tx.Exec(
+			`UPDATE alerts SET severity = $2, metric_value = $3, threshold = $4, message = $5, last_seen_at = $6
+			 WHERE fingerprint = $1 AND status <> 'resolved'`,
+			a.Fingerprint, a.Severity, a.MetricValue, a.Threshold, a.Message, now,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ошибка обновления алерта: %v", err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			continue
+		}
+
+		a.Status = models.AlertOpen
+		a.FirstSeenAt = now
+		a.LastSeenAt = now
+		err = tx.QueryRow(
+			`INSERT INTO alerts (rule, fingerprint, severity, subject_type, department, group_name, student,
+			 	metric_value, threshold, message, status, first_seen_at, last_seen_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $12)
+			 RETURNING id`,
+			a.Rule, a.Fingerprint, a.Severity, a.SubjectType, a.Department, a.Group, a.Student,
+			a.MetricValue, a.Threshold, a.Message, a.Status, now,
+		).Scan(&a.ID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ошибка вставки алерта: %v", err)
+		}
+		created = append(created, a)
+	}
+
+	// Закрываем алерты, которые больше не срабатывают
+	query := `UPDATE alerts SET status = 'resolved', resolved_at = $1
+		WHERE status <> 'resolved' AND NOT (fingerprint = ANY($2))`
+	args := []interface{}{now, pq.Array(seen)}
+	if len(rules) > 0 {
+		query += ` AND rule = ANY($3)`
+		args = append(args, pq.Array(rules))
+	}
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка закрытия алертов: %v", err)
+	}
+	n, _ := res.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("ошибка коммита транзакции: %v", err)
+	}
+	return created, int(n), nil
+}
+
+// List возвращает алерты по фильтру, начиная с самых свежих
+func (s *AlertStore) List(f AlertFilter) ([]models.Alert, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("БД не подключена")
+	}
+
+	var where []string
+	var args []interface{}
+	add := func(cond string, val interface{}) {
+		args = append(args, val)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if f.Severity != "" {
+		add("severity = $%d", f.Severity)
+	}
+	if f.SubjectType != "" {
+		add("subject_type = $%d", f.SubjectType)
+	}
+	if f.Rule != "" {
+		add("rule = $%d", f.Rule)
+	}
+	if f.Department != "" {
+		add("department = $%d", f.Department)
+	}
+	if f.Group != "" {
+		add("group_name = $%d", f.Group)
+	}
+	if f.Student != "" {
+		add("student = $%d", f.Student)
+	}
+	if f.Scope != nil && !f.Scope.All {
+		args = append(args, pq.Array(f.Scope.Departments), pq.Array(f.Scope.Groups))
+		where = append(where, fmt.Sprintf("(department = ANY($%d) OR group_name = ANY($%d))", len(args)-1, len(args)))
+	}
+
+	query := "SELECT " + alertColumns + " FROM alerts"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	limit := f.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	args = append(args, limit, f.Offset)
+	query += fmt.Sprintf(" ORDER BY last_seen_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки алертов: %v", err)
+	}
+	defer rows.Close()
+
+	out := []models.Alert{}
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// Get возвращает алерт по ID
+func (s *AlertStore) Get(id int) (models.Alert, error) {
+	if s.db == nil {
+		return models.Alert{}, fmt.Errorf("БД не подключена")
+	}
+	row := s.db.QueryRow("SELECT "+alertColumns+" FROM alerts WHERE id = $1", id)
+	a, err := scanAlert(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Alert{}, ErrNotFound
+	}
+	return a, err
+}
+
+// Acknowledge переводит открытый алерт в статус acknowledged
+func (s *AlertStore) Acknowledge(id int, by string) (models.Alert, error) {
+	return s.transition(id,
+		`UPDATE alerts SET status = 'acknowledged', acknowledged_at = $2, acknowledged_by = $3
+		 WHERE id = $1 AND status = 'open'`, by)
+}
+
+// Resolve вручную закрывает алерт
+func (s *AlertStore) Resolve(id int, by string) (models.Alert, error) {
+	return s.transition(id,
+		`UPDATE alerts SET status = 'resolved', resolved_at = $2, resolved_by = $3
+		 WHERE id = $1 AND status <> 'resolved'`, by)
+}
+
+// transition выполняет смену статуса и возвращает обновлённый алерт
+func (s *AlertStore) transition(id int, query, by string) (models.Alert, error) {
+	if s.db == nil {
+		return models.Alert{}, fmt.Errorf("БД не подключена")
+	}
+	if _, err := s.db.Exec(query, id, time.Now(), by); err != nil {
+		return models.Alert{}, fmt.Errorf("ошибка обновления алерта: %v", err)
+	}
+	return s.Get(id)
+}
+
+// rowScanner общий интерфейс для *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAlert(row rowScanner) (models.Alert, error) {
+	var a models.Alert
+	var ackAt, resolvedAt sql.NullTime
+	err := row.Scan(&a.ID, &a.Rule, &a.Fingerprint, &a.Severity, &a.SubjectType, &a.Department, &a.Group, &a.Student,
+		&a.MetricValue, &a.Threshold, &a.Message, &a.Status, &a.FirstSeenAt, &a.LastSeenAt,
+		&ackAt, &a.AcknowledgedBy, &resolvedAt, &a.ResolvedBy)
+	if err != nil {
+		return a, err
+	}
+	if ackAt.Valid {
+		a.AcknowledgedAt = &ackAt.Time
+	}
+	if resolvedAt.Valid {
+		a.ResolvedAt = &resolvedAt.Time
+	}
+	return a, nil
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"dashboard/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// APIKeyStore хранит ключи API для интеграций
+type APIKeyStore struct {
+	db *sql.DB
+}
+
+func NewAPIKeyStore(db *sql.DB) *APIKeyStore {
+	return &APIKeyStore{db: db}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, departments, expires_at, last_used_at, last_used_ip, created_by, revoked_at, created_at`
+
+// List возвращает все ключи, включая отозванные
+func (s *APIKeyStore) List() ([]models.APIKey, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("БД не подключена")
+	}
+
+	rows, err := s.db.Query(`SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки ключей API: %v", err)
+	}
+	defer rows.Close()
+
+	out := []models.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+// Get возвращает ключ по ID
+func (s *APIKeyStore) Get(id int) (models.APIKey, error) {
+	if s.db == nil {
+		return models.APIKey{}, fmt.Errorf("БД не подключена")
+	}
+	k, err := scanAPIKey(s.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.APIKey{}, ErrNotFound
+	}
+	return k, err
+}
+
+// GetByPrefix возвращает ключ по открытой части
+func (s *APIKeyStore) GetByPrefix(prefix string) (models.APIKey, error) {
+	if s.db == nil {
+		return models.APIKey{}, fmt.Errorf("БД не подключена")
+	}
+	k, err := scanAPIKey(s.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE prefix = $1`, prefix))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.APIKey{}, ErrNotFound
+	}
+	return k, err
+}
+
+// Create сохраняет ключ. KeyHash и Prefix должны быть уже вычислены.
+func (s *APIKeyStore) Create(k models.APIKey) (models.APIKey, error) {
+	if s.db == nil {
+		return models.APIKey{}, fmt.Errorf("БД не подключена")
+	}
+	var id int
+	err := s.db.QueryRow(
+		`INSERT INTO api_keys (name, prefix, key_hash, scopes, departments, expires_at, created_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		k.Name, k.Prefix, k.KeyHash, pq.Array(nonNil(k.Scopes)), pq.Array(nonNil(k.Departments)), k.ExpiresAt, k.CreatedBy,
+	).Scan(&id)
+	if isUniqueViolation(err) {
+		return models.APIKey{}, ErrDuplicate
+	}
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("ошибка сохранения ключа API: %v", err)
+	}
+	return s.Get(id)
+}
+
+// Revoke отзывает ключ. Повторный отзыв не меняет время отзыва.
+func (s *APIKeyStore) Revoke(id int) (models.APIKey, error) {
+	if s.db == nil {
+		return models.APIKey{}, fmt.Errorf("БД не подключена")
+	}
+	res, err := s.db.Exec(`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1`, id, time.Now())
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("ошибка отзыва ключа API: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.APIKey{}, ErrNotFound
+	}
+	return s.Get(id)
+}
+
+// TouchUsed запоминает время и адрес последнего использования ключа
+func (s *APIKeyStore) TouchUsed(id int, ip string) error {
+	if s.db == nil {
+		return fmt.Errorf("БД не подключена")
+	}
+	if _, err := s.db.Exec(`UPDATE api_keys SET last_used_at = $2, last_used_ip = $3 WHERE id = $1`, id, time.Now(), ip); err != nil {
+		return fmt.Errorf("ошибка обновления ключа API: %v", err)
+	}
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var k models.APIKey
+	var expiresAt, lastUsed, revokedAt, createdAt sql.NullTime
+	err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.KeyHash, pq.Array(&k.Scopes), pq.Array(&k.Departments),
+		&expiresAt, &lastUsed, &k.LastUsedIP, &k.CreatedBy, &revokedAt, &createdAt)
+	if err != nil {
+		return k, err
+	}
+	k.Scopes = nonNil(k.Scopes)
+	k.Departments = nonNil(k.Departments)
+	if expiresAt.Valid {
+		k.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsed.Valid {
+		k.LastUsedAt = &lastUsed.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	k.CreatedAt = createdAt.Time
+	return k, nil
+}
@@ -0,0 +1,204 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"dashboard/internal/models"
+)
+
+// auditLockKey - ключ advisory-блокировки, под которой добавляются записи:
+// хэш предыдущей записи должен читаться и использоваться без гонок
+const auditLockKey = 0x61756469
+
+// AuditStore хранит журнал аудита. Записи только добавляются,
+// изменение и удаление запрещены триггером в БД.
+type AuditStore struct {
+	db *sql.DB
+}
+
+func NewAuditStore(db *sql.DB) *AuditStore {
+	return &AuditStore{db: db}
+}
+
+// Available сообщает, подключена ли БД
+func (s *AuditStore) Available() bool {
+	return s.db != nil
+}
+
+// AuditFilter условия выборки журнала. Пустые поля не ограничивают.
+type AuditFilter struct {
+	Actor  string
+	Action string // префикс, например "POST /api/admin/users"
+	Target string
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+// AuditChainStatus результат проверки цепочки хэшей
+type AuditChainStatus struct {
+	Checked  int    `json:"checked"`
+	Valid    bool   `json:"valid"`
+	BrokenAt int64  `json:"broken_at,omitempty"` // ID первой несовпавшей записи
+	Reason   string `json:"reason,omitempty"`
+}
+
+const auditColumns = `id, created_at, actor, role, ip, action, target, status, before_data, after_data, prev_hash, hash`
+
+// Append добавляет запись в конец цепочки и возвращает её с ID и хэшем
+func (s *AuditStore) Append(e models.AuditEvent) (models.AuditEvent, error) {
+	if s.db == nil {
+		return e, fmt.Errorf("БД не подключена")
+	}
+	// Время хранится без часового пояса с точностью до микросекунд:
+	// хэш должен совпасть после чтения из БД
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Time = e.Time.UTC().Truncate(time.Microsecond)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return e, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, auditLockKey); err != nil {
+		return e, fmt.Errorf("ошибка блокировки журнала аудита: %v", err)
+	}
+	err = tx.QueryRow(`SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1`).Scan(&e.PrevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return e, fmt.Errorf("ошибка чтения журнала аудита: %v", err)
+	}
+	e.Hash = e.ChainHash()
+
+	err = tx.QueryRow(
+		`INSERT INTO audit_events (created_at, actor, role, ip, action, target, status, before_data, after_data, prev_hash, hash)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`,
+		e.Time, e.Actor, e.Role, e.IP, e.Action, e.Target, e.Status, string(e.Before), string(e.After), e.PrevHash, e.Hash,
+	).Scan(&e.ID)
+	if err != nil {
+		return e, fmt.Errorf("ошибка записи в журнал аудита: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return e, fmt.Errorf("ошибка записи в журнал аудита: %v", err)
+	}
+	return e, nil
+}
+
+// List возвращает записи по фильтру, новые первыми
+func (s *AuditStore) List(f AuditFilter) ([]models.AuditEvent, error) {
+	limit := f.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	out := []models.AuditEvent{}
+	err := s.query(f, fmt.Sprintf(" ORDER BY id DESC LIMIT %d OFFSET %d", limit, max(f.Offset, 0)), func(e models.AuditEvent) error {
+		out = append(out, e)
+		return nil
+	})
+	return out, err
+}
+
+// Each передаёт fn все записи по фильтру по порядку, не загружая
+// журнал в память целиком (выгрузка в CSV). Limit и Offset не учитываются.
+func (s *AuditStore) Each(f AuditFilter, fn func(models.AuditEvent) error) error {
+	return s.query(f, " ORDER BY id", fn)
+}
+
+// Verify проходит всю цепочку и сверяет хэши. Изменённая запись не совпадёт
+// со своим хэшем, удалённая - разорвёт ссылку следующей на предыдущую.
+func (s *AuditStore) Verify() (AuditChainStatus, error) {
+	st := AuditChainStatus{Valid: true}
+	prev := ""
+	err := s.query(AuditFilter{}, " ORDER BY id", func(e models.AuditEvent) error {
+		if !st.Valid {
+			return nil
+		}
+		st.Checked++
+		switch {
+		case e.PrevHash != prev:
+			st.Valid, st.BrokenAt, st.Reason = false, e.ID, "предыдущая запись удалена или изменена"
+		case e.ChainHash() != e.Hash:
+			st.Valid, st.BrokenAt, st.Reason = false, e.ID, "запись изменена"
+		}
+		prev = e.Hash
+		return nil
+	})
+	return st, err
+}
+
+func (s *AuditStore) query(f AuditFilter, suffix string, fn func(models.AuditEvent) error) error {
+	if s.db == nil {
+		return fmt.Errorf("БД не подключена")
+	}
+
+	var where []string
+	var args []interface{}
+	add := func(cond string, val interface{}) {
+		args = append(args, val)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Actor != "" {
+		add("actor = $%d", f.Actor)
+	}
+	if f.Action != "" {
+		add("action LIKE $%d", escapeLike(f.Action)+"%")
+	}
+	if f.Target != "" {
+		add("target = $%d", f.Target)
+	}
+	if f.From != nil {
+		add("created_at >= $%d", f.From.UTC())
+	}
+	if f.To != nil {
+		add("created_at < $%d", f.To.UTC())
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_events"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	rows, err := s.db.Query(query+suffix, args...)
+	if err != nil {
+		return fmt.Errorf("ошибка выборки журнала аудита: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func scanAuditEvent(row rowScanner) (models.AuditEvent, error) {
+	var e models.AuditEvent
+	var before, after string
+	err := row.Scan(&e.ID, &e.Time, &e.Actor, &e.Role, &e.IP, &e.Action, &e.Target, &e.Status,
+		&before, &after, &e.PrevHash, &e.Hash)
+	if err != nil {
+		return e, err
+	}
+	if before != "" {
+		e.Before = []byte(before)
+	}
+	if after != "" {
+		e.After = []byte(after)
+	}
+	return e, nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -1,11 +1,14 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+
+	"dashboard/internal/logging"
 
 	_ "github.com/lib/pq" // PostgreSQL драйвер
 )
@@ -13,6 +16,8 @@ import (
 // DB содержит подключение к базе данных
 var DB *sql.DB
 
+var logger = logging.For("database")
+
 // Connect подключается к PostgreSQL
 func Connect(databaseURL string) error {
 	if databaseURL == "" {
@@ -30,7 +35,7 @@ func Connect(databaseURL string) error {
 		return fmt.Errorf("ошибка ping БД: %v", err)
 	}
 
-	log.Println("[Database] Подключение к PostgreSQL установлено")
+	logger.Info("Подключение к PostgreSQL установлено")
 	return nil
 }
 
@@ -61,14 +66,14 @@ func InitSchema() error {
 	for _, schemaPath := range possiblePaths {
 		sqlBytes, err = os.ReadFile(schemaPath)
 		if err == nil {
-			log.Printf("[Database] Схема найдена: %s", schemaPath)
+			logger.Info("Схема найдена", "path", schemaPath)
 			break
 		}
 	}
 
 	if err != nil {
 		// Если файл не найден, используем встроенную схему
-		log.Println("[Database] Файл schema.sql не найден, используем встроенную схему")
+		logger.Warn("Файл schema.sql не найден, используем встроенную схему")
 		sqlBytes = []byte(getEmbeddedSchema())
 	}
 
@@ -77,10 +82,56 @@ func InitSchema() error {
 		return fmt.Errorf("ошибка выполнения schema.sql: %v", err)
 	}
 
-	log.Println("[Database] Схема БД инициализирована")
+	logger.Info("Схема БД инициализирована")
 	return nil
 }
 
+// schemaTable находит таблицы в SQL схеме
+var schemaTable = regexp.MustCompile(`(?i)CREATE TABLE IF NOT EXISTS\s+(\w+)`)
+
+// SchemaTables возвращает таблицы, которые создаёт схема БД
+func SchemaTables() []string {
+	var tables []string
+	for _, m := range schemaTable.FindAllStringSubmatch(getEmbeddedSchema(), -1) {
+		tables = append(tables, m[1])
+	}
+	return tables
+}
+
+// MissingTables возвращает таблицы схемы, которых нет в БД: схема
+// не применена или применена частично
+func MissingTables(ctx context.Context) ([]string, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("БД не подключена")
+	}
+	rows, err := DB.QueryContext(ctx,
+		`SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema()`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения списка таблиц: %v", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		existing[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, t := range SchemaTables() {
+		if !existing[t] {
+			missing = append(missing, t)
+		}
+	}
+	return missing, nil
+}
+
 // getEmbeddedSchema возвращает встроенную SQL схему (на случай, если файл не найден)
 func getEmbeddedSchema() string {
 	return `
@@ -152,6 +203,166 @@ CREATE TABLE IF NOT EXISTS summary_students (
     UNIQUE(summary_group_id, full_name)
 );
 
+CREATE TABLE IF NOT EXISTS alerts (
+    id SERIAL PRIMARY KEY,
+    rule VARCHAR(100) NOT NULL,
+    fingerprint VARCHAR(1024) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    subject_type VARCHAR(20) NOT NULL,
+    department VARCHAR(255) NOT NULL DEFAULT '',
+    group_name VARCHAR(50) NOT NULL DEFAULT '',
+    student VARCHAR(255) NOT NULL DEFAULT '',
+    metric_value NUMERIC(10, 2) NOT NULL,
+    threshold NUMERIC(10, 2) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    first_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    acknowledged_at TIMESTAMP,
+    acknowledged_by VARCHAR(255) NOT NULL DEFAULT '',
+    resolved_at TIMESTAMP,
+    resolved_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    type VARCHAR(50) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    definition JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS notification_recipients (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    department VARCHAR(255) NOT NULL DEFAULT '',
+    group_name VARCHAR(50) NOT NULL DEFAULT '',
+    alerts BOOLEAN NOT NULL DEFAULT TRUE,
+    digest BOOLEAN NOT NULL DEFAULT TRUE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(email, department, group_name)
+);
+
+CREATE TABLE IF NOT EXISTS notification_log (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL,
+    alert_id INTEGER REFERENCES alerts(id) ON DELETE SET NULL,
+    recipient VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(name)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    replay_of INTEGER REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(100) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    scope_departments TEXT[] NOT NULL DEFAULT '{}',
+    scope_groups TEXT[] NOT NULL DEFAULT '{}',
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    auth_source VARCHAR(20) NOT NULL DEFAULT 'local',
+    totp_secret VARCHAR(64) NOT NULL DEFAULT '',
+    totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    recovery_codes TEXT[] NOT NULL DEFAULT '{}',
+    last_login_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS scope_departments TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS scope_groups TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS auth_source VARCHAR(20) NOT NULL DEFAULT 'local';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS recovery_codes TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_hash VARCHAR(64) NOT NULL,
+    previous_hash VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL UNIQUE,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    departments TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    last_used_ip VARCHAR(64) NOT NULL DEFAULT '',
+    created_by VARCHAR(100) NOT NULL DEFAULT '',
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    actor VARCHAR(150) NOT NULL DEFAULT '',
+    role VARCHAR(32) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    action VARCHAR(255) NOT NULL,
+    target VARCHAR(255) NOT NULL DEFAULT '',
+    status INTEGER NOT NULL DEFAULT 0,
+    before_data TEXT NOT NULL DEFAULT '',
+    after_data TEXT NOT NULL DEFAULT '',
+    prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+    hash VARCHAR(64) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS refresh_jobs (
+    id VARCHAR(32) PRIMARY KEY,
+    trigger VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP,
+    data JSONB NOT NULL
+);
+
 CREATE INDEX IF NOT EXISTS idx_groups_department_id ON groups(department_id);
 CREATE INDEX IF NOT EXISTS idx_students_group_id ON students(group_id);
 CREATE INDEX IF NOT EXISTS idx_attendance_student_id ON attendance(student_id);
@@ -159,5 +370,29 @@ CREATE INDEX IF NOT EXISTS idx_attendance_date ON attendance(date);
 CREATE INDEX IF NOT EXISTS idx_specialties_department_id ON specialties(department_id);
 CREATE INDEX IF NOT EXISTS idx_summary_groups_specialty_id ON summary_groups(specialty_id);
 CREATE INDEX IF NOT EXISTS idx_summary_students_group_id ON summary_students(summary_group_id);
+CREATE INDEX IF NOT EXISTS idx_alerts_status ON alerts(status);
+CREATE INDEX IF NOT EXISTS idx_alerts_department ON alerts(department);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alerts_active_fingerprint ON alerts(fingerprint) WHERE status <> 'resolved';
+CREATE INDEX IF NOT EXISTS idx_notification_log_alert ON notification_log(alert_id, recipient);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id);
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_revoked ON sessions(revoked_at) WHERE revoked_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor, id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created ON audit_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_refresh_jobs_created ON refresh_jobs(created_at);
+
+CREATE OR REPLACE FUNCTION audit_events_immutable()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS audit_events_no_change ON audit_events;
+CREATE TRIGGER audit_events_no_change BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_immutable();
+DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events;
+CREATE TRIGGER audit_events_no_truncate BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_immutable();
 `
 }
@@ -1,12 +1,16 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"time"
+
+	"dashboard/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Loader загружает JSON данные в БД
@@ -19,12 +23,14 @@ func NewLoader(db *sql.DB) *Loader {
 }
 
 // LoadAttendance загружает данные посещаемости из JSON в БД
-func (l *Loader) LoadAttendance(jsonPath string) error {
+func (l *Loader) LoadAttendance(ctx context.Context, jsonPath string) (err error) {
 	if l.db == nil {
 		return fmt.Errorf("БД не подключена")
 	}
+	ctx, span := tracing.Start(ctx, "database.LoadAttendance", attribute.String("file", jsonPath))
+	defer func() { tracing.End(span, err) }()
 
-	log.Printf("[Database] Загрузка посещаемости из %s...", jsonPath)
+	logger.InfoContext(ctx, "Загрузка посещаемости", "path", jsonPath)
 
 	// Читаем JSON файл
 	data, err := os.ReadFile(jsonPath)
@@ -52,14 +58,14 @@ func (l *Loader) LoadAttendance(jsonPath string) error {
 	}
 
 	// Начинаем транзакцию
-	tx, err := l.db.Begin()
+	tx, err := l.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("ошибка начала транзакции: %v", err)
 	}
 	defer tx.Rollback()
 
 	// Очищаем старые данные (опционально - можно закомментировать для инкрементального обновления)
-	// if _, err := tx.Exec("TRUNCATE TABLE attendance, students, groups, departments CASCADE"); err != nil {
+	// if _, err := tx.ExecContext(ctx, "TRUNCATE TABLE attendance, students, groups, departments CASCADE"); err != nil {
 	// 	return fmt.Errorf("ошибка очистки данных: %v", err)
 	// }
 
@@ -67,7 +73,7 @@ func (l *Loader) LoadAttendance(jsonPath string) error {
 	for _, dept := range departments {
 		// Вставляем или получаем отделение
 		var deptID int
-		err := tx.QueryRow(
+		err := tx.QueryRowContext(ctx,
 			`INSERT INTO departments (name) VALUES ($1) 
 			 ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name 
 			 RETURNING id`,
@@ -80,7 +86,7 @@ func (l *Loader) LoadAttendance(jsonPath string) error {
 		for _, group := range dept.Groups {
 			// Вставляем или получаем группу
 			var groupID int
-			err := tx.QueryRow(
+			err := tx.QueryRowContext(ctx,
 				`INSERT INTO groups (department_id, name) VALUES ($1, $2) 
 				 ON CONFLICT (department_id, name) DO UPDATE SET name = EXCLUDED.name 
 				 RETURNING id`,
@@ -93,7 +99,7 @@ func (l *Loader) LoadAttendance(jsonPath string) error {
 			for _, student := range group.Students {
 				// Вставляем или получаем студента
 				var studentID int
-				err := tx.QueryRow(
+				err := tx.QueryRowContext(ctx,
 					`INSERT INTO students (group_id, full_name) VALUES ($1, $2) 
 					 ON CONFLICT (group_id, full_name) DO UPDATE SET full_name = EXCLUDED.full_name 
 					 RETURNING id`,
@@ -108,11 +114,11 @@ func (l *Loader) LoadAttendance(jsonPath string) error {
 					// Парсим дату
 					date, err := time.Parse("2006-01-02", att.Date)
 					if err != nil {
-						log.Printf("[Database] Предупреждение: неверный формат даты %s: %v", att.Date, err)
+						logger.WarnContext(ctx, "Неверный формат даты", "date", att.Date, "error", err)
 						continue
 					}
 
-					_, err = tx.Exec(
+					_, err = tx.ExecContext(ctx,
 						`INSERT INTO attendance (student_id, date, missed_hours) 
 						 VALUES ($1, $2, $3)
 						 ON CONFLICT (student_id, date) 
@@ -132,17 +138,20 @@ func (l *Loader) LoadAttendance(jsonPath string) error {
 		return fmt.Errorf("ошибка коммита транзакции: %v", err)
 	}
 
-	log.Printf("[Database] Посещаемость загружена. Отделений: %d", len(departments))
+	span.SetAttributes(attribute.Int("departments", len(departments)))
+	logger.InfoContext(ctx, "Посещаемость загружена", "departments", len(departments))
 	return nil
 }
 
 // LoadStatement загружает данные ведомости из JSON в БД
-func (l *Loader) LoadStatement(jsonPath string) error {
+func (l *Loader) LoadStatement(ctx context.Context, jsonPath string) (err error) {
 	if l.db == nil {
 		return fmt.Errorf("БД не подключена")
 	}
+	ctx, span := tracing.Start(ctx, "database.LoadStatement", attribute.String("file", jsonPath))
+	defer func() { tracing.End(span, err) }()
 
-	log.Printf("[Database] Загрузка ведомости из %s...", jsonPath)
+	logger.InfoContext(ctx, "Загрузка ведомости", "path", jsonPath)
 
 	// Читаем JSON файл
 	data, err := os.ReadFile(jsonPath)
@@ -175,14 +184,14 @@ func (l *Loader) LoadStatement(jsonPath string) error {
 	}
 
 	// Начинаем транзакцию
-	tx, err := l.db.Begin()
+	tx, err := l.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("ошибка начала транзакции: %v", err)
 	}
 	defer tx.Rollback()
 
 	// Очищаем старые данные summary (опционально)
-	// if _, err := tx.Exec("TRUNCATE TABLE summary_students, summary_groups, specialties CASCADE"); err != nil {
+	// if _, err := tx.ExecContext(ctx, "TRUNCATE TABLE summary_students, summary_groups, specialties CASCADE"); err != nil {
 	// 	return fmt.Errorf("ошибка очистки summary данных: %v", err)
 	// }
 
@@ -190,10 +199,10 @@ func (l *Loader) LoadStatement(jsonPath string) error {
 	for _, dept := range departments {
 		// Получаем ID отделения (должно существовать из attendance)
 		var deptID int
-		err := tx.QueryRow("SELECT id FROM departments WHERE name = $1", dept.Department).Scan(&deptID)
+		err := tx.QueryRowContext(ctx, "SELECT id FROM departments WHERE name = $1", dept.Department).Scan(&deptID)
 		if err != nil {
 			// Если отделение не найдено, создаём его
-			err = tx.QueryRow(
+			err = tx.QueryRowContext(ctx,
 				`INSERT INTO departments (name) VALUES ($1) 
 				 ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name 
 				 RETURNING id`,
@@ -207,7 +216,7 @@ func (l *Loader) LoadStatement(jsonPath string) error {
 		for _, spec := range dept.Specialties {
 			// Вставляем или получаем специальность
 			var specID int
-			err := tx.QueryRow(
+			err := tx.QueryRowContext(ctx,
 				`INSERT INTO specialties (department_id, name, total_missed) VALUES ($1, $2, $3) 
 				 ON CONFLICT (department_id, name) 
 				 DO UPDATE SET total_missed = EXCLUDED.total_missed 
@@ -221,7 +230,7 @@ func (l *Loader) LoadStatement(jsonPath string) error {
 			for _, group := range spec.Groups {
 				// Вставляем или получаем группу summary
 				var summaryGroupID int
-				err := tx.QueryRow(
+				err := tx.QueryRowContext(ctx,
 					`INSERT INTO summary_groups (specialty_id, name, total_missed) VALUES ($1, $2, $3) 
 					 ON CONFLICT (specialty_id, name) 
 					 DO UPDATE SET total_missed = EXCLUDED.total_missed 
@@ -234,7 +243,7 @@ func (l *Loader) LoadStatement(jsonPath string) error {
 
 				for _, student := range group.Students {
 					// Вставляем студента summary
-					_, err = tx.Exec(
+					_, err = tx.ExecContext(ctx,
 						`INSERT INTO summary_students (summary_group_id, full_name, missed_total, missed_bad, missed_excused) 
 						 VALUES ($1, $2, $3, $4, $5)
 						 ON CONFLICT (summary_group_id, full_name) 
@@ -257,6 +266,7 @@ func (l *Loader) LoadStatement(jsonPath string) error {
 		return fmt.Errorf("ошибка коммита транзакции: %v", err)
 	}
 
-	log.Printf("[Database] Ведомость загружена. Отделений: %d", len(departments))
+	span.SetAttributes(attribute.Int("departments", len(departments)))
+	logger.InfoContext(ctx, "Ведомость загружена", "departments", len(departments))
 	return nil
 }
@@ -0,0 +1,190 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"dashboard/internal/models"
+)
+
+// NotificationStore хранит получателей уведомлений и журнал доставки
+type NotificationStore struct {
+	db *sql.DB
+}
+
+func NewNotificationStore(db *sql.DB) *NotificationStore {
+	return &NotificationStore{db: db}
+}
+
+const recipientColumns = `id, email, name, department, group_name, alerts, digest, enabled, created_at, updated_at`
+
+// ListRecipients возвращает всех получателей
+func (s *NotificationStore) ListRecipients() ([]models.Recipient, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("БД не подключена")
+	}
+
+	rows, err := s.db.Query(`SELECT ` + recipientColumns + ` FROM notification_recipients ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки получателей: %v", err)
+	}
+	defer rows.Close()
+
+	out := []models.Recipient{}
+	for rows.Next() {
+		r, err := scanRecipient(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// GetRecipient возвращает получателя по ID
+func (s *NotificationStore) GetRecipient(id int) (models.Recipient, error) {
+	if s.db == nil {
+		return models.Recipient{}, fmt.Errorf("БД не подключена")
+	}
+	r, err := scanRecipient(s.db.QueryRow(`SELECT `+recipientColumns+` FROM notification_recipients WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Recipient{}, ErrNotFound
+	}
+	return r, err
+}
+
+// CreateRecipient добавляет получателя
+func (s *NotificationStore) CreateRecipient(r models.Recipient) (models.Recipient, error) {
+	if s.db == nil {
+		return models.Recipient{}, fmt.Errorf("БД не подключена")
+	}
+	var id int
+	err := s.db.QueryRow(
+		`INSERT INTO notification_recipients (email, name, department, group_name, alerts, digest, enabled)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		r.Email, r.Name, r.Department, r.Group, r.Alerts, r.Digest, r.Enabled,
+	).Scan(&id)
+	if isUniqueViolation(err) {
+		return models.Recipient{}, ErrDuplicate
+	}
+	if err != nil {
+		return models.Recipient{}, fmt.Errorf("ошибка сохранения получателя: %v", err)
+	}
+	return s.GetRecipient(id)
+}
+
+// UpdateRecipient заменяет данные получателя
+func (s *NotificationStore) UpdateRecipient(id int, r models.Recipient) (models.Recipient, error) {
+	if s.db == nil {
+		return models.Recipient{}, fmt.Errorf("БД не подключена")
+	}
+	res, err := s.db.Exec(
+		`UPDATE notification_recipients
+		 SET email = $2, name = $3, department = $4, group_name = $5, alerts = $6, digest = $7, enabled = $8
+		 WHERE id = $1`,
+		id, r.Email, r.Name, r.Department, r.Group, r.Alerts, r.Digest, r.Enabled,
+	)
+	if isUniqueViolation(err) {
+		return models.Recipient{}, ErrDuplicate
+	}
+	if err != nil {
+		return models.Recipient{}, fmt.Errorf("ошибка обновления получателя: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.Recipient{}, ErrNotFound
+	}
+	return s.GetRecipient(id)
+}
+
+// DeleteRecipient удаляет получателя
+func (s *NotificationStore) DeleteRecipient(id int) error {
+	if s.db == nil {
+		return fmt.Errorf("БД не подключена")
+	}
+	res, err := s.db.Exec(`DELETE FROM notification_recipients WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления получателя: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// WasNotified проверяет, отправлялось ли уже уведомление об алерте получателю
+func (s *NotificationStore) WasNotified(alertID int, recipient string) (bool, error) {
+	if s.db == nil {
+		return false, fmt.Errorf("БД не подключена")
+	}
+	var exists bool
+	err := s.db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM notification_log
+		 WHERE kind = 'alert' AND alert_id = $1 AND recipient = $2 AND status = 'sent')`,
+		alertID, recipient,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("ошибка проверки журнала уведомлений: %v", err)
+	}
+	return exists, nil
+}
+
+// LogDelivery записывает результат отправки в журнал
+func (s *NotificationStore) LogDelivery(d models.Delivery) error {
+	if s.db == nil {
+		return fmt.Errorf("БД не подключена")
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO notification_log (kind, alert_id, recipient, subject, status, error)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		d.Kind, d.AlertID, d.Recipient, d.Subject, d.Status, d.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка записи журнала уведомлений: %v", err)
+	}
+	return nil
+}
+
+// ListDeliveries возвращает журнал доставки, начиная с самых свежих записей
+func (s *NotificationStore) ListDeliveries(limit, offset int) ([]models.Delivery, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("БД не подключена")
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	rows, err := s.db.Query(
+		`SELECT id, kind, alert_id, recipient, subject, status, error, created_at
+		 FROM notification_log ORDER BY id DESC LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки журнала уведомлений: %v", err)
+	}
+	defer rows.Close()
+
+	out := []models.Delivery{}
+	for rows.Next() {
+		var d models.Delivery
+		var alertID sql.NullInt64
+		if err := rows.Scan(&d.ID, &d.Kind, &alertID, &d.Recipient, &d.Subject, &d.Status, &d.Error, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		if alertID.Valid {
+			id := int(alertID.Int64)
+			d.AlertID = &id
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func scanRecipient(row rowScanner) (models.Recipient, error) {
+	var r models.Recipient
+	var createdAt, updatedAt sql.NullTime
+	err := row.Scan(&r.ID, &r.Email, &r.Name, &r.Department, &r.Group, &r.Alerts, &r.Digest, &r.Enabled, &createdAt, &updatedAt)
+	r.CreatedAt = createdAt.Time
+	r.UpdatedAt = updatedAt.Time
+	return r, err
+}
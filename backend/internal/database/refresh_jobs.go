@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RefreshJobRecord - завершённая задача обновления. Data - задача
+// целиком (этапы, ошибка) в JSON, остальные поля - для выборки.
+type RefreshJobRecord struct {
+	ID         string
+	Trigger    string
+	Status     string
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+	Data       json.RawMessage
+}
+
+// RefreshJobStore хранит историю задач обновления, чтобы она
+// переживала перезапуск сервера
+type RefreshJobStore struct {
+	db *sql.DB
+}
+
+func NewRefreshJobStore(db *sql.DB) *RefreshJobStore {
+	return &RefreshJobStore{db: db}
+}
+
+// Available сообщает, подключена ли БД
+func (s *RefreshJobStore) Available() bool {
+	return s.db != nil
+}
+
+// Save сохраняет задачу (повторное сохранение заменяет запись)
+func (s *RefreshJobStore) Save(ctx context.Context, r RefreshJobRecord) error {
+	if s.db == nil {
+		return fmt.Errorf("БД не подключена")
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO refresh_jobs (id, trigger, status, created_at, finished_at, data)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (id) DO UPDATE SET status = EXCLUDED.status,
+		   finished_at = EXCLUDED.finished_at, data = EXCLUDED.data`,
+		r.ID, r.Trigger, r.Status, r.CreatedAt, r.FinishedAt, []byte(r.Data))
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения задачи обновления: %v", err)
+	}
+	return nil
+}
+
+// Recent возвращает последние задачи, начиная с самой свежей
+func (s *RefreshJobStore) Recent(ctx context.Context, limit int) ([]RefreshJobRecord, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("БД не подключена")
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, trigger, status, created_at, finished_at, data
+		 FROM refresh_jobs ORDER BY created_at DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки истории обновлений: %v", err)
+	}
+	defer rows.Close()
+
+	out := []RefreshJobRecord{}
+	for rows.Next() {
+		var r RefreshJobRecord
+		var finished sql.NullTime
+		var data []byte
+		if err := rows.Scan(&r.ID, &r.Trigger, &r.Status, &r.CreatedAt, &finished, &data); err != nil {
+			return nil, err
+		}
+		if finished.Valid {
+			r.FinishedAt = &finished.Time
+		}
+		r.Data = data
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"dashboard/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrDuplicate возвращается при нарушении уникальности
+var ErrDuplicate = errors.New("запись уже существует")
+
+// RuleStore хранит правила алертов в БД.
+// Правило целиком хранится в JSONB, имя и тип вынесены в колонки.
+type RuleStore struct {
+	db *sql.DB
+}
+
+func NewRuleStore(db *sql.DB) *RuleStore {
+	return &RuleStore{db: db}
+}
+
+// List возвращает все правила
+func (s *RuleStore) List() ([]models.AlertRule, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("БД не подключена")
+	}
+
+	rows, err := s.db.Query(`SELECT id, enabled, definition, created_at, updated_at FROM alert_rules ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки правил: %v", err)
+	}
+	defer rows.Close()
+
+	out := []models.AlertRule{}
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// Get возвращает правило по ID
+func (s *RuleStore) Get(id int) (models.AlertRule, error) {
+	if s.db == nil {
+		return models.AlertRule{}, fmt.Errorf("БД не подключена")
+	}
+	r, err := scanRule(s.db.QueryRow(`SELECT id, enabled, definition, created_at, updated_at FROM alert_rules WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.AlertRule{}, ErrNotFound
+	}
+	return r, err
+}
+
+// Create сохраняет новое правило
+func (s *RuleStore) Create(r models.AlertRule) (models.AlertRule, error) {
+	if s.db == nil {
+		return models.AlertRule{}, fmt.Errorf("БД не подключена")
+	}
+	def, err := json.Marshal(r)
+	if err != nil {
+		return models.AlertRule{}, fmt.Errorf("ошибка сериализации правила: %v", err)
+	}
+
+	var id int
+	err = s.db.QueryRow(
+		`INSERT INTO alert_rules (name, type, enabled, definition) VALUES ($1, $2, $3, $4) RETURNING id`,
+		r.Name, r.Type, r.Enabled, def,
+	).Scan(&id)
+	if isUniqueViolation(err) {
+		return models.AlertRule{}, ErrDuplicate
+	}
+	if err != nil {
+		return models.AlertRule{}, fmt.Errorf("ошибка сохранения правила: %v", err)
+	}
+	return s.Get(id)
+}
+
+// Update заменяет правило целиком
+func (s *RuleStore) Update(id int, r models.AlertRule) (models.AlertRule, error) {
+	if s.db == nil {
+		return models.AlertRule{}, fmt.Errorf("БД не подключена")
+	}
+	def, err := json.Marshal(r)
+	if err != nil {
+		return models.AlertRule{}, fmt.Errorf("ошибка сериализации правила: %v", err)
+	}
+
+	res, err := s.db.Exec(
+		`UPDATE alert_rules SET name = $2, type = $3, enabled = $4, definition = $5 WHERE id = $1`,
+		id, r.Name, r.Type, r.Enabled, def,
+	)
+	if isUniqueViolation(err) {
+		return models.AlertRule{}, ErrDuplicate
+	}
+	if err != nil {
+		return models.AlertRule{}, fmt.Errorf("ошибка обновления правила: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.AlertRule{}, ErrNotFound
+	}
+	return s.Get(id)
+}
+
+// Delete удаляет правило
+func (s *RuleStore) Delete(id int) error {
+	if s.db == nil {
+		return fmt.Errorf("БД не подключена")
+	}
+	res, err := s.db.Exec(`DELETE FROM alert_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления правила: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanRule(row rowScanner) (models.AlertRule, error) {
+	var (
+		r       models.AlertRule
+		id      int
+		enabled bool
+		def     []byte
+	)
+	var createdAt, updatedAt sql.NullTime
+	if err := row.Scan(&id, &enabled, &def, &createdAt, &updatedAt); err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(def, &r); err != nil {
+		return r, fmt.Errorf("ошибка разбора правила %d: %v", id, err)
+	}
+	r.ID = id
+	r.Enabled = enabled
+	r.CreatedAt = createdAt.Time
+	r.UpdatedAt = updatedAt.Time
+	return r, nil
+}
+
+// isUniqueViolation проверяет, что ошибка - нарушение ограничения UNIQUE
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
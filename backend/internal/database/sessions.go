@@ -0,0 +1,176 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"dashboard/internal/models"
+)
+
+// SessionStore хранит сеансы входа и хэши refresh-токенов
+type SessionStore struct {
+	db *sql.DB
+}
+
+func NewSessionStore(db *sql.DB) *SessionStore {
+	return &SessionStore{db: db}
+}
+
+// Available сообщает, подключена ли БД
+func (s *SessionStore) Available() bool {
+	return s.db != nil
+}
+
+const sessionColumns = `id, user_id, refresh_hash, previous_hash, user_agent, ip, created_at, last_used_at, expires_at, revoked_at`
+
+// Create сохраняет новый сеанс
+func (s *SessionStore) Create(sess models.Session) error {
+	if s.db == nil {
+		return fmt.Errorf("БД не подключена")
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO sessions (id, user_id, refresh_hash, user_agent, ip, expires_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		sess.ID, sess.UserID, sess.RefreshHash, sess.UserAgent, sess.IP, sess.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения сеанса: %v", err)
+	}
+	return nil
+}
+
+// Get возвращает сеанс по ID
+func (s *SessionStore) Get(id string) (models.Session, error) {
+	if s.db == nil {
+		return models.Session{}, fmt.Errorf("БД не подключена")
+	}
+	sess, err := scanSession(s.db.QueryRow(`SELECT `+sessionColumns+` FROM sessions WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Session{}, ErrNotFound
+	}
+	return sess, err
+}
+
+// Rotate заменяет refresh-токен активного сеанса, если предъявлен текущий.
+// Возвращает false, если токен уже был заменён параллельным запросом.
+func (s *SessionStore) Rotate(id, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	if s.db == nil {
+		return false, fmt.Errorf("БД не подключена")
+	}
+	res, err := s.db.Exec(
+		`UPDATE sessions SET previous_hash = refresh_hash, refresh_hash = $3, last_used_at = $4, expires_at = $5
+		 WHERE id = $1 AND refresh_hash = $2 AND revoked_at IS NULL`,
+		id, oldHash, newHash, time.Now(), expiresAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("ошибка обновления сеанса: %v", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// Revoke завершает сеанс
+func (s *SessionStore) Revoke(id string) error {
+	if s.db == nil {
+		return fmt.Errorf("БД не подключена")
+	}
+	res, err := s.db.Exec(`UPDATE sessions SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка завершения сеанса: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RevokeUser завершает все активные сеансы пользователя и возвращает их ID
+func (s *SessionStore) RevokeUser(userID int) ([]string, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("БД не подключена")
+	}
+	rows, err := s.db.Query(
+		`UPDATE sessions SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL RETURNING id`,
+		userID, time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка завершения сеансов: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListActive возвращает действующие сеансы пользователя
+func (s *SessionStore) ListActive(userID int) ([]models.Session, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("БД не подключена")
+	}
+	rows, err := s.db.Query(
+		`SELECT `+sessionColumns+` FROM sessions
+		 WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2 ORDER BY last_used_at DESC`,
+		userID, time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки сеансов: %v", err)
+	}
+	defer rows.Close()
+
+	out := []models.Session{}
+	for rows.Next() {
+		sess, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sess)
+	}
+	return out, rows.Err()
+}
+
+// RevokedSince возвращает ID сеансов, завершённых после указанного момента.
+// Используется, чтобы восстановить список отозванных токенов после перезапуска.
+func (s *SessionStore) RevokedSince(since time.Time) ([]string, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("БД не подключена")
+	}
+	rows, err := s.db.Query(`SELECT id FROM sessions WHERE revoked_at > $1`, since)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки сеансов: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func scanSession(row rowScanner) (models.Session, error) {
+	var sess models.Session
+	var createdAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&sess.ID, &sess.UserID, &sess.RefreshHash, &sess.PreviousHash, &sess.UserAgent, &sess.IP,
+		&createdAt, &lastUsedAt, &sess.ExpiresAt, &revokedAt)
+	if err != nil {
+		return sess, err
+	}
+	sess.CreatedAt = createdAt.Time
+	sess.LastUsedAt = lastUsedAt.Time
+	if revokedAt.Valid {
+		sess.RevokedAt = &revokedAt.Time
+	}
+	return sess, nil
+}
@@ -0,0 +1,253 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"dashboard/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// UserStore хранит учётные записи пользователей
+type UserStore struct {
+	db *sql.DB
+}
+
+func NewUserStore(db *sql.DB) *UserStore {
+	return &UserStore{db: db}
+}
+
+// Available сообщает, подключена ли БД с пользователями
+func (s *UserStore) Available() bool {
+	return s.db != nil
+}
+
+const userColumns = `id, username, password_hash, role, scope_departments, scope_groups, disabled, auth_source, totp_enabled, totp_secret, recovery_codes, last_login_at, created_at, updated_at`
+
+// List возвращает всех пользователей
+func (s *UserStore) List() ([]models.User, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("БД не подключена")
+	}
+
+	rows, err := s.db.Query(`SELECT ` + userColumns + ` FROM users ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки пользователей: %v", err)
+	}
+	defer rows.Close()
+
+	out := []models.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// Get возвращает пользователя по ID
+func (s *UserStore) Get(id int) (models.User, error) {
+	if s.db == nil {
+		return models.User{}, fmt.Errorf("БД не подключена")
+	}
+	u, err := scanUser(s.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, ErrNotFound
+	}
+	return u, err
+}
+
+// GetByUsername возвращает пользователя по логину
+func (s *UserStore) GetByUsername(username string) (models.User, error) {
+	if s.db == nil {
+		return models.User{}, fmt.Errorf("БД не подключена")
+	}
+	u, err := scanUser(s.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE username = $1`, username))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, ErrNotFound
+	}
+	return u, err
+}
+
+// Create добавляет пользователя. PasswordHash должен быть уже вычислен.
+func (s *UserStore) Create(u models.User) (models.User, error) {
+	if s.db == nil {
+		return models.User{}, fmt.Errorf("БД не подключена")
+	}
+	var id int
+	err := s.db.QueryRow(
+		`INSERT INTO users (username, password_hash, role, scope_departments, scope_groups, disabled)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		u.Username, u.PasswordHash, u.Role, pq.Array(nonNil(u.Departments)), pq.Array(nonNil(u.Groups)), u.Disabled,
+	).Scan(&id)
+	if isUniqueViolation(err) {
+		return models.User{}, ErrDuplicate
+	}
+	if err != nil {
+		return models.User{}, fmt.Errorf("ошибка сохранения пользователя: %v", err)
+	}
+	return s.Get(id)
+}
+
+// SyncExternal создаёт или обновляет учётную запись внешнего источника (LDAP):
+// роль и область видимости берутся из каталога при каждом входе. Пароль
+// в БД не хранится. Если логин занят локальной учётной записью,
+// возвращается ErrDuplicate.
+func (s *UserStore) SyncExternal(source, username, role string, departments, groups []string) (models.User, error) {
+	if s.db == nil {
+		return models.User{}, fmt.Errorf("БД не подключена")
+	}
+	var id int
+	err := s.db.QueryRow(
+		`INSERT INTO users (username, password_hash, role, scope_departments, scope_groups, auth_source, last_login_at)
+		 VALUES ($1, '', $2, $3, $4, $5, $6)
+		 ON CONFLICT (username) DO UPDATE
+		 SET role = EXCLUDED.role, scope_departments = EXCLUDED.scope_departments,
+		     scope_groups = EXCLUDED.scope_groups, last_login_at = EXCLUDED.last_login_at
+		 WHERE users.auth_source = EXCLUDED.auth_source
+		 RETURNING id`,
+		username, role, pq.Array(nonNil(departments)), pq.Array(nonNil(groups)), source, time.Now(),
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, ErrDuplicate
+	}
+	if err != nil {
+		return models.User{}, fmt.Errorf("ошибка синхронизации пользователя %s: %v", username, err)
+	}
+	return s.Get(id)
+}
+
+// SetDisabled блокирует или разблокирует пользователя
+func (s *UserStore) SetDisabled(id int, disabled bool) (models.User, error) {
+	return s.update(id, `UPDATE users SET disabled = $2 WHERE id = $1`, disabled)
+}
+
+// SetPassword заменяет хэш пароля
+func (s *UserStore) SetPassword(id int, hash string) (models.User, error) {
+	return s.update(id, `UPDATE users SET password_hash = $2 WHERE id = $1`, hash)
+}
+
+// SetRole назначает роль и область видимости
+func (s *UserStore) SetRole(id int, role string, departments, groups []string) (models.User, error) {
+	if s.db == nil {
+		return models.User{}, fmt.Errorf("БД не подключена")
+	}
+	res, err := s.db.Exec(
+		`UPDATE users SET role = $2, scope_departments = $3, scope_groups = $4 WHERE id = $1`,
+		id, role, pq.Array(nonNil(departments)), pq.Array(nonNil(groups)),
+	)
+	if err != nil {
+		return models.User{}, fmt.Errorf("ошибка обновления пользователя: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.User{}, ErrNotFound
+	}
+	return s.Get(id)
+}
+
+// SetTOTP сохраняет секрет второго фактора, его состояние и хэши кодов
+// восстановления. Пустой секрет и enabled = false отключают 2FA.
+func (s *UserStore) SetTOTP(id int, secret string, enabled bool, recoveryHashes []string) (models.User, error) {
+	if s.db == nil {
+		return models.User{}, fmt.Errorf("БД не подключена")
+	}
+	res, err := s.db.Exec(
+		`UPDATE users SET totp_secret = $2, totp_enabled = $3, recovery_codes = $4 WHERE id = $1`,
+		id, secret, enabled, pq.Array(nonNil(recoveryHashes)),
+	)
+	if err != nil {
+		return models.User{}, fmt.Errorf("ошибка обновления пользователя: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.User{}, ErrNotFound
+	}
+	return s.Get(id)
+}
+
+// UseRecoveryCode погашает код восстановления. Возвращает false, если такого
+// кода нет или он уже использован; параллельные запросы не погасят код дважды.
+func (s *UserStore) UseRecoveryCode(id int, hash string) (bool, error) {
+	if s.db == nil {
+		return false, fmt.Errorf("БД не подключена")
+	}
+	res, err := s.db.Exec(
+		`UPDATE users SET recovery_codes = array_remove(recovery_codes, $2)
+		 WHERE id = $1 AND $2 = ANY(recovery_codes)`,
+		id, hash,
+	)
+	if err != nil {
+		return false, fmt.Errorf("ошибка обновления пользователя: %v", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// TouchLogin запоминает время последнего входа
+func (s *UserStore) TouchLogin(id int) error {
+	if s.db == nil {
+		return fmt.Errorf("БД не подключена")
+	}
+	if _, err := s.db.Exec(`UPDATE users SET last_login_at = $2 WHERE id = $1`, id, time.Now()); err != nil {
+		return fmt.Errorf("ошибка обновления пользователя: %v", err)
+	}
+	return nil
+}
+
+// CountActiveAdmins считает незаблокированных администраторов
+func (s *UserStore) CountActiveAdmins() (int, error) {
+	if s.db == nil {
+		return 0, fmt.Errorf("БД не подключена")
+	}
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM users WHERE role = $1 AND NOT disabled`, models.RoleAdmin).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка подсчёта администраторов: %v", err)
+	}
+	return n, nil
+}
+
+func (s *UserStore) update(id int, query string, arg interface{}) (models.User, error) {
+	if s.db == nil {
+		return models.User{}, fmt.Errorf("БД не подключена")
+	}
+	res, err := s.db.Exec(query, id, arg)
+	if err != nil {
+		return models.User{}, fmt.Errorf("ошибка обновления пользователя: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.User{}, ErrNotFound
+	}
+	return s.Get(id)
+}
+
+func scanUser(row rowScanner) (models.User, error) {
+	var u models.User
+	var lastLogin, createdAt, updatedAt sql.NullTime
+	err := row.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Role, pq.Array(&u.Departments), pq.Array(&u.Groups),
+		&u.Disabled, &u.Source, &u.TOTPEnabled, &u.TOTPSecret, pq.Array(&u.RecoveryCodes), &lastLogin, &createdAt, &updatedAt)
+	if err != nil {
+		return u, err
+	}
+	u.Departments = nonNil(u.Departments)
+	u.Groups = nonNil(u.Groups)
+	u.RecoveryCodes = nonNil(u.RecoveryCodes)
+	if lastLogin.Valid {
+		u.LastLoginAt = &lastLogin.Time
+	}
+	u.CreatedAt = createdAt.Time
+	u.UpdatedAt = updatedAt.Time
+	return u, nil
+}
+
+// nonNil заменяет nil на пустой срез, чтобы в БД попал '{}', а не NULL
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,229 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"dashboard/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// WebhookStore хранит подписки webhooks и историю доставки
+type WebhookStore struct {
+	db *sql.DB
+}
+
+func NewWebhookStore(db *sql.DB) *WebhookStore {
+	return &WebhookStore{db: db}
+}
+
+const webhookColumns = `id, name, url, secret, events, enabled, created_at, updated_at`
+
+const deliveryColumns = `id, webhook_id, event_type, payload, status, attempts, response_status, error,
+	replay_of, created_at, delivered_at`
+
+// List возвращает все подписки
+func (s *WebhookStore) List() ([]models.Webhook, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("БД не подключена")
+	}
+
+	rows, err := s.db.Query(`SELECT ` + webhookColumns + ` FROM webhooks ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки webhooks: %v", err)
+	}
+	defer rows.Close()
+
+	out := []models.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// Get возвращает подписку по ID
+func (s *WebhookStore) Get(id int) (models.Webhook, error) {
+	if s.db == nil {
+		return models.Webhook{}, fmt.Errorf("БД не подключена")
+	}
+	w, err := scanWebhook(s.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Webhook{}, ErrNotFound
+	}
+	return w, err
+}
+
+// Create добавляет подписку
+func (s *WebhookStore) Create(w models.Webhook) (models.Webhook, error) {
+	if s.db == nil {
+		return models.Webhook{}, fmt.Errorf("БД не подключена")
+	}
+	var id int
+	err := s.db.QueryRow(
+		`INSERT INTO webhooks (name, url, secret, events, enabled) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		w.Name, w.URL, w.Secret, pq.Array(w.Events), w.Enabled,
+	).Scan(&id)
+	if isUniqueViolation(err) {
+		return models.Webhook{}, ErrDuplicate
+	}
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("ошибка сохранения webhook: %v", err)
+	}
+	return s.Get(id)
+}
+
+// Update заменяет подписку. Пустой секрет оставляет прежний.
+func (s *WebhookStore) Update(id int, w models.Webhook) (models.Webhook, error) {
+	if s.db == nil {
+		return models.Webhook{}, fmt.Errorf("БД не подключена")
+	}
+	res, err := s.db.Exec(
+		`UPDATE webhooks SET name = $2, url = $3, secret = COALESCE(NULLIF($4, ''), secret), events = $5, enabled = $6
+		 WHERE id = $1`,
+		id, w.Name, w.URL, w.Secret, pq.Array(w.Events), w.Enabled,
+	)
+	if isUniqueViolation(err) {
+		return models.Webhook{}, ErrDuplicate
+	}
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("ошибка обновления webhook: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return models.Webhook{}, ErrNotFound
+	}
+	return s.Get(id)
+}
+
+// Delete удаляет подписку вместе с историей доставки
+func (s *WebhookStore) Delete(id int) error {
+	if s.db == nil {
+		return fmt.Errorf("БД не подключена")
+	}
+	res, err := s.db.Exec(`DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления webhook: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CreateDelivery сохраняет новую доставку со статусом pending
+func (s *WebhookStore) CreateDelivery(d models.WebhookDelivery) (models.WebhookDelivery, error) {
+	if s.db == nil {
+		return models.WebhookDelivery{}, fmt.Errorf("БД не подключена")
+	}
+	var id int
+	err := s.db.QueryRow(
+		`INSERT INTO webhook_deliveries (webhook_id, event_type, payload, status, replay_of)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		d.WebhookID, d.EventType, []byte(d.Payload), models.WebhookPending, d.ReplayOf,
+	).Scan(&id)
+	if err != nil {
+		return models.WebhookDelivery{}, fmt.Errorf("ошибка сохранения доставки: %v", err)
+	}
+	return s.GetDelivery(id)
+}
+
+// UpdateDelivery записывает результат очередной попытки доставки
+func (s *WebhookStore) UpdateDelivery(d models.WebhookDelivery) error {
+	if s.db == nil {
+		return fmt.Errorf("БД не подключена")
+	}
+	var deliveredAt interface{}
+	if d.Status == models.WebhookDelivered {
+		deliveredAt = time.Now()
+	}
+	_, err := s.db.Exec(
+		`UPDATE webhook_deliveries SET status = $2, attempts = $3, response_status = $4, error = $5, delivered_at = $6
+		 WHERE id = $1`,
+		d.ID, d.Status, d.Attempts, d.ResponseStatus, d.Error, deliveredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления доставки: %v", err)
+	}
+	return nil
+}
+
+// GetDelivery возвращает доставку по ID
+func (s *WebhookStore) GetDelivery(id int) (models.WebhookDelivery, error) {
+	if s.db == nil {
+		return models.WebhookDelivery{}, fmt.Errorf("БД не подключена")
+	}
+	d, err := scanDelivery(s.db.QueryRow(`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.WebhookDelivery{}, ErrNotFound
+	}
+	return d, err
+}
+
+// ListDeliveries возвращает историю доставки подписки, начиная с самых свежих
+func (s *WebhookStore) ListDeliveries(webhookID, limit, offset int) ([]models.WebhookDelivery, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("БД не подключена")
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	rows, err := s.db.Query(
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE webhook_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3`,
+		webhookID, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки доставок: %v", err)
+	}
+	defer rows.Close()
+
+	out := []models.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func scanWebhook(row rowScanner) (models.Webhook, error) {
+	var w models.Webhook
+	var createdAt, updatedAt sql.NullTime
+	err := row.Scan(&w.ID, &w.Name, &w.URL, &w.Secret, pq.Array(&w.Events), &w.Enabled, &createdAt, &updatedAt)
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+	w.CreatedAt = createdAt.Time
+	w.UpdatedAt = updatedAt.Time
+	return w, err
+}
+
+func scanDelivery(row rowScanner) (models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var payload []byte
+	var replayOf sql.NullInt64
+	var createdAt, deliveredAt sql.NullTime
+	err := row.Scan(&d.ID, &d.WebhookID, &d.EventType, &payload, &d.Status, &d.Attempts, &d.ResponseStatus, &d.Error,
+		&replayOf, &createdAt, &deliveredAt)
+	if err != nil {
+		return d, err
+	}
+	d.Payload = payload
+	d.CreatedAt = createdAt.Time
+	if replayOf.Valid {
+		id := int(replayOf.Int64)
+		d.ReplayOf = &id
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return d, nil
+}
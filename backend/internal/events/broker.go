@@ -0,0 +1,157 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Типы событий, которые получают клиенты
+const (
+	RefreshStarted   = "refresh.started"
+	RefreshProgress  = "refresh.progress"
+	RefreshCompleted = "refresh.completed"
+	RefreshFailed    = "refresh.failed"
+	SnapshotActive   = "snapshot.activated"
+	AlertCreated     = "alert.created"
+)
+
+const (
+	// subscriberBuffer - размер буфера канала одного подписчика
+	subscriberBuffer = 32
+	// defaultReplaySize - сколько последних событий хранится для переподключений
+	defaultReplaySize = 100
+)
+
+// Event описывает одно событие для клиентов
+type Event struct {
+	ID   int64       `json:"id"`
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data,omitempty"`
+
+	// Roles ограничивает получателей события. Пустой список - событие видят все.
+	Roles []string `json:"-"`
+}
+
+// VisibleTo сообщает, доступно ли событие пользователю с указанной ролью
+func (e Event) VisibleTo(role string) bool {
+	if len(e.Roles) == 0 {
+		return true
+	}
+	for _, r := range e.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter решает, нужно ли отправлять событие конкретному подписчику
+type Filter func(Event) bool
+
+// Subscription - подписка клиента на события
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	filter Filter
+}
+
+// Broker рассылает события всем подписчикам
+type Broker struct {
+	mu         sync.Mutex
+	nextID     int64
+	subs       map[*Subscription]struct{}
+	recent     []Event
+	replaySize int
+	closed     bool
+}
+
+// NewBroker создаёт брокер событий
+func NewBroker() *Broker {
+	return &Broker{
+		subs:       make(map[*Subscription]struct{}),
+		replaySize: defaultReplaySize,
+	}
+}
+
+// Publish присваивает событию ID и рассылает его подписчикам.
+// Медленные подписчики, у которых переполнен буфер, пропускают событие.
+func (b *Broker) Publish(eventType string, data interface{}, roles ...string) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	ev := Event{
+		ID:    b.nextID,
+		Type:  eventType,
+		Time:  time.Now(),
+		Data:  data,
+		Roles: roles,
+	}
+
+	b.recent = append(b.recent, ev)
+	if len(b.recent) > b.replaySize {
+		b.recent = b.recent[len(b.recent)-b.replaySize:]
+	}
+
+	for sub := range b.subs {
+		if sub.filter != nil && !sub.filter(ev) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+		}
+	}
+	return ev
+}
+
+// Subscribe регистрирует подписчика. Если lastID > 0, подписчик сначала
+// получает пропущенные события из буфера (для переподключения по Last-Event-ID).
+func (b *Broker) Subscribe(filter Filter, lastID int64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer+len(b.recent))
+	sub := &Subscription{C: ch, ch: ch, filter: filter}
+
+	if lastID > 0 {
+		for _, ev := range b.recent {
+			if ev.ID > lastID && (filter == nil || filter(ev)) {
+				ch <- ev
+			}
+		}
+	}
+
+	if b.closed {
+		close(ch)
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe отключает подписчика и закрывает его канал
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Close отключает всех подписчиков: каналы закрываются, и потоки SSE
+// завершаются. Нужен при остановке сервера - http.Server.Shutdown не
+// отменяет контекст открытых запросов и иначе ждал бы их до таймаута.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
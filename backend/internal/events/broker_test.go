@@ -0,0 +1,56 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestBroker_FilterAndReplay(t *testing.T) {
+	b := NewBroker()
+
+	viewerFilter := func(ev Event) bool { return ev.VisibleTo("viewer") }
+	sub := b.Subscribe(viewerFilter, 0)
+	defer b.Unsubscribe(sub)
+
+	b.Publish(RefreshStarted, nil, "admin")
+	b.Publish(SnapshotActive, nil)
+
+	select {
+	case ev := <-sub.C:
+		if ev.Type != SnapshotActive {
+			t.Errorf("Ожидалось событие %s, получено %s", SnapshotActive, ev.Type)
+		}
+	default:
+		t.Fatal("Ожидалось событие для подписчика")
+	}
+	select {
+	case ev := <-sub.C:
+		t.Errorf("Событие только для admin не должно доходить до viewer: %s", ev.Type)
+	default:
+	}
+
+	// Переподключение с Last-Event-ID получает пропущенные события
+	replay := b.Subscribe(nil, 1)
+	defer b.Unsubscribe(replay)
+	select {
+	case ev := <-replay.C:
+		if ev.ID != 2 {
+			t.Errorf("Ожидалось событие с ID 2, получено %d", ev.ID)
+		}
+	default:
+		t.Fatal("Ожидалось повторное получение пропущенного события")
+	}
+}
+
+func TestBroker_Close(t *testing.T) {
+	b := NewBroker()
+	sub := b.Subscribe(nil, 0)
+	b.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("После Close канал подписчика должен быть закрыт")
+	}
+	// Повторная отписка и подписка после Close не должны паниковать
+	b.Unsubscribe(sub)
+	if _, ok := <-b.Subscribe(nil, 0).C; ok {
+		t.Error("Подписка после Close должна сразу завершаться")
+	}
+}
@@ -0,0 +1,31 @@
+package events
+
+import (
+	"dashboard/internal/scheduler"
+)
+
+// adminRole - события о ходе обновления видят только администраторы
+const adminRole = "admin"
+
+// RefreshListener транслирует события задач обновления в брокер.
+// Ход обновления и ошибки видят администраторы, а о завершении узнают все
+// клиенты. Событие о новом снимке публикует сервис посещаемости после подмены данных.
+func (b *Broker) RefreshListener() scheduler.Listener {
+	return func(event string, job scheduler.Job) {
+		switch event {
+		case scheduler.EventJobStarted:
+			b.Publish(RefreshStarted, job, adminRole)
+		case scheduler.EventJobProgress:
+			b.Publish(RefreshProgress, job, adminRole)
+		case scheduler.EventJobFinished:
+			if job.Status != scheduler.JobSuccess {
+				b.Publish(RefreshFailed, job, adminRole)
+				return
+			}
+			b.Publish(RefreshCompleted, map[string]interface{}{
+				"job_id":      job.ID,
+				"finished_at": job.FinishedAt,
+			})
+		}
+	}
+}
@@ -0,0 +1,275 @@
+// Package health проверяет готовность сервера: БД и схема, снимки данных,
+// входные файлы и состояние планировщика обновлений.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"dashboard/internal/scheduler"
+)
+
+// Статусы проверки и отчёта
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// pingTimeout ограничивает проверку БД, чтобы зонд готовности не зависал
+const pingTimeout = 2 * time.Second
+
+// Check - результат одной проверки. Только проверки со статусом fail
+// снимают готовность; warn попадает в отчёт администратору.
+type Check struct {
+	Name    string         `json:"name"`
+	Status  string         `json:"status"`
+	Message string         `json:"message,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Report - подробный отчёт о состоянии сервера
+type Report struct {
+	Status    string    `json:"status"`
+	Ready     bool      `json:"ready"`
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Check   `json:"checks"`
+}
+
+// File - отслеживаемый файл данных. Отсутствие или устаревание
+// обязательного файла снимает готовность, необязательного - предупреждение.
+type File struct {
+	Name     string
+	Path     string
+	Required bool
+}
+
+// Thresholds - допустимый возраст данных. Ноль отключает проверку.
+type Thresholds struct {
+	MaxDataAge  time.Duration // с последнего успешного обновления снимка
+	MaxInputAge time.Duration // с последнего изменения входного файла
+}
+
+// Config - источники состояния для проверок
+type Config struct {
+	// DatabaseConfigured - задан ли DATABASE_URL. Если задан, а DB == nil,
+	// подключение при запуске не удалось.
+	DatabaseConfigured bool
+	DB                 *sql.DB
+	// SchemaCheck возвращает таблицы схемы, которых нет в БД
+	SchemaCheck func(ctx context.Context) ([]string, error)
+
+	Snapshots []File
+	Inputs    []File
+
+	Runner   *scheduler.Runner
+	Schedule *scheduler.Schedule
+}
+
+// Checker выполняет проверки. Пороги можно менять без перезапуска.
+type Checker struct {
+	cfg Config
+
+	mu         sync.RWMutex
+	thresholds Thresholds
+}
+
+// NewChecker создаёт проверку состояния
+func NewChecker(cfg Config, t Thresholds) *Checker {
+	return &Checker{cfg: cfg, thresholds: t}
+}
+
+// SetThresholds заменяет пороги возраста данных
+func (c *Checker) SetThresholds(t Thresholds) {
+	c.mu.Lock()
+	c.thresholds = t
+	c.mu.Unlock()
+}
+
+func (c *Checker) limits() Thresholds {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.thresholds
+}
+
+// Run выполняет все проверки
+func (c *Checker) Run(ctx context.Context) Report {
+	now := time.Now()
+	t := c.limits()
+
+	var refresh scheduler.Status
+	if c.cfg.Runner != nil {
+		refresh = c.cfg.Runner.Status()
+	}
+
+	checks := []Check{c.database(ctx)}
+	if c.cfg.DB != nil && c.cfg.SchemaCheck != nil {
+		checks = append(checks, c.schema(ctx))
+	}
+	for _, f := range c.cfg.Snapshots {
+		checks = append(checks, snapshotCheck(f, refresh.LastSuccess, t.MaxDataAge, now))
+	}
+	for _, f := range c.cfg.Inputs {
+		checks = append(checks, inputCheck(f, t.MaxInputAge, now))
+	}
+	if c.cfg.Runner != nil {
+		checks = append(checks, c.scheduler(refresh))
+	}
+	return newReport(checks, now)
+}
+
+// newReport сводит проверки: fail - сервер не готов, warn - работает с замечаниями
+func newReport(checks []Check, now time.Time) Report {
+	r := Report{Status: StatusOK, Ready: true, CheckedAt: now, Checks: checks}
+	for _, ch := range checks {
+		switch ch.Status {
+		case StatusFail:
+			r.Status, r.Ready = StatusFail, false
+		case StatusWarn:
+			if r.Status == StatusOK {
+				r.Status = StatusWarn
+			}
+		}
+	}
+	return r
+}
+
+func (c *Checker) database(ctx context.Context) Check {
+	ch := Check{Name: "database", Status: StatusOK}
+	switch {
+	case !c.cfg.DatabaseConfigured:
+		ch.Status, ch.Message = StatusWarn, "БД не настроена, данные не сохраняются"
+		return ch
+	case c.cfg.DB == nil:
+		ch.Status, ch.Message = StatusFail, "не удалось подключиться к БД при запуске"
+		return ch
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := c.cfg.DB.PingContext(ctx); err != nil {
+		ch.Status, ch.Message = StatusFail, fmt.Sprintf("ping: %v", err)
+		return ch
+	}
+	stats := c.cfg.DB.Stats()
+	ch.Details = map[string]any{
+		"latency_ms":       time.Since(start).Milliseconds(),
+		"open_connections": stats.OpenConnections,
+		"in_use":           stats.InUse,
+	}
+	return ch
+}
+
+func (c *Checker) schema(ctx context.Context) Check {
+	ch := Check{Name: "schema", Status: StatusOK}
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	missing, err := c.cfg.SchemaCheck(ctx)
+	switch {
+	case err != nil:
+		ch.Status, ch.Message = StatusFail, err.Error()
+	case len(missing) > 0:
+		ch.Status, ch.Message = StatusFail, "схема БД не применена полностью"
+		ch.Details = map[string]any{"missing_tables": missing}
+	}
+	return ch
+}
+
+// snapshotCheck проверяет JSON-снимок данных. Возраст данных считается
+// от последнего успешного обновления: если входной файл не менялся,
+// конвертация пропускается и снимок не перезаписывается, но данные актуальны.
+func snapshotCheck(f File, lastSuccess time.Time, maxAge time.Duration, now time.Time) Check {
+	ch := Check{Name: "snapshot:" + f.Name, Status: StatusOK}
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		ch.Status, ch.Message = failure(f), fileError(err)
+		return ch
+	}
+	fresh := info.ModTime()
+	if lastSuccess.After(fresh) {
+		fresh = lastSuccess
+	}
+	age := now.Sub(fresh)
+	ch.Details = map[string]any{
+		"modified_at": info.ModTime(),
+		"size":        info.Size(),
+		"age":         age.Round(time.Second).String(),
+	}
+	if maxAge > 0 && age > maxAge {
+		ch.Status = failure(f)
+		ch.Message = fmt.Sprintf("данные не обновлялись %s (допустимо %s)", age.Round(time.Minute), maxAge)
+	}
+	return ch
+}
+
+// inputCheck проверяет входной Excel-файл. Старый входной файл - повод
+// напомнить секретарям, но не признак неготовности сервера.
+func inputCheck(f File, maxAge time.Duration, now time.Time) Check {
+	ch := Check{Name: "input:" + f.Name, Status: StatusOK}
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		ch.Status, ch.Message = StatusWarn, fileError(err)
+		return ch
+	}
+	age := now.Sub(info.ModTime())
+	ch.Details = map[string]any{
+		"modified_at": info.ModTime(),
+		"age":         age.Round(time.Second).String(),
+	}
+	if maxAge > 0 && age > maxAge {
+		ch.Status = StatusWarn
+		ch.Message = fmt.Sprintf("файл не менялся %s (ожидается не реже раза в %s)", age.Round(time.Minute), maxAge)
+	}
+	return ch
+}
+
+func (c *Checker) scheduler(st scheduler.Status) Check {
+	ch := Check{Name: "scheduler", Status: StatusOK, Details: map[string]any{
+		"in_progress": st.InProgress,
+		"queued":      len(st.Queue),
+	}}
+	if !st.LastSuccess.IsZero() {
+		ch.Details["last_success"] = st.LastSuccess
+	}
+	if c.cfg.Schedule != nil {
+		sched := c.cfg.Schedule.Status()
+		ch.Details["paused"] = sched.Paused
+		ch.Details["next_run"] = sched.NextRun
+		if sched.Paused {
+			ch.Status, ch.Message = StatusWarn, "плановое обновление приостановлено"
+		}
+	}
+	if job := st.LastJob; job != nil {
+		ch.Details["last_job"] = map[string]any{
+			"id":          job.ID,
+			"trigger":     job.Trigger,
+			"status":      job.Status,
+			"error":       job.Error,
+			"finished_at": job.FinishedAt,
+		}
+		if job.Status == scheduler.JobFailed {
+			ch.Status, ch.Message = StatusWarn, "последнее обновление завершилось ошибкой: "+job.Error
+		}
+	}
+	return ch
+}
+
+// failure - статус проблемы с файлом в зависимости от его обязательности
+func failure(f File) string {
+	if f.Required {
+		return StatusFail
+	}
+	return StatusWarn
+}
+
+func fileError(err error) string {
+	if os.IsNotExist(err) {
+		return "файл не найден"
+	}
+	return err.Error()
+}
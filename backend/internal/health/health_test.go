@@ -0,0 +1,97 @@
+package health
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	dir := t.TempDir()
+	attendance := filepath.Join(dir, "attendance.json")
+	input := filepath.Join(dir, "attendance.xlsx")
+	for _, p := range []string{attendance, input} {
+		if err := os.WriteFile(p, []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(input, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewChecker(Config{
+		Snapshots: []File{
+			{Name: "attendance", Path: attendance, Required: true},
+			{Name: "statement", Path: filepath.Join(dir, "statement.json")},
+		},
+		Inputs: []File{{Name: "attendance", Path: input}},
+	}, Thresholds{MaxDataAge: time.Hour, MaxInputAge: 24 * time.Hour})
+
+	status := func(r Report) map[string]string {
+		out := make(map[string]string)
+		for _, ch := range r.Checks {
+			out[ch.Name] = ch.Status
+		}
+		return out
+	}
+
+	// Без БД и без необязательной ведомости сервер готов, но с предупреждениями
+	r := c.Run(context.Background())
+	got := status(r)
+	if !r.Ready || r.Status != StatusWarn {
+		t.Errorf("ожидалась готовность со статусом warn, получено ready=%v status=%s", r.Ready, r.Status)
+	}
+	for name, want := range map[string]string{
+		"database":            StatusWarn,
+		"snapshot:attendance": StatusOK,
+		"snapshot:statement":  StatusWarn,
+		"input:attendance":    StatusWarn,
+	} {
+		if got[name] != want {
+			t.Errorf("%s: ожидался %s, получено %q", name, want, got[name])
+		}
+	}
+
+	// Устаревший обязательный снимок снимает готовность
+	if err := os.Chtimes(attendance, old, old); err != nil {
+		t.Fatal(err)
+	}
+	r = c.Run(context.Background())
+	if r.Ready || status(r)["snapshot:attendance"] != StatusFail {
+		t.Errorf("устаревший снимок должен снимать готовность: %+v", r.Checks)
+	}
+
+	// Порог меняется без пересоздания; ноль отключает проверку
+	c.SetThresholds(Thresholds{})
+	if r = c.Run(context.Background()); !r.Ready {
+		t.Errorf("с отключёнными порогами ожидалась готовность: %+v", r.Checks)
+	}
+
+	// Подключение к заданной БД не удалось
+	c = NewChecker(Config{DatabaseConfigured: true}, Thresholds{})
+	if r = c.Run(context.Background()); r.Ready || status(r)["database"] != StatusFail {
+		t.Errorf("без подключения к БД ожидался fail: %+v", r.Checks)
+	}
+}
+
+func TestSnapshotAgeFromLastSuccess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "attendance.json")
+	if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+	os.Chtimes(path, old, old)
+
+	// Вход не менялся, конвертация пропущена, но обновление прошло успешно
+	f := File{Name: "attendance", Path: path, Required: true}
+	if ch := snapshotCheck(f, now.Add(-time.Minute), time.Hour, now); ch.Status != StatusOK {
+		t.Errorf("после успешного обновления ожидался ok, получено %s: %s", ch.Status, ch.Message)
+	}
+	if ch := snapshotCheck(f, time.Time{}, time.Hour, now); ch.Status != StatusFail {
+		t.Errorf("без успешных обновлений ожидался fail по времени файла, получено %s", ch.Status)
+	}
+}
@@ -0,0 +1,141 @@
+// Package logging настраивает структурированные логи (log/slog): уровень,
+// формат JSON или текст, ID запроса, задачи обновления и трассировки из контекста.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// level - уровень логов, меняется без перезапуска (SIGHUP)
+var level = new(slog.LevelVar)
+
+// Setup устанавливает логгер по умолчанию. Вывод стандартного пакета log
+// тоже идёт через него (уровень INFO).
+func Setup(w io.Writer, lvl, format string) error {
+	if err := SetLevel(lvl); err != nil {
+		return err
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("неизвестный формат логов %q: ожидается json или text", format)
+	}
+	slog.SetDefault(slog.New(contextHandler{h}))
+	return nil
+}
+
+// ParseLevel разбирает уровень логов: debug, info, warn, error
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return l, fmt.Errorf("неизвестный уровень логов %q: ожидается debug, info, warn или error", s)
+	}
+	return l, nil
+}
+
+// SetLevel меняет уровень логов
+func SetLevel(s string) error {
+	l, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	jobIDKey
+)
+
+// WithRequestID добавляет в контекст ID HTTP-запроса
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID возвращает ID HTTP-запроса из контекста
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithJobID добавляет в контекст ID задачи обновления
+func WithJobID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, jobIDKey, id)
+}
+
+// JobID возвращает ID задачи обновления из контекста
+func JobID(ctx context.Context) string {
+	id, _ := ctx.Value(jobIDKey).(string)
+	return id
+}
+
+// contextHandler добавляет к записи request_id, job_id и trace_id из контекста
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if id := JobID(ctx); id != "" {
+			r.AddAttrs(slog.String("job_id", id))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// For возвращает логгер компонента (атрибут component). Логгер обращается
+// к логгеру по умолчанию в момент записи, поэтому его можно создать
+// в переменной пакета до вызова Setup.
+func For(component string) *slog.Logger {
+	return slog.New(deferredHandler{attrs: []slog.Attr{slog.String("component", component)}})
+}
+
+// deferredHandler передаёт записи текущему обработчику slog.Default()
+type deferredHandler struct {
+	attrs []slog.Attr
+}
+
+func (h deferredHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return slog.Default().Handler().Enabled(ctx, l)
+}
+
+func (h deferredHandler) Handle(ctx context.Context, r slog.Record) error {
+	return slog.Default().Handler().WithAttrs(h.attrs).Handle(ctx, r)
+}
+
+func (h deferredHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return deferredHandler{attrs: append(append([]slog.Attr{}, h.attrs...), attrs...)}
+}
+
+// WithGroup фиксирует текущий обработчик: группы в проекте не используются
+func (h deferredHandler) WithGroup(name string) slog.Handler {
+	return slog.Default().Handler().WithAttrs(h.attrs).WithGroup(name)
+}
@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestContextAttrs(t *testing.T) {
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+
+	var buf bytes.Buffer
+	if err := Setup(&buf, "info", "json"); err != nil {
+		t.Fatal(err)
+	}
+	logger := For("scheduler")
+
+	ctx := WithJobID(WithRequestID(context.Background(), "req-1"), "job-7")
+	logger.InfoContext(ctx, "Задача поставлена в очередь", "trigger", "manual")
+	logger.Debug("не должно попасть в лог")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("ожидалась одна JSON-запись, получено %q: %v", buf.String(), err)
+	}
+	for key, want := range map[string]string{
+		"level":      "INFO",
+		"component":  "scheduler",
+		"request_id": "req-1",
+		"job_id":     "job-7",
+		"trigger":    "manual",
+	} {
+		if rec[key] != want {
+			t.Errorf("%s = %v, ожидалось %q", key, rec[key], want)
+		}
+	}
+
+	// Уровень меняется без пересоздания логгеров
+	buf.Reset()
+	if err := SetLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("отладка")
+	if buf.Len() == 0 {
+		t.Error("после SetLevel(debug) запись DEBUG не попала в лог")
+	}
+	if err := SetLevel("verbose"); err == nil {
+		t.Error("ожидалась ошибка для неизвестного уровня")
+	}
+	SetLevel("info")
+}
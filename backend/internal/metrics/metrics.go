@@ -0,0 +1,159 @@
+// Package metrics собирает метрики сервера в формате Prometheus:
+// HTTP-запросы, задачи обновления, строки конвертеров, свежесть данных,
+// алерты и пул соединений с БД.
+package metrics
+
+import (
+	"database/sql"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "dashboard"
+
+// Результаты обработки строк конвертером
+const (
+	RowsImported = "imported"
+	RowsSkipped  = "skipped"
+	RowsFailed   = "failed"
+)
+
+// registry - собственный реестр вместо глобального, чтобы в /metrics
+// попадали только метрики сервера, Go runtime и процесса
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP-запросы по методу, маршруту и коду ответа.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Время обработки HTTP-запросов.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	refreshJobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "refresh_jobs_total",
+		Help:      "Завершённые задачи обновления по источнику запуска и статусу.",
+	}, []string{"trigger", "status"})
+
+	refreshDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "refresh_job_duration_seconds",
+		Help:      "Длительность задач обновления.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200},
+	}, []string{"status"})
+
+	refreshStageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "refresh_stage_duration_seconds",
+		Help:      "Длительность этапов обновления: конвертация и загрузка в БД.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300, 600},
+	}, []string{"stage", "status"})
+
+	converterRows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "converter_rows_total",
+		Help:      "Строки листов Excel по конвертеру и результату (imported, skipped, failed).",
+	}, []string{"converter", "result"})
+
+	alertsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alerts_created_total",
+		Help:      "Созданные алерты по правилу и важности.",
+	}, []string{"rule", "severity"})
+
+	// Время последнего успешного импорта (UnixNano), 0 - ещё не было
+	lastImport atomic.Int64
+	startedAt  = time.Now()
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		refreshJobs,
+		refreshDuration,
+		refreshStageDuration,
+		converterRows,
+		alertsCreated,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "data_age_seconds",
+			Help:      "Секунды с последнего успешного импорта данных (до первого импорта - с запуска сервера).",
+		}, dataAge),
+	)
+}
+
+// dataAge считает возраст данных. До первого импорта отсчёт идёт от запуска
+// сервера: так алерт на устаревшие данные сработает и при неудачном старте.
+func dataAge() float64 {
+	since := startedAt
+	if ns := lastImport.Load(); ns != 0 {
+		since = time.Unix(0, ns)
+	}
+	return math.Max(0, time.Since(since).Seconds())
+}
+
+// ObserveHTTP учитывает обработанный HTTP-запрос. route - шаблон маршрута
+// (/api/alerts/:id), а не фактический путь, чтобы не плодить серии.
+func ObserveHTTP(method, route string, status int, d time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+// ObserveRefresh учитывает завершённую задачу обновления
+func ObserveRefresh(trigger, status string, d time.Duration) {
+	refreshJobs.WithLabelValues(trigger, status).Inc()
+	refreshDuration.WithLabelValues(status).Observe(d.Seconds())
+}
+
+// ObserveStage учитывает завершённый этап задачи обновления
+func ObserveStage(stage, status string, d time.Duration) {
+	refreshStageDuration.WithLabelValues(stage, status).Observe(d.Seconds())
+}
+
+// ObserveRows учитывает строки, обработанные конвертером
+func ObserveRows(converter string, imported, skipped, failed int) {
+	converterRows.WithLabelValues(converter, RowsImported).Add(float64(imported))
+	converterRows.WithLabelValues(converter, RowsSkipped).Add(float64(skipped))
+	converterRows.WithLabelValues(converter, RowsFailed).Add(float64(failed))
+}
+
+// MarkImported запоминает время успешного импорта данных
+func MarkImported(t time.Time) {
+	lastImport.Store(t.UnixNano())
+}
+
+// AlertCreated учитывает новый алерт
+func AlertCreated(rule, severity string) {
+	alertsCreated.WithLabelValues(rule, severity).Inc()
+}
+
+// RegisterDB добавляет статистику пула соединений с БД
+// (go_sql_open_connections{db_name="dashboard"}, go_sql_wait_count_total и др.)
+func RegisterDB(db *sql.DB) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// Handler возвращает обработчик /metrics в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"dashboard/internal/auth"
+	"dashboard/internal/database"
+	"dashboard/internal/logging"
+	"dashboard/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var authLog = logging.For("auth")
+
+// APIKeyHeader - заголовок с ключом API. Ключ можно передать и как Bearer-токен.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyTouchInterval - как часто записывать время последнего использования ключа
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyStore - хранилище ключей API
+type APIKeyStore interface {
+	GetByPrefix(prefix string) (models.APIKey, error)
+	TouchUsed(id int, ip string) error
+}
+
+// APIKeyOrJWT принимает ключ API (X-API-Key или Authorization: Bearer dk_...),
+// остальные запросы передаёт в jwtAuth. Ключи дают только чтение: GET-запросы
+// к разделам из областей ключа, с данными его отделений.
+func APIKeyOrJWT(store APIKeyStore, jwtAuth gin.HandlerFunc) gin.HandlerFunc {
+	var mu sync.Mutex
+	touched := make(map[int]time.Time)
+
+	return func(c *gin.Context) {
+		key := apiKeyFromRequest(c)
+		if key == "" {
+			jwtAuth(c)
+			return
+		}
+
+		prefix, ok := auth.ParseAPIKey(key)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			return
+		}
+		k, err := store.GetByPrefix(prefix)
+		if err != nil && !errors.Is(err, database.ErrNotFound) {
+			authLog.ErrorContext(c.Request.Context(), "Ошибка проверки ключа API", "error", err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Cannot verify API key"})
+			return
+		}
+		if err != nil || !auth.CheckAPIKey(key, k.KeyHash) || !k.Active(time.Now()) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			return
+		}
+
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API keys are read-only"})
+			return
+		}
+		if scope := apiKeyScope(c.FullPath()); scope == "" || !k.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key scope does not allow this endpoint"})
+			return
+		}
+
+		mu.Lock()
+		touch := time.Since(touched[k.ID]) >= apiKeyTouchInterval
+		if touch {
+			touched[k.ID] = time.Now()
+		}
+		mu.Unlock()
+		if touch {
+			if err := store.TouchUsed(k.ID, c.ClientIP()); err != nil {
+				authLog.WarnContext(c.Request.Context(), "Не удалось отметить использование ключа API", "key_id", k.ID, "error", err)
+			}
+		}
+
+		// Ключ действует как наблюдатель, ограниченный своими отделениями
+		c.Set("role", models.RoleViewer)
+		c.Set("username", "apikey:"+k.Name)
+		c.Set("api_key_id", k.ID)
+		c.Set("scope", k.Scope())
+		c.Next()
+	}
+}
+
+// apiKeyFromRequest достаёт ключ API из заголовков запроса
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && strings.HasPrefix(token, auth.APIKeyPrefix) {
+		return token
+	}
+	return ""
+}
+
+// apiKeyScope определяет область доступа, нужную для маршрута
+func apiKeyScope(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/api/attendance"):
+		return models.APIScopeAttendance
+	case strings.HasPrefix(path, "/api/alerts"):
+		return models.APIScopeAlerts
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"dashboard/internal/audit"
+	"dashboard/internal/models"
+)
+
+// Ключи контекста, через которые handler уточняет запись аудита
+const (
+	auditTargetKey = "audit_target"
+	auditBeforeKey = "audit_before"
+	auditAfterKey  = "audit_after"
+)
+
+// Audit записывает в журнал каждый изменяющий запрос (не GET/HEAD/OPTIONS):
+// кто, с какого адреса, какой маршрут и с каким результатом. Подключается
+// после авторизации, чтобы в контексте были пользователь и роль.
+func Audit(rec *audit.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+		target := c.GetString(auditTargetKey)
+		if target == "" {
+			target = routeParams(c)
+		}
+		e := models.AuditEvent{
+			Actor:  c.GetString("username"),
+			Role:   c.GetString("role"),
+			IP:     c.ClientIP(),
+			Action: c.Request.Method + " " + path,
+			Target: target,
+			Status: c.Writer.Status(),
+		}
+		if v, ok := c.Get(auditBeforeKey); ok {
+			e.Before = audit.Payload(v)
+		}
+		if v, ok := c.Get(auditAfterKey); ok {
+			e.After = audit.Payload(v)
+		}
+		rec.Record(c.Request.Context(), e)
+	}
+}
+
+// AuditChange сообщает middleware объект изменения и его состояние
+// до и после. nil означает, что состояния нет (создание или удаление).
+func AuditChange(c *gin.Context, target string, before, after interface{}) {
+	c.Set(auditTargetKey, target)
+	if before != nil {
+		c.Set(auditBeforeKey, before)
+	}
+	if after != nil {
+		c.Set(auditAfterKey, after)
+	}
+}
+
+// routeParams описывает объект запроса параметрами маршрута: "id=5 sid=..."
+func routeParams(c *gin.Context) string {
+	parts := make([]string, 0, len(c.Params))
+	for _, p := range c.Params {
+		parts = append(parts, p.Key+"="+p.Value)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dashboard/internal/audit"
+	"dashboard/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// memoryAudit хранит журнал в памяти с той же цепочкой хэшей, что и БД
+type memoryAudit struct {
+	events []models.AuditEvent
+}
+
+func (m *memoryAudit) Available() bool { return true }
+
+func (m *memoryAudit) Append(e models.AuditEvent) (models.AuditEvent, error) {
+	if n := len(m.events); n > 0 {
+		e.PrevHash = m.events[n-1].Hash
+	}
+	e.ID = int64(len(m.events) + 1)
+	e.Hash = e.ChainHash()
+	m.events = append(m.events, e)
+	return e, nil
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &memoryAudit{}
+
+	router := gin.New()
+	api := router.Group("/api", func(c *gin.Context) {
+		c.Set("username", "admin")
+		c.Set("role", models.RoleAdmin)
+	}, Audit(audit.NewRecorder(store)))
+	api.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.PUT("/users/:id/role", func(c *gin.Context) {
+		AuditChange(c, "user:ivanov", gin.H{"role": "viewer"}, gin.H{"role": "curator"})
+		c.Status(http.StatusOK)
+	})
+	api.DELETE("/users/:id/sessions", func(c *gin.Context) { c.Status(http.StatusForbidden) })
+
+	for _, r := range []struct{ method, path string }{
+		{http.MethodGet, "/api/users/5"},
+		{http.MethodPut, "/api/users/5/role"},
+		{http.MethodDelete, "/api/users/7/sessions"},
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(r.method, r.path, nil))
+	}
+
+	if len(store.events) != 2 {
+		t.Fatalf("ожидалось 2 записи (чтение не пишется), получено %d", len(store.events))
+	}
+	e := store.events[0]
+	if e.Actor != "admin" || e.Action != "PUT /api/users/:id/role" || e.Target != "user:ivanov" || e.Status != http.StatusOK {
+		t.Errorf("неожиданная запись: %+v", e)
+	}
+	if string(e.Before) != `{"role":"viewer"}` || string(e.After) != `{"role":"curator"}` {
+		t.Errorf("неверные данные до/после: %s %s", e.Before, e.After)
+	}
+	// Отказ тоже фиксируется, объект - из параметров маршрута
+	if e := store.events[1]; e.Target != "id=7" || e.Status != http.StatusForbidden {
+		t.Errorf("неожиданная запись отказа: %+v", e)
+	}
+
+	// Цепочка: вторая запись ссылается на первую, правка задним числом видна
+	if store.events[1].PrevHash != e.Hash {
+		t.Error("запись должна ссылаться на хэш предыдущей")
+	}
+	e.Target = "user:petrov"
+	if e.ChainHash() == store.events[0].Hash {
+		t.Error("изменённая запись не должна совпадать со своим хэшем")
+	}
+}
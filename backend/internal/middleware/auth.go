@@ -1,66 +1,130 @@
 package middleware
 
 import (
+	"encoding/json"
 	"net/http"
 	"strings"
 	"time"
 
+	"dashboard/internal/auth"
+	"dashboard/internal/models"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 const roleContextKey = "role"
 
-// JWTAuth middleware для проверки JWT токена
-func JWTAuth(secret string) gin.HandlerFunc {
+// Revoker сообщает, отозван ли сеанс, к которому относится токен
+type Revoker interface {
+	IsRevoked(sessionID string) bool
+}
+
+// JWTAuth middleware для проверки JWT токена. Подпись, алгоритм, kid,
+// iss/aud и сроки проверяет набор ключей. Если передан revoker,
+// токены завершённых сеансов (logout, блокировка) отклоняются.
+func JWTAuth(keys *auth.KeySet, revoker Revoker) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		auth := c.GetHeader("Authorization")
+		header := c.GetHeader("Authorization")
 		const prefix = "Bearer "
-		if len(auth) < len(prefix) || !strings.HasPrefix(auth, prefix) {
+		if len(header) < len(prefix) || !strings.HasPrefix(header, prefix) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid Authorization header"})
 			c.Abort()
 			return
 		}
 
-		tokenStr := auth[len(prefix):]
-		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := keys.Parse(header[len(prefix):])
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		role, ok := claims["role"].(string)
+		if !ok || role == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token role"})
 			c.Abort()
 			return
 		}
 
-		role, ok := claims["role"].(string)
-		if !ok || role == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token role"})
+		sid, _ := claims["sid"].(string)
+		if sid != "" && revoker != nil && revoker.IsRevoked(sid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token revoked"})
 			c.Abort()
 			return
 		}
 
-		// Сохраняем роль в контекст
+		// Сохраняем роль и пользователя в контекст.
+		// У токенов входа по LOGIN_USER (без БД) uid равен 0.
 		c.Set("role", role)
+		if username, ok := claims["sub"].(string); ok {
+			c.Set("username", username)
+		}
+		if uid, ok := claims["uid"].(float64); ok {
+			c.Set("uid", int(uid))
+		}
+		c.Set("scope", scopeFromClaims(claims, role))
+		if sid != "" {
+			c.Set("sid", sid)
+		}
+		c.Next()
+	}
+}
+
+// TokenFromQuery переносит токен из параметра access_token в заголовок Authorization.
+// Нужен для EventSource в браузере, который не умеет передавать заголовки.
+func TokenFromQuery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
 		c.Next()
 	}
 }
 
-// IssueJWT создаёт JWT токен
-func IssueJWT(secret, role string) (string, error) {
+// IssueJWT создаёт access-токен пользователя на время ttl. Область видимости
+// (отделения и группы) встраивается в токен, sid связывает его с сеансом.
+func IssueJWT(keys *auth.KeySet, user models.User, sessionID string, ttl time.Duration) (string, error) {
 	now := time.Now()
 	claims := jwt.MapClaims{
-		"role": role,
-		"iat":  now.Unix(),
-		"exp":  now.Add(24 * time.Hour).Unix(),
+		"uid":   user.ID,
+		"sub":   user.Username,
+		"role":  user.Role,
+		"scope": user.Scope(),
+		"iat":   now.Unix(),
+		"nbf":   now.Unix(),
+		"exp":   now.Add(ttl).Unix(),
+	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
+	return keys.Sign(claims)
+}
+
+// scopeFromClaims читает область видимости из токена. В токенах,
+// выпущенных до появления областей, её нет - тогда она выводится из роли.
+func scopeFromClaims(claims jwt.MapClaims, role string) models.Scope {
+	raw, ok := claims["scope"]
+	if !ok {
+		return models.ScopeFor(role, nil, nil)
+	}
+	var scope models.Scope
+	data, err := json.Marshal(raw)
+	if err != nil || json.Unmarshal(data, &scope) != nil {
+		return models.Scope{}
+	}
+	return scope
+}
+
+// ScopeFromContext возвращает область видимости текущего пользователя.
+// Без JWTAuth в цепочке доступ не выдаётся.
+func ScopeFromContext(c *gin.Context) models.Scope {
+	if v, ok := c.Get("scope"); ok {
+		if scope, ok := v.(models.Scope); ok {
+			return scope
+		}
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return models.Scope{}
 }
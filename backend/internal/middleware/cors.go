@@ -1,17 +1,32 @@
 package middleware
 
 import (
+	"sync/atomic"
+	"time"
+
+	"dashboard/internal/origin"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-// SetupCORS настраивает CORS middleware
-func SetupCORS() gin.HandlerFunc {
-	return cors.New(cors.Config{
-		AllowOrigins: []string{
-			"http://localhost:5173", // Vite dev server
-			"http://localhost:3000", // React dev server
-			"http://localhost:8080", // Тот же сервер
+// CORS - middleware CORS со списком адресов, который можно заменить
+// без перезапуска (перезагрузка конфигурации по SIGHUP)
+type CORS struct {
+	origins atomic.Pointer[origin.Matcher]
+	handler gin.HandlerFunc
+}
+
+// NewCORS создаёт middleware CORS. Пустой список адресов разрешает
+// только запросы с того же адреса, что и API.
+func NewCORS(origins []string, allowCredentials bool, maxAge time.Duration) (*CORS, error) {
+	c := &CORS{}
+	if err := c.SetOrigins(origins); err != nil {
+		return nil, err
+	}
+	c.handler = cors.New(cors.Config{
+		AllowOriginFunc: func(o string) bool {
+			return c.origins.Load().Allows(o)
 		},
 		AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders: []string{
@@ -20,9 +35,27 @@ func SetupCORS() gin.HandlerFunc {
 			"Accept",
 			"Authorization",
 			"X-Requested-With",
+			"X-API-Key",
+			"X-Request-ID",
 		},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * 3600, // 12 часов
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "Retry-After", "X-Request-ID"},
+		AllowCredentials: allowCredentials,
+		MaxAge:           maxAge,
 	})
+	return c, nil
+}
+
+// SetOrigins заменяет список разрешённых адресов
+func (c *CORS) SetOrigins(origins []string) error {
+	m, err := origin.Parse(origins)
+	if err != nil {
+		return err
+	}
+	c.origins.Store(m)
+	return nil
+}
+
+// Handler возвращает gin middleware
+func (c *CORS) Handler() gin.HandlerFunc {
+	return c.handler
 }
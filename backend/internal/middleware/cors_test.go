@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSReload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policy, err := NewCORS([]string{"https://a.example.edu"}, true, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.Use(policy.Handler())
+	r.GET("/api/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("https://a.example.edu")
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "https://a.example.edu" {
+		t.Errorf("разрешённый адрес: код %d, заголовок %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if w := request("https://b.example.edu"); w.Code != http.StatusForbidden {
+		t.Errorf("чужой адрес должен получать 403, получено %d", w.Code)
+	}
+
+	if err := policy.SetOrigins([]string{"https://b.example.edu"}); err != nil {
+		t.Fatal(err)
+	}
+	if w := request("https://b.example.edu"); w.Code != http.StatusOK {
+		t.Errorf("после перезагрузки адрес должен быть разрешён, получено %d", w.Code)
+	}
+	if w := request("https://a.example.edu"); w.Code != http.StatusForbidden {
+		t.Errorf("после перезагрузки старый адрес должен быть запрещён, получено %d", w.Code)
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	headers := NewSecurityHeaders(SecurityPolicy{
+		CSP:            "default-src 'none'",
+		HSTS:           "max-age=31536000",
+		FrameOptions:   "deny",
+		ReferrerPolicy: "off",
+	})
+	r := gin.New()
+	r.Use(headers.Handler())
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	for name, want := range map[string]string{
+		"X-Content-Type-Options":    "nosniff",
+		"Content-Security-Policy":   "default-src 'none'",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "",
+		"Strict-Transport-Security": "",
+	} {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("%s: получено %q, ожидалось %q", name, got, want)
+		}
+	}
+
+	req.Header.Set("X-Forwarded-Proto", "https")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Header().Get("Strict-Transport-Security") != "max-age=31536000" {
+		t.Error("за HTTPS-прокси должен отправляться HSTS")
+	}
+}
@@ -1,13 +1,53 @@
 package middleware
 
 import (
-	"log"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
+	"dashboard/internal/logging"
+
 	"github.com/gin-gonic/gin"
 )
 
-// Logger middleware для логирования HTTP запросов
+// RequestIDHeader - заголовок с ID запроса
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID ограничивает ID, пришедший от клиента или прокси:
+// произвольная строка не должна попадать в логи как есть
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+var httpLog = logging.For("http")
+
+// RequestID берёт ID запроса из X-Request-ID (если он корректен) или
+// генерирует новый, кладёт его в контекст запроса и возвращает в ответе.
+// Логи с контекстом запроса получают атрибут request_id.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// newRequestID генерирует случайный ID запроса
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Logger middleware для логирования HTTP запросов: одна запись на запрос,
+// 5xx - ERROR, 4xx - WARN, остальное - INFO
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -18,21 +58,47 @@ func Logger() gin.HandlerFunc {
 		c.Next()
 
 		// Логируем после обработки
-		latency := time.Since(start)
-		clientIP := c.ClientIP()
-		method := c.Request.Method
-		statusCode := c.Writer.Status()
-
 		if raw != "" {
-			path = path + "?" + raw
+			path = path + "?" + redactQuery(raw)
+		}
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.Int("status", status),
+			slog.String("method", c.Request.Method),
+			slog.String("path", path),
+			slog.String("route", c.FullPath()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
 		}
+		if user := c.GetString("username"); user != "" {
+			attrs = append(attrs, slog.String("user", user))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		httpLog.LogAttrs(c.Request.Context(), level, "HTTP запрос", attrs...)
+	}
+}
 
-		log.Printf("[HTTP] %3d | %13v | %15s | %-7s %s",
-			statusCode,
-			latency,
-			clientIP,
-			method,
-			path,
-		)
+// redactQuery скрывает токены в строке запроса, чтобы они не попадали в логи.
+// Пары разбираются по одной: url.ParseQuery при ошибке в одной паре всё равно
+// возвращает остальные, и токен из такой строки принимается при входе.
+func redactQuery(raw string) string {
+	pairs := strings.Split(raw, "&")
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if k, err := url.QueryUnescape(key); err == nil && k == "access_token" {
+			pairs[i] = key + "=***"
+		}
 	}
+	return strings.Join(pairs, "&")
 }
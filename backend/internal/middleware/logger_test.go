@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dashboard/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, logging.RequestID(c.Request.Context()))
+	})
+
+	serve := func(header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set(RequestIDHeader, header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// ID от прокси сохраняется
+	w := serve("abc-123")
+	if got := w.Header().Get(RequestIDHeader); got != "abc-123" || w.Body.String() != "abc-123" {
+		t.Errorf("ожидался ID abc-123, заголовок %q, контекст %q", got, w.Body.String())
+	}
+
+	// Без заголовка и с некорректным заголовком генерируется новый ID
+	for _, header := range []string{"", "bad id\nINFO fake"} {
+		w := serve(header)
+		got := w.Header().Get(RequestIDHeader)
+		if got == "" || got == header || w.Body.String() != got {
+			t.Errorf("заголовок %q: ожидался новый ID, заголовок %q, контекст %q", header, got, w.Body.String())
+		}
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	for raw, want := range map[string]string{
+		"access_token=SECRET.JWT":              "access_token=***",
+		"x=1&access_token=SECRET.JWT":          "x=1&access_token=***",
+		"access_token=SECRET.JWT&x=%zz":        "access_token=***&x=%zz",
+		"access%5Ftoken=SECRET.JWT;y&z=%zz":    "access%5Ftoken=***&z=%zz",
+		"department=%D0%9E%D1%82%D0%B4&page=2": "department=%D0%9E%D1%82%D0%B4&page=2",
+	} {
+		got := redactQuery(raw)
+		if got != want {
+			t.Errorf("%q: получено %q, ожидалось %q", raw, got, want)
+		}
+		if strings.Contains(got, "SECRET") {
+			t.Errorf("%q: токен попал в лог: %q", raw, got)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"dashboard/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics учитывает HTTP-запросы в метриках Prometheus: число запросов
+// по маршруту и коду ответа и гистограмму времени обработки
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		metrics.ObserveHTTP(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
+
+// StaticToken проверяет заголовок "Authorization: Bearer <token>" для
+// служебных эндпоинтов (например, /metrics для Prometheus).
+// Пустой token отключает проверку.
+func StaticToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+		got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid metrics token"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dashboard/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Metrics())
+	r.GET("/api/alerts/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/metrics", StaticToken("secret"), gin.WrapH(metrics.Handler()))
+
+	serve := func(path, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	serve("/api/alerts/1", "")
+	serve("/api/alerts/2", "")
+
+	if w := serve("/metrics", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("без токена ожидался 401, получено %d", w.Code)
+	}
+	if w := serve("/metrics", "Bearer wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("с неверным токеном ожидался 401, получено %d", w.Code)
+	}
+
+	w := serve("/metrics", "Bearer secret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("с токеном ожидался 200, получено %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		// Серии по шаблону маршрута, а не по фактическому пути
+		`dashboard_http_requests_total{method="GET",route="/api/alerts/:id",status="200"} 2`,
+		`dashboard_http_request_duration_seconds_count{method="GET",route="/api/alerts/:id"} 2`,
+		"dashboard_data_age_seconds",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("в /metrics нет %q", want)
+		}
+	}
+}
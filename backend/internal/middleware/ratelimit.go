@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dashboard/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit ограничивает частоту запросов по ключу, который возвращает key.
+// При превышении квоты отвечает 429 с заголовком Retry-After.
+func RateLimit(l *ratelimit.Limiter, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ok, wait := l.Allow(key(c)); !ok {
+			TooManyRequests(c, wait)
+			return
+		}
+		c.Next()
+	}
+}
+
+// TokenKey - ключ квоты для защищённых эндпоинтов: ключ API, сеанс,
+// пользователь или, в крайнем случае, адрес клиента
+func TokenKey(c *gin.Context) string {
+	if id, ok := c.Get("api_key_id"); ok {
+		return fmt.Sprintf("apikey:%v", id)
+	}
+	if sid := c.GetString("sid"); sid != "" {
+		return "sid:" + sid
+	}
+	if username := c.GetString("username"); username != "" {
+		return "user:" + username
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// TooManyRequests прерывает запрос ответом 429 с Retry-After в секундах
+func TooManyRequests(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests", "retry_after": seconds})
+}
@@ -1,8 +1,9 @@
 package middleware
 
 import (
-	"log"
+	"fmt"
 	"net/http"
+	"runtime/debug"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,7 +11,8 @@ import (
 // Recovery middleware для обработки паник
 func Recovery() gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		log.Printf("[Recovery] Паника: %v", recovered)
+		httpLog.ErrorContext(c.Request.Context(), "Паника при обработке запроса",
+			"panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Внутренняя ошибка сервера",
 			"message": "Произошла непредвиденная ошибка",
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityPolicy значения заголовков безопасности.
+// Пустое значение или "off" - заголовок не отправляется.
+type SecurityPolicy struct {
+	CSP            string // Content-Security-Policy
+	HSTS           string // Strict-Transport-Security, только для HTTPS
+	FrameOptions   string // X-Frame-Options
+	ReferrerPolicy string // Referrer-Policy
+}
+
+// SecurityHeaders - middleware заголовков безопасности. Политику можно
+// заменить без перезапуска (перезагрузка конфигурации по SIGHUP).
+type SecurityHeaders struct {
+	policy atomic.Pointer[SecurityPolicy]
+}
+
+// NewSecurityHeaders создаёт middleware заголовков безопасности
+func NewSecurityHeaders(p SecurityPolicy) *SecurityHeaders {
+	s := &SecurityHeaders{}
+	s.SetPolicy(p)
+	return s
+}
+
+// SetPolicy заменяет значения заголовков
+func (s *SecurityHeaders) SetPolicy(p SecurityPolicy) {
+	s.policy.Store(&p)
+}
+
+// Handler возвращает gin middleware. Handler маршрута может заменить
+// заголовок (например, ослабить CSP для страницы Swagger).
+func (s *SecurityHeaders) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := s.policy.Load()
+		h := c.Writer.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		set := func(name, value string) {
+			if value != "" && !strings.EqualFold(value, "off") {
+				h.Set(name, value)
+			}
+		}
+		set("Content-Security-Policy", p.CSP)
+		set("X-Frame-Options", strings.ToUpper(p.FrameOptions))
+		set("Referrer-Policy", p.ReferrerPolicy)
+		// По HTTP браузеры HSTS игнорируют; за прокси смотрим X-Forwarded-Proto
+		if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+			set("Strict-Transport-Security", p.HSTS)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	prevProvider, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	defer func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevProp)
+	}()
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID(), Tracing())
+	r.GET("/api/alerts/:id", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	req := httptest.NewRequest(http.MethodGet, "/api/alerts/7", nil)
+	// Запрос пришёл через прокси с трассировкой
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("ожидался 1 спан, получено %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /api/alerts/:id" {
+		t.Errorf("имя спана %q, ожидалось по шаблону маршрута", span.Name())
+	}
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("трассировка из traceparent не продолжена: %s", got)
+	}
+	if span.Status().Code != codes.Error {
+		t.Error("ответ 5xx должен отмечать спан как ошибочный")
+	}
+	attrs := attribute.NewSet(span.Attributes()...)
+	if v, ok := attrs.Value("http.response.status_code"); !ok || v.AsInt64() != 500 {
+		t.Errorf("нет кода ответа в атрибутах: %v", span.Attributes())
+	}
+	if _, ok := attrs.Value("request_id"); !ok {
+		t.Error("нет request_id в атрибутах спана")
+	}
+}
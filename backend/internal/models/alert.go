@@ -0,0 +1,115 @@
+package models
+
+import "time"
+
+// Статусы алерта
+const (
+	AlertOpen         = "open"
+	AlertAcknowledged = "acknowledged"
+	AlertResolved     = "resolved"
+)
+
+// Уровни важности алерта
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Субъекты алерта
+const (
+	SubjectStudent    = "student"
+	SubjectGroup      = "group"
+	SubjectDepartment = "department"
+)
+
+// Alert модель алерта о превышении порога пропусков
+type Alert struct {
+	ID             int        `json:"id" db:"id"`
+	Rule           string     `json:"rule" db:"rule"`
+	Fingerprint    string     `json:"-" db:"fingerprint"`
+	Severity       string     `json:"severity" db:"severity"`
+	SubjectType    string     `json:"subject_type" db:"subject_type"`
+	Department     string     `json:"department" db:"department"`
+	Group          string     `json:"group,omitempty" db:"group_name"`
+	Student        string     `json:"student,omitempty" db:"student"`
+	MetricValue    float64    `json:"metric_value" db:"metric_value"`
+	Threshold      float64    `json:"threshold" db:"threshold"`
+	Message        string     `json:"message" db:"message"`
+	Status         string     `json:"status" db:"status"`
+	FirstSeenAt    time.Time  `json:"first_seen_at" db:"first_seen_at"`
+	LastSeenAt     time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty" db:"acknowledged_by"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+	ResolvedBy     string     `json:"resolved_by,omitempty" db:"resolved_by"`
+}
+
+// MakeFingerprint формирует ключ, по которому повторные срабатывания
+// одного правила для одного субъекта склеиваются в один алерт
+func (a *Alert) MakeFingerprint() string {
+	return a.Rule + "|" + a.SubjectType + "|" + a.Department + "|" + a.Group + "|" + a.Student
+}
+
+// Типы правил алертов
+const (
+	// RuleTypeGroupAverage - среднее число пропущенных часов на студента в группе
+	RuleTypeGroupAverage = "group_average"
+	// RuleTypeStudentHours - пропущенные часы студента (за окно window_days или за всё время)
+	RuleTypeStudentHours = "student_hours"
+	// RuleTypeAbsenceStreak - число учебных дней подряд с пропусками
+	RuleTypeAbsenceStreak = "absence_streak"
+	// RuleTypeScheduledPercent - процент пропущенных часов от запланированных за окно
+	RuleTypeScheduledPercent = "scheduled_percent"
+	// RuleTypeUnexcused - часы, пропущенные без уважительной причины (по ведомости)
+	RuleTypeUnexcused = "unexcused"
+)
+
+// RuleOverride переопределяет параметры правила для отделения
+type RuleOverride struct {
+	Enabled           *bool    `json:"enabled,omitempty"`
+	Threshold         *float64 `json:"threshold,omitempty"`
+	CriticalThreshold *float64 `json:"critical_threshold,omitempty"`
+}
+
+// AlertRule декларативное описание правила алерта
+type AlertRule struct {
+	ID          int    `json:"id" db:"id"`
+	Name        string `json:"name" db:"name"`
+	Type        string `json:"type" db:"type"`
+	Description string `json:"description,omitempty"`
+	Enabled     bool   `json:"enabled" db:"enabled"`
+
+	// Threshold - порог срабатывания (уровень warning)
+	Threshold float64 `json:"threshold"`
+	// CriticalThreshold - порог уровня critical, 0 - не используется
+	CriticalThreshold float64 `json:"critical_threshold,omitempty"`
+	// WindowDays - скользящее окно в днях до текущей даты, 0 - все данные
+	WindowDays int `json:"window_days,omitempty"`
+	// ScheduledHoursPerDay - запланировано часов в учебный день (для scheduled_percent)
+	ScheduledHoursPerDay float64 `json:"scheduled_hours_per_day,omitempty"`
+	// FiveDayWeek - суббота не учебный день (для серий и расчёта плана часов)
+	FiveDayWeek bool `json:"five_day_week,omitempty"`
+
+	// Overrides - переопределения по названию отделения
+	Overrides map[string]RuleOverride `json:"overrides,omitempty"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ForDepartment возвращает порог, критический порог и признак включения правила для отделения
+func (r *AlertRule) ForDepartment(department string) (threshold, critical float64, enabled bool) {
+	threshold, critical, enabled = r.Threshold, r.CriticalThreshold, r.Enabled
+	if o, ok := r.Overrides[department]; ok {
+		if o.Enabled != nil {
+			enabled = *o.Enabled
+		}
+		if o.Threshold != nil {
+			threshold = *o.Threshold
+		}
+		if o.CriticalThreshold != nil {
+			critical = *o.CriticalThreshold
+		}
+	}
+	return threshold, critical, enabled
+}
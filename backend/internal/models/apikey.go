@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Области доступа ключей API. Ключи дают только чтение.
+const (
+	APIScopeAttendance = "attendance:read"
+	APIScopeAlerts     = "alerts:read"
+)
+
+// ValidAPIScope проверяет, известна ли область доступа ключа
+func ValidAPIScope(scope string) bool {
+	return scope == APIScopeAttendance || scope == APIScopeAlerts
+}
+
+// APIKey ключ для интеграций (скрипты, выгрузки). Хранится только
+// SHA-256 ключа; Prefix - открытая часть для поиска и отображения.
+type APIKey struct {
+	ID          int        `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	Prefix      string     `json:"prefix" db:"prefix"`
+	KeyHash     string     `json:"-" db:"key_hash"`
+	Scopes      []string   `json:"scopes" db:"scopes"`
+	Departments []string   `json:"departments" db:"departments"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	LastUsedIP  string     `json:"last_used_ip,omitempty" db:"last_used_ip"`
+	CreatedBy   string     `json:"created_by" db:"created_by"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Active сообщает, действует ли ключ в момент now
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// HasScope проверяет, разрешена ли ключу область доступа
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Scope возвращает область видимости данных: отделения ключа или все данные
+func (k *APIKey) Scope() Scope {
+	if len(k.Departments) == 0 {
+		return Scope{All: true}
+	}
+	return Scope{Departments: k.Departments}
+}
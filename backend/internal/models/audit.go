@@ -0,0 +1,61 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Действия журнала аудита, которые пишутся не middleware, а сервисами
+const (
+	AuditLogin       = "auth.login"
+	AuditLoginFailed = "auth.login_failed"
+	AuditLockout     = "auth.lockout"
+)
+
+// AuditEvent запись журнала аудита. Записи только добавляются; каждая
+// содержит хэш предыдущей, поэтому удаление или правка записи задним
+// числом обнаруживается проверкой цепочки.
+type AuditEvent struct {
+	ID       int64           `json:"id" db:"id"`
+	Time     time.Time       `json:"time" db:"created_at"`
+	Actor    string          `json:"actor" db:"actor"`
+	Role     string          `json:"role" db:"role"`
+	IP       string          `json:"ip" db:"ip"`
+	Action   string          `json:"action" db:"action"`
+	Target   string          `json:"target" db:"target"`
+	Status   int             `json:"status" db:"status"` // HTTP-статус, 0 для событий сервисов
+	Before   json.RawMessage `json:"before,omitempty" db:"before_data"`
+	After    json.RawMessage `json:"after,omitempty" db:"after_data"`
+	PrevHash string          `json:"prev_hash" db:"prev_hash"`
+	Hash     string          `json:"hash" db:"hash"`
+}
+
+// ChainHash вычисляет хэш записи вместе с хэшем предыдущей (PrevHash).
+// ID не входит в хэш: последовательность может иметь пропуски.
+func (e AuditEvent) ChainHash() string {
+	fields := []string{
+		e.PrevHash,
+		e.Time.UTC().Format(time.RFC3339Nano),
+		e.Actor,
+		e.Role,
+		e.IP,
+		e.Action,
+		e.Target,
+		strconv.Itoa(e.Status),
+		string(e.Before),
+		string(e.After),
+	}
+	// Длина перед каждым полем исключает подмену границ между полями
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString(strconv.Itoa(len(f)))
+		b.WriteByte(':')
+		b.WriteString(f)
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Виды уведомлений
+const (
+	NotificationAlert  = "alert"
+	NotificationDigest = "digest"
+	NotificationTest   = "test"
+)
+
+// Статусы доставки уведомления
+const (
+	DeliverySent   = "sent"
+	DeliveryFailed = "failed"
+)
+
+// Recipient получатель уведомлений. Пустые Department и Group означают
+// "все отделения" и "все группы" соответственно.
+type Recipient struct {
+	ID         int       `json:"id" db:"id"`
+	Email      string    `json:"email" db:"email"`
+	Name       string    `json:"name" db:"name"`
+	Department string    `json:"department" db:"department"`
+	Group      string    `json:"group" db:"group_name"`
+	Alerts     bool      `json:"alerts" db:"alerts"`
+	Digest     bool      `json:"digest" db:"digest"`
+	Enabled    bool      `json:"enabled" db:"enabled"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Covers проверяет, относится ли отделение и группа к зоне получателя
+func (r *Recipient) Covers(department, group string) bool {
+	if r.Department != "" && r.Department != department {
+		return false
+	}
+	if r.Group != "" && r.Group != group {
+		return false
+	}
+	return true
+}
+
+// Delivery запись журнала доставки уведомлений
+type Delivery struct {
+	ID        int       `json:"id" db:"id"`
+	Kind      string    `json:"kind" db:"kind"`
+	AlertID   *int      `json:"alert_id,omitempty" db:"alert_id"`
+	Recipient string    `json:"recipient" db:"recipient"`
+	Subject   string    `json:"subject" db:"subject"`
+	Status    string    `json:"status" db:"status"`
+	Error     string    `json:"error,omitempty" db:"error"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Session сеанс входа пользователя. Хранит хэш текущего refresh-токена;
+// при каждом обновлении токен заменяется (ротация), а предыдущий хэш
+// запоминается, чтобы распознать повторное использование украденного токена.
+type Session struct {
+	ID           string     `json:"id" db:"id"`
+	UserID       int        `json:"user_id" db:"user_id"`
+	RefreshHash  string     `json:"-" db:"refresh_hash"`
+	PreviousHash string     `json:"-" db:"previous_hash"`
+	UserAgent    string     `json:"user_agent" db:"user_agent"`
+	IP           string     `json:"ip" db:"ip"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt   time.Time  `json:"last_used_at" db:"last_used_at"`
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
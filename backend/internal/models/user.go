@@ -0,0 +1,96 @@
+package models
+
+import "time"
+
+// Роли пользователей. Администратор и наблюдатель видят все данные,
+// остальные роли ограничены своей областью видимости (Scope).
+const (
+	RoleAdmin          = "admin"
+	RoleViewer         = "viewer"
+	RoleDepartmentHead = "department_head"
+	RoleCurator        = "curator"
+	RoleTeacher        = "teacher"
+)
+
+// Источники учётных записей: локальные пароли, каталог LDAP или провайдер OIDC
+const (
+	SourceLocal = "local"
+	SourceLDAP  = "ldap"
+	SourceOIDC  = "oidc"
+)
+
+// ValidRole проверяет, известна ли роль
+func ValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleViewer, RoleDepartmentHead, RoleCurator, RoleTeacher:
+		return true
+	}
+	return false
+}
+
+// ScopedRole сообщает, ограничена ли роль отделениями или группами
+func ScopedRole(role string) bool {
+	return role == RoleDepartmentHead || role == RoleCurator || role == RoleTeacher
+}
+
+// Scope область видимости данных пользователя.
+// Нулевое значение не даёт доступа ни к чему.
+type Scope struct {
+	All         bool     `json:"all,omitempty"`
+	Departments []string `json:"departments,omitempty"`
+	Groups      []string `json:"groups,omitempty"`
+}
+
+// ScopeFor строит область видимости для роли: заведующий видит свои
+// отделения, куратор и преподаватель - свои группы, остальные - всё
+func ScopeFor(role string, departments, groups []string) Scope {
+	switch role {
+	case RoleDepartmentHead:
+		return Scope{Departments: departments}
+	case RoleCurator, RoleTeacher:
+		return Scope{Groups: groups}
+	}
+	return Scope{All: true}
+}
+
+// Allows проверяет, входит ли группа отделения в область видимости
+func (s Scope) Allows(department, group string) bool {
+	if s.All {
+		return true
+	}
+	for _, d := range s.Departments {
+		if d == department {
+			return true
+		}
+	}
+	for _, g := range s.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// User учётная запись сотрудника. RecoveryCodes хранит SHA-256
+// неиспользованных кодов восстановления второго фактора.
+type User struct {
+	ID            int        `json:"id" db:"id"`
+	Username      string     `json:"username" db:"username"`
+	PasswordHash  string     `json:"-" db:"password_hash"`
+	Role          string     `json:"role" db:"role"`
+	Departments   []string   `json:"departments" db:"scope_departments"`
+	Groups        []string   `json:"groups" db:"scope_groups"`
+	Disabled      bool       `json:"disabled" db:"disabled"`
+	Source        string     `json:"source" db:"auth_source"`
+	TOTPEnabled   bool       `json:"totp_enabled" db:"totp_enabled"`
+	TOTPSecret    string     `json:"-" db:"totp_secret"`
+	RecoveryCodes []string   `json:"-" db:"recovery_codes"`
+	LastLoginAt   *time.Time `json:"last_login_at,omitempty" db:"last_login_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Scope возвращает область видимости пользователя по его роли
+func (u *User) Scope() Scope {
+	return ScopeFor(u.Role, u.Departments, u.Groups)
+}
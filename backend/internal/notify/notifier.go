@@ -0,0 +1,218 @@
+package notify
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"dashboard/internal/logging"
+	"dashboard/internal/models"
+)
+
+var logger = logging.For("notify")
+
+// queueSize - сколько алертов может ждать отправки
+const queueSize = 256
+
+// Store - хранилище получателей и журнала доставки
+type Store interface {
+	ListRecipients() ([]models.Recipient, error)
+	WasNotified(alertID int, recipient string) (bool, error)
+	LogDelivery(d models.Delivery) error
+}
+
+// ActiveAlerts возвращает открытые и принятые в работу алерты для сводки
+type ActiveAlerts func() ([]models.Alert, error)
+
+// Notifier рассылает письма об алертах и еженедельные сводки.
+// Письма об алертах отправляются в фоне, чтобы не задерживать обновление данных.
+type Notifier struct {
+	sender       Sender
+	store        Store
+	activeAlerts ActiveAlerts
+	dashboardURL string
+
+	mu     sync.Mutex
+	closed bool
+	queue  chan models.Alert
+	done   chan struct{}
+}
+
+// NewNotifier создаёт рассыльщик уведомлений
+func NewNotifier(sender Sender, store Store, activeAlerts ActiveAlerts, dashboardURL string) *Notifier {
+	return &Notifier{
+		sender:       sender,
+		store:        store,
+		activeAlerts: activeAlerts,
+		dashboardURL: dashboardURL,
+		queue:        make(chan models.Alert, queueSize),
+		done:         make(chan struct{}),
+	}
+}
+
+// Start запускает фоновую отправку писем об алертах
+func (n *Notifier) Start() {
+	go func() {
+		defer close(n.done)
+		for a := range n.queue {
+			if _, err := n.NotifyAlert(a); err != nil {
+				logger.Error("Ошибка уведомления об алерте", "alert_id", a.ID, "error", err)
+			}
+		}
+	}()
+}
+
+// Stop дожидается отправки писем из очереди и останавливает рассылку
+func (n *Notifier) Stop() {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return
+	}
+	n.closed = true
+	close(n.queue)
+	n.mu.Unlock()
+
+	<-n.done
+}
+
+// Enqueue ставит алерт в очередь на отправку. Если очередь заполнена, алерт пропускается.
+func (n *Notifier) Enqueue(a models.Alert) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return
+	}
+	select {
+	case n.queue <- a:
+	default:
+		logger.Warn("Очередь уведомлений заполнена, алерт пропущен", "alert_id", a.ID)
+	}
+}
+
+// NotifyAlert отправляет письмо об алерте всем получателям, в чью зону он попадает.
+// Получатели, которым письмо об этом алерте уже отправлялось, пропускаются.
+func (n *Notifier) NotifyAlert(a models.Alert) (int, error) {
+	recipients, err := n.store.ListRecipients()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	seen := make(map[string]struct{})
+	for _, r := range recipients {
+		if !r.Enabled || !r.Alerts || !r.Covers(a.Department, a.Group) {
+			continue
+		}
+		if _, ok := seen[r.Email]; ok {
+			continue
+		}
+		seen[r.Email] = struct{}{}
+
+		notified, err := n.store.WasNotified(a.ID, r.Email)
+		if err != nil {
+			return sent, err
+		}
+		if notified {
+			continue
+		}
+
+		body, err := render(alertTemplate, map[string]interface{}{
+			"Recipient":    r,
+			"Alert":        a,
+			"DashboardURL": n.dashboardURL,
+		})
+		if err != nil {
+			return sent, fmt.Errorf("ошибка шаблона письма: %v", err)
+		}
+
+		alertID := a.ID
+		if n.deliver(models.NotificationAlert, &alertID, r.Email, alertSubject(a), body) {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// SendDigest отправляет еженедельную сводку по активным алертам
+// каждому получателю сводок с учётом его отделения и группы
+func (n *Notifier) SendDigest() (int, error) {
+	recipients, err := n.store.ListRecipients()
+	if err != nil {
+		return 0, err
+	}
+	alerts, err := n.activeAlerts()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	sent := 0
+	for _, r := range recipients {
+		if !r.Enabled || !r.Digest {
+			continue
+		}
+		var own []models.Alert
+		for _, a := range alerts {
+			if r.Covers(a.Department, a.Group) {
+				own = append(own, a)
+			}
+		}
+
+		body, err := render(digestTemplate, map[string]interface{}{
+			"Recipient":    r,
+			"Alerts":       own,
+			"Now":          now,
+			"DashboardURL": n.dashboardURL,
+		})
+		if err != nil {
+			return sent, fmt.Errorf("ошибка шаблона сводки: %v", err)
+		}
+
+		subject := fmt.Sprintf("Посещаемость: сводка за неделю (%d алертов)", len(own))
+		if n.deliver(models.NotificationDigest, nil, r.Email, subject, body) {
+			sent++
+		}
+	}
+	logger.Info("Еженедельная сводка отправлена", "sent", sent)
+	return sent, nil
+}
+
+// SendTest отправляет тестовое письмо для проверки настроек SMTP
+func (n *Notifier) SendTest(email string) error {
+	subject := "Посещаемость: тестовое письмо"
+	body := "Это тестовое письмо системы мониторинга посещаемости. Настройки SMTP работают.\n"
+	if err := n.sender.Send(Message{To: []string{email}, Subject: subject, Body: body}); err != nil {
+		n.logDelivery(models.NotificationTest, nil, email, subject, err)
+		return err
+	}
+	n.logDelivery(models.NotificationTest, nil, email, subject, nil)
+	return nil
+}
+
+// deliver отправляет письмо и записывает результат в журнал
+func (n *Notifier) deliver(kind string, alertID *int, email, subject, body string) bool {
+	err := n.sender.Send(Message{To: []string{email}, Subject: subject, Body: body})
+	if err != nil {
+		logger.Error("Ошибка отправки письма", "email", email, "error", err)
+	}
+	n.logDelivery(kind, alertID, email, subject, err)
+	return err == nil
+}
+
+func (n *Notifier) logDelivery(kind string, alertID *int, email, subject string, sendErr error) {
+	d := models.Delivery{
+		Kind:      kind,
+		AlertID:   alertID,
+		Recipient: email,
+		Subject:   subject,
+		Status:    models.DeliverySent,
+	}
+	if sendErr != nil {
+		d.Status = models.DeliveryFailed
+		d.Error = sendErr.Error()
+	}
+	if err := n.store.LogDelivery(d); err != nil {
+		logger.Warn("Не удалось записать журнал доставки", "error", err)
+	}
+}
@@ -0,0 +1,217 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"dashboard/internal/models"
+)
+
+// fakeSMTP - минимальный SMTP сервер (аналог MailHog) для тестов
+type fakeSMTP struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	rcpt []string
+	data []string
+}
+
+func startFakeSMTP(t *testing.T) *fakeSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Не удалось запустить SMTP сервер: %v", err)
+	}
+	s := &fakeSMTP{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM"):
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO"):
+			s.mu.Lock()
+			s.rcpt = append(s.rcpt, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			s.mu.Unlock()
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(l)
+			}
+			s.mu.Lock()
+			s.data = append(s.data, b.String())
+			s.mu.Unlock()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Not implemented")
+		}
+	}
+}
+
+func TestSMTPSender_Send(t *testing.T) {
+	srv := startFakeSMTP(t)
+	host, port, _ := net.SplitHostPort(srv.ln.Addr().String())
+	sender := NewSMTPSender(host, port, "", "", "dashboard@localhost")
+
+	err := sender.Send(Message{To: []string{"curator@college.ru"}, Subject: "Посещаемость: 11-ис", Body: "Группа 11-ис превысила порог"})
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка отправки: %v", err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.rcpt) != 1 || srv.rcpt[0] != "curator@college.ru" {
+		t.Fatalf("Неверные получатели: %v", srv.rcpt)
+	}
+	if len(srv.data) != 1 {
+		t.Fatalf("Ожидалось одно письмо, получено %d", len(srv.data))
+	}
+
+	raw := srv.data[0]
+	if !strings.Contains(raw, "Subject: =?UTF-8?b?") {
+		t.Errorf("Тема должна быть закодирована в UTF-8: %s", raw)
+	}
+	body := raw[strings.Index(raw, "\r\n\r\n")+4:]
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\r\n", ""))
+	if err != nil || string(decoded) != "Группа 11-ис превысила порог" {
+		t.Errorf("Неверное тело письма: %q (%v)", decoded, err)
+	}
+}
+
+type fakeStore struct {
+	recipients []models.Recipient
+	log        []models.Delivery
+}
+
+func (s *fakeStore) ListRecipients() ([]models.Recipient, error) { return s.recipients, nil }
+
+func (s *fakeStore) WasNotified(alertID int, recipient string) (bool, error) {
+	for _, d := range s.log {
+		if d.Kind == models.NotificationAlert && d.AlertID != nil && *d.AlertID == alertID &&
+			d.Recipient == recipient && d.Status == models.DeliverySent {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *fakeStore) LogDelivery(d models.Delivery) error {
+	s.log = append(s.log, d)
+	return nil
+}
+
+type fakeSender struct {
+	sent []Message
+}
+
+func (s *fakeSender) Send(msg Message) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestNotifier_NotifyAlertRoutesAndDeduplicates(t *testing.T) {
+	store := &fakeStore{recipients: []models.Recipient{
+		{Email: "head@college.ru", Name: "Заведующий", Department: "Отделение 1", Alerts: true, Enabled: true},
+		{Email: "curator@college.ru", Department: "Отделение 1", Group: "11-ис", Alerts: true, Enabled: true},
+		{Email: "other@college.ru", Department: "Отделение 1", Group: "12-ис", Alerts: true, Enabled: true},
+		{Email: "digest-only@college.ru", Alerts: false, Digest: true, Enabled: true},
+		{Email: "off@college.ru", Alerts: true, Enabled: false},
+	}}
+	sender := &fakeSender{}
+	n := NewNotifier(sender, store, nil, "http://dashboard.local")
+
+	alert := models.Alert{ID: 7, Rule: "group_average", Severity: models.SeverityCritical,
+		Department: "Отделение 1", Group: "11-ис", Message: "Группа 11-ис превысила порог"}
+
+	sent, err := n.NotifyAlert(alert)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if sent != 2 {
+		t.Fatalf("Ожидалось 2 письма (заведующий и куратор), отправлено %d", sent)
+	}
+	if sender.sent[0].To[0] != "head@college.ru" || sender.sent[1].To[0] != "curator@college.ru" {
+		t.Errorf("Неверная маршрутизация: %v, %v", sender.sent[0].To, sender.sent[1].To)
+	}
+	if !strings.Contains(sender.sent[0].Body, "Заведующий") || !strings.Contains(sender.sent[0].Body, "http://dashboard.local") {
+		t.Errorf("Шаблон письма заполнен неверно:\n%s", sender.sent[0].Body)
+	}
+	if !strings.Contains(sender.sent[0].Subject, "критично") {
+		t.Errorf("Тема критического алерта должна это отражать: %s", sender.sent[0].Subject)
+	}
+
+	// Повторное уведомление о том же алерте не отправляется
+	sent, err = n.NotifyAlert(alert)
+	if err != nil || sent != 0 {
+		t.Errorf("Повторная отправка должна быть пропущена: sent=%d err=%v", sent, err)
+	}
+	if len(store.log) != 2 {
+		t.Errorf("В журнале должно быть 2 записи, получено %d", len(store.log))
+	}
+}
+
+func TestNotifier_SendDigest(t *testing.T) {
+	store := &fakeStore{recipients: []models.Recipient{
+		{Email: "head@college.ru", Department: "Отделение 1", Digest: true, Enabled: true},
+		{Email: "alerts-only@college.ru", Alerts: true, Enabled: true},
+	}}
+	sender := &fakeSender{}
+	active := func() ([]models.Alert, error) {
+		return []models.Alert{
+			{Department: "Отделение 1", Group: "11-ис", Message: "Группа 11-ис превысила порог", Status: models.AlertAcknowledged},
+			{Department: "Отделение 2", Group: "21-ис", Message: "Группа 21-ис превысила порог", Status: models.AlertOpen},
+		}, nil
+	}
+	n := NewNotifier(sender, store, active, "")
+
+	sent, err := n.SendDigest()
+	if err != nil || sent != 1 {
+		t.Fatalf("Ожидалась одна сводка: sent=%d err=%v", sent, err)
+	}
+	body := sender.sent[0].Body
+	if !strings.Contains(body, "11-ис") || strings.Contains(body, "21-ис") {
+		t.Errorf("Сводка должна содержать только алерты отделения получателя:\n%s", body)
+	}
+	if !strings.Contains(body, "принят в работу") {
+		t.Errorf("Принятые в работу алерты должны быть отмечены:\n%s", body)
+	}
+}
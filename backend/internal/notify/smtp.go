@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Message письмо для отправки
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Sender отправляет письма
+type Sender interface {
+	Send(msg Message) error
+}
+
+// SMTPSender отправляет письма через SMTP сервер.
+// Для локальной проверки подходит MailHog (localhost:1025, без авторизации).
+type SMTPSender struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+// NewSMTPSender создаёт отправителя для host:port
+func NewSMTPSender(host, port, username, password, from string) *SMTPSender {
+	return &SMTPSender{
+		Addr:     net.JoinHostPort(host, port),
+		Username: username,
+		Password: password,
+		From:     from,
+	}
+}
+
+// Send отправляет письмо. STARTTLS используется, если сервер его поддерживает.
+func (s *SMTPSender) Send(msg Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("не указаны получатели")
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, _ := net.SplitHostPort(s.Addr)
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	if err := smtp.SendMail(s.Addr, auth, s.From, msg.To, buildMessage(s.From, msg)); err != nil {
+		return fmt.Errorf("ошибка отправки письма через %s: %v", s.Addr, err)
+	}
+	return nil
+}
+
+// buildMessage формирует письмо в формате RFC 5322 с телом в UTF-8
+func buildMessage(from string, msg Message) []byte {
+	var b bytes.Buffer
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(msg.To, ", ") + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n")
+	b.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return b.Bytes()
+}
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+	"time"
+
+	"dashboard/internal/models"
+)
+
+var funcs = template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("02.01.2006 15:04") },
+	"severity": func(s string) string {
+		if s == models.SeverityCritical {
+			return "критический"
+		}
+		return "предупреждение"
+	},
+}
+
+var alertTemplate = template.Must(template.New("alert").Funcs(funcs).Parse(
+	`Здравствуйте{{if .Recipient.Name}}, {{.Recipient.Name}}{{end}}!
+
+Сработало правило контроля посещаемости «{{.Alert.Rule}}» (уровень: {{severity .Alert.Severity}}).
+
+{{.Alert.Message}}
+
+Отделение: {{.Alert.Department}}
+{{- if .Alert.Group}}
+Группа: {{.Alert.Group}}{{end}}
+{{- if .Alert.Student}}
+Студент: {{.Alert.Student}}{{end}}
+Значение: {{.Alert.MetricValue}}, порог: {{.Alert.Threshold}}
+Обнаружено: {{date .Alert.FirstSeenAt}}
+{{if .DashboardURL}}
+Подробнее: {{.DashboardURL}}
+{{end}}
+--
+Письмо отправлено автоматически системой мониторинга посещаемости.
+`))
+
+var digestTemplate = template.Must(template.New("digest").Funcs(funcs).Parse(
+	`Здравствуйте{{if .Recipient.Name}}, {{.Recipient.Name}}{{end}}!
+
+Еженедельная сводка по посещаемости на {{date .Now}}.
+{{if .Alerts}}
+Активных алертов: {{len .Alerts}}
+{{range .Alerts}}
+- [{{severity .Severity}}] {{.Message}}{{if eq .Status "acknowledged"}} (принят в работу){{end}}
+{{- end}}
+{{else}}
+Активных алертов нет.
+{{end}}
+{{- if .DashboardURL}}
+Дашборд: {{.DashboardURL}}
+{{end}}
+--
+Письмо отправлено автоматически системой мониторинга посещаемости.
+`))
+
+// alertSubject тема письма об алерте
+func alertSubject(a models.Alert) string {
+	subject := a.Group
+	if a.Student != "" {
+		subject = a.Student
+	}
+	if subject == "" {
+		subject = a.Department
+	}
+	prefix := "Посещаемость"
+	if a.Severity == models.SeverityCritical {
+		prefix = "Посещаемость [критично]"
+	}
+	return prefix + ": " + subject
+}
+
+func render(t *template.Template, data interface{}) (string, error) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return strings.TrimLeft(b.String(), "\n"), nil
+}
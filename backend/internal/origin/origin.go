@@ -0,0 +1,86 @@
+// Package origin разбирает шаблоны адресов CORS. Одни и те же правила
+// применяют проверка конфигурации и middleware CORS.
+package origin
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Matcher проверяет адрес (Origin) по списку шаблонов:
+// точный адрес, "*", поддомены "https://*.college.edu"
+// и любой порт "http://localhost:*"
+type Matcher struct {
+	any      bool
+	patterns []pattern
+}
+
+type pattern struct {
+	scheme    string
+	host      string // без "*." для шаблона поддоменов
+	subdomain bool
+	port      string // "*" - любой порт
+}
+
+// Parse разбирает шаблоны адресов
+func Parse(patterns []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, raw := range patterns {
+		if raw == "*" {
+			m.any = true
+			continue
+		}
+		p, err := parsePattern(raw)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный адрес CORS %q: %v", raw, err)
+		}
+		m.patterns = append(m.patterns, p)
+	}
+	return m, nil
+}
+
+// parsePattern разбирает шаблон: схема и хост без пути, "*" допускается
+// в начале хоста (поддомены) и вместо порта
+func parsePattern(raw string) (pattern, error) {
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok || (scheme != "http" && scheme != "https") {
+		return pattern{}, fmt.Errorf("ожидается http:// или https://")
+	}
+	if rest == "" || strings.ContainsAny(rest, "/?#") {
+		return pattern{}, fmt.Errorf("адрес не должен содержать путь")
+	}
+	host, port, _ := strings.Cut(rest, ":")
+	p := pattern{scheme: scheme, host: strings.ToLower(host), port: port}
+	if strings.HasPrefix(p.host, "*.") {
+		p.subdomain, p.host = true, p.host[1:] // ".college.edu"
+	}
+	if strings.Contains(p.host, "*") || (port != "*" && strings.Contains(port, "*")) {
+		return pattern{}, fmt.Errorf("\"*\" допускается только в начале хоста (*.example.edu) или вместо порта")
+	}
+	return p, nil
+}
+
+// Allows сообщает, разрешён ли адрес
+func (m *Matcher) Allows(origin string) bool {
+	if m.any {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	for _, p := range m.patterns {
+		if p.scheme != u.Scheme || (p.port != "*" && p.port != port) {
+			continue
+		}
+		if p.subdomain && strings.HasSuffix(host, p.host) && len(host) > len(p.host) {
+			return true
+		}
+		if !p.subdomain && p.host == host {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package origin
+
+import "testing"
+
+func TestMatcher(t *testing.T) {
+	m, err := Parse([]string{"https://dashboard.example.edu", "https://*.college.edu", "http://localhost:*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for origin, want := range map[string]bool{
+		"https://dashboard.example.edu":      true,
+		"http://dashboard.example.edu":       false,
+		"https://dashboard.example.edu:8443": false,
+		"https://reports.college.edu":        true,
+		"https://a.b.college.edu":            true,
+		"https://college.edu":                false,
+		"https://evilcollege.edu":            false,
+		"http://localhost:5173":              true,
+		"http://localhost":                   true,
+		"http://localhost.evil.com":          false,
+		"null":                               false,
+	} {
+		if got := m.Allows(origin); got != want {
+			t.Errorf("%s: получено %v, ожидалось %v", origin, got, want)
+		}
+	}
+
+	for _, bad := range []string{"dashboard.example.edu", "https://example.edu/app", "https://ex*.edu", "ftp://example.edu"} {
+		if _, err := Parse([]string{bad}); err == nil {
+			t.Errorf("%s: ожидалась ошибка", bad)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Package quiet разбирает окна тишины планового обновления. Пакет без
+// зависимостей: его используют и проверка конфигурации, и планировщик.
+package quiet
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window - время суток, когда плановое обновление не запускается
+// (например, пока секретари редактируют таблицы). Минуты от полуночи;
+// окно с From > To переходит через полночь.
+type Window struct {
+	From int
+	To   int
+}
+
+// Parse разбирает окна тишины вида "22:00-06:00"
+func Parse(specs []string) ([]Window, error) {
+	windows := make([]Window, 0, len(specs))
+	for _, spec := range specs {
+		from, to, ok := strings.Cut(spec, "-")
+		if !ok {
+			return nil, fmt.Errorf("окно тишины %q: ожидается ЧЧ:ММ-ЧЧ:ММ", spec)
+		}
+		w := Window{}
+		var err error
+		if w.From, err = parseClock(from); err == nil {
+			w.To, err = parseClock(to)
+		}
+		if err != nil || w.From == w.To {
+			return nil, fmt.Errorf("окно тишины %q: ожидается ЧЧ:ММ-ЧЧ:ММ", spec)
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// parseClock разбирает время суток "ЧЧ:ММ" в минуты от полуночи
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains сообщает, попадает ли момент t в окно (по местному времени t)
+func (w Window) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.From < w.To {
+		return m >= w.From && m < w.To
+	}
+	return m >= w.From || m < w.To
+}
+
+func (w Window) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.From/60, w.From%60, w.To/60, w.To%60)
+}
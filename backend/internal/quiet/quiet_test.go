@@ -0,0 +1,36 @@
+package quiet
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	windows, err := Parse([]string{"22:00-06:00", "12:30-13:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(hh, mm int) time.Time { return time.Date(2026, 3, 2, hh, mm, 0, 0, time.UTC) }
+	for _, c := range []struct {
+		w    int
+		at   time.Time
+		want bool
+	}{
+		{0, day(23, 0), true},
+		{0, day(5, 59), true},
+		{0, day(6, 0), false},
+		{0, day(12, 0), false},
+		{1, day(12, 30), true},
+		{1, day(13, 0), false},
+	} {
+		if got := windows[c.w].Contains(c.at); got != c.want {
+			t.Errorf("%s в %s: получено %v, ожидалось %v", windows[c.w], c.at.Format("15:04"), got, c.want)
+		}
+	}
+
+	for _, bad := range []string{"22:00", "25:00-06:00", "10:00-10:00"} {
+		if _, err := Parse([]string{bad}); err == nil {
+			t.Errorf("%q: ожидалась ошибка", bad)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Package ratelimit ограничивает частоту запросов и блокирует подбор паролей
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval - как часто удалять состояние неактивных ключей
+const sweepInterval = time.Minute
+
+// Limiter ограничивает частоту запросов по ключу (token bucket):
+// в среднем perMinute запросов в минуту с всплеском до burst подряд
+type Limiter struct {
+	rate  float64 // токенов в секунду
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewLimiter создаёт ограничитель. burst <= 0 означает burst = perMinute.
+func NewLimiter(perMinute, burst int) *Limiter {
+	if burst <= 0 {
+		burst = perMinute
+	}
+	return &Limiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow расходует один запрос ключа. Если лимит исчерпан, возвращает
+// false и время до появления следующего разрешённого запроса.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// sweep забывает ключи, у которых счётчик успел восстановиться полностью
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) > refill {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Lockout блокирует ключ (логин или IP) после серии неудачных попыток входа.
+// Каждая следующая блокировка вдвое длиннее предыдущей, но не больше max.
+// Неудачи, разделённые паузой дольше window, не складываются; история
+// блокировок забывается после успешного входа или суток без неудач.
+type Lockout struct {
+	threshold int
+	base      time.Duration
+	max       time.Duration
+	window    time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*lockEntry
+	lastSweep time.Time
+}
+
+type lockEntry struct {
+	failures    int
+	lockouts    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// lockoutForget - через сколько после последней неудачи забывается история ключа
+const lockoutForget = 24 * time.Hour
+
+// NewLockout создаёт счётчик неудач: threshold попыток подряд блокируют
+// ключ на base, повторные блокировки растут до max
+func NewLockout(threshold int, base, max time.Duration) *Lockout {
+	if max < base {
+		max = base
+	}
+	return &Lockout{
+		threshold: threshold,
+		base:      base,
+		max:       max,
+		window:    15 * time.Minute,
+		now:       time.Now,
+		entries:   make(map[string]*lockEntry),
+	}
+}
+
+// Locked сообщает, заблокирован ли ключ, и сколько осталось ждать
+func (l *Lockout) Locked(key string) (bool, time.Duration) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[key]
+	if !ok || !now.Before(e.lockedUntil) {
+		return false, 0
+	}
+	return true, e.lockedUntil.Sub(now)
+}
+
+// Fail учитывает неудачную попытку. Если она привела к блокировке,
+// возвращает true и время окончания блокировки.
+func (l *Lockout) Fail(key string) (bool, time.Time) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	e, ok := l.entries[key]
+	if !ok {
+		e = &lockEntry{}
+		l.entries[key] = e
+	}
+	if now.Sub(e.lastFailure) > l.window {
+		e.failures = 0
+	}
+	e.failures++
+	e.lastFailure = now
+	if e.failures < l.threshold {
+		return false, time.Time{}
+	}
+
+	d := l.base
+	for i := 0; i < e.lockouts && d < l.max; i++ {
+		d *= 2
+	}
+	if d > l.max {
+		d = l.max
+	}
+	e.failures = 0
+	e.lockouts++
+	e.lockedUntil = now.Add(d)
+	return true, e.lockedUntil
+}
+
+// Success сбрасывает историю ключа после успешного входа
+func (l *Lockout) Success(key string) {
+	l.mu.Lock()
+	delete(l.entries, key)
+	l.mu.Unlock()
+}
+
+func (l *Lockout) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, e := range l.entries {
+		if now.Sub(e.lastFailure) > lockoutForget && !now.Before(e.lockedUntil) {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Виды блокировок входа
+const (
+	LockoutUsername = "username"
+	LockoutIP       = "ip"
+)
+
+// LockoutEvent - блокировка входа после серии неудачных попыток
+type LockoutEvent struct {
+	Kind     string    `json:"kind"`
+	Username string    `json:"username"`
+	IP       string    `json:"ip"`
+	Until    time.Time `json:"until"`
+}
+
+// LoginGuard защищает вход от подбора паролей: ограничивает частоту попыток
+// с одного адреса и для одного логина и блокирует их после серии неудач
+type LoginGuard struct {
+	perIP   *Limiter
+	perUser *Limiter
+	users   *Lockout
+	ips     *Lockout
+
+	mu        sync.Mutex
+	listeners []func(LockoutEvent)
+}
+
+// NewLoginGuard создаёт защиту входа: perMinute попыток в минуту с адреса
+// и для логина; maxFailures неудач подряд блокируют логин на lockout
+// (повторно - вдвое дольше, до maxLockout)
+func NewLoginGuard(perMinute, maxFailures int, lockout, maxLockout time.Duration) *LoginGuard {
+	return &LoginGuard{
+		perIP:   NewLimiter(perMinute, perMinute),
+		perUser: NewLimiter(perMinute, perMinute),
+		users:   NewLockout(maxFailures, lockout, maxLockout),
+		// Через один адрес (NAT колледжа) входит много людей, поэтому порог выше
+		ips: NewLockout(maxFailures*4, lockout, maxLockout),
+	}
+}
+
+// AddListener подписывает функцию на события блокировки (журнал аудита)
+func (g *LoginGuard) AddListener(fn func(LockoutEvent)) {
+	g.mu.Lock()
+	g.listeners = append(g.listeners, fn)
+	g.mu.Unlock()
+}
+
+// Check проверяет, можно ли сейчас пытаться войти. Если нет,
+// возвращает время, через которое стоит повторить попытку.
+func (g *LoginGuard) Check(ip, username string) (bool, time.Duration) {
+	if locked, wait := g.users.Locked(userKey(username)); locked {
+		return false, wait
+	}
+	if locked, wait := g.ips.Locked(ip); locked {
+		return false, wait
+	}
+	if ok, wait := g.perIP.Allow(ip); !ok {
+		return false, wait
+	}
+	if ok, wait := g.perUser.Allow(userKey(username)); !ok {
+		return false, wait
+	}
+	return true, 0
+}
+
+// Failure учитывает неверный пароль
+func (g *LoginGuard) Failure(ip, username string) {
+	if locked, until := g.users.Fail(userKey(username)); locked {
+		g.emit(LockoutEvent{Kind: LockoutUsername, Username: username, IP: ip, Until: until})
+	}
+	if locked, until := g.ips.Fail(ip); locked {
+		g.emit(LockoutEvent{Kind: LockoutIP, Username: username, IP: ip, Until: until})
+	}
+}
+
+// Success сбрасывает счётчик неудач логина. Счётчик адреса не сбрасывается,
+// чтобы успешный вход в свою учётную запись не открывал подбор чужих.
+func (g *LoginGuard) Success(ip, username string) {
+	g.users.Success(userKey(username))
+}
+
+func (g *LoginGuard) emit(ev LockoutEvent) {
+	g.mu.Lock()
+	listeners := append([]func(LockoutEvent){}, g.listeners...)
+	g.mu.Unlock()
+	for _, fn := range listeners {
+		fn(ev)
+	}
+}
+
+func userKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time      { return c.t }
+func (c *fakeClock) add(d time.Duration) { c.t = c.t.Add(d) }
+func newClock() *fakeClock               { return &fakeClock{t: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)} }
+
+func TestLimiter(t *testing.T) {
+	clock := newClock()
+	l := NewLimiter(60, 3)
+	l.now = clock.now
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("запрос %d в пределах всплеска должен проходить", i+1)
+		}
+	}
+	ok, wait := l.Allow("a")
+	if ok || wait <= 0 || wait > time.Second {
+		t.Errorf("после всплеска ожидался отказ с ожиданием до 1с, получено %v %v", ok, wait)
+	}
+	if ok, _ := l.Allow("b"); !ok {
+		t.Error("ключи не должны влиять друг на друга")
+	}
+
+	clock.add(time.Second)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("через секунду при 60 запросах в минуту должен появиться запрос")
+	}
+}
+
+func TestLockoutProgressive(t *testing.T) {
+	clock := newClock()
+	l := NewLockout(3, time.Minute, 3*time.Minute)
+	l.now = clock.now
+
+	fail := func(n int) (bool, time.Time) {
+		var locked bool
+		var until time.Time
+		for i := 0; i < n; i++ {
+			locked, until = l.Fail("ivanova")
+		}
+		return locked, until
+	}
+
+	if locked, _ := fail(2); locked {
+		t.Fatal("две неудачи не должны блокировать")
+	}
+	locked, until := fail(1)
+	if !locked || until.Sub(clock.t) != time.Minute {
+		t.Fatalf("третья неудача должна блокировать на минуту, получено %v %v", locked, until.Sub(clock.t))
+	}
+	if locked, _ := l.Locked("ivanova"); !locked {
+		t.Error("логин должен быть заблокирован")
+	}
+
+	clock.add(time.Minute)
+	if locked, _ := l.Locked("ivanova"); locked {
+		t.Error("блокировка должна закончиться")
+	}
+	if _, until := fail(3); until.Sub(clock.t) != 2*time.Minute {
+		t.Errorf("повторная блокировка должна быть вдвое длиннее, получено %v", until.Sub(clock.t))
+	}
+	clock.add(2 * time.Minute)
+	if _, until := fail(3); until.Sub(clock.t) != 3*time.Minute {
+		t.Errorf("блокировка не должна превышать максимум, получено %v", until.Sub(clock.t))
+	}
+
+	l.Success("ivanova")
+	clock.add(3 * time.Minute)
+	if _, until := fail(3); until.Sub(clock.t) != time.Minute {
+		t.Errorf("после успешного входа блокировки начинаются заново, получено %v", until.Sub(clock.t))
+	}
+}
+
+func TestLoginGuardEvents(t *testing.T) {
+	g := NewLoginGuard(100, 2, time.Minute, time.Hour)
+	var events []LockoutEvent
+	g.AddListener(func(ev LockoutEvent) { events = append(events, ev) })
+
+	g.Failure("10.0.0.1", "Admin")
+	g.Failure("10.0.0.2", "admin")
+	if len(events) != 1 || events[0].Kind != LockoutUsername {
+		t.Fatalf("ожидалось событие блокировки логина, получено %+v", events)
+	}
+	if ok, wait := g.Check("10.0.0.3", "ADMIN"); ok || wait <= 0 {
+		t.Error("логин должен быть заблокирован независимо от регистра и адреса")
+	}
+	if ok, _ := g.Check("10.0.0.3", "petrov"); !ok {
+		t.Error("другие логины не должны блокироваться")
+	}
+}
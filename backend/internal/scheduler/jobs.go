@@ -0,0 +1,637 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"dashboard/internal/database"
+	"dashboard/internal/logging"
+	"dashboard/internal/metrics"
+	"dashboard/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Статусы задачи обновления
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSuccess   = "success"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+	// JobInterrupted - задача прервана остановкой сервера
+	JobInterrupted = "interrupted"
+)
+
+// Статусы этапа задачи
+const (
+	StagePending = "pending"
+	StageRunning = "running"
+	StageDone    = "done"
+	StageSkipped = "skipped"
+	StageFailed  = "failed"
+)
+
+// События жизненного цикла задачи для слушателей Runner
+const (
+	EventJobStarted  = "started"
+	EventJobProgress = "progress"
+	EventJobFinished = "finished"
+)
+
+// Источники запуска задачи
+const (
+	TriggerStartup = "startup"
+	TriggerCron    = "cron"
+	TriggerManual  = "manual"
+)
+
+const (
+	// defaultMaxQueue ограничивает число задач, ожидающих своей очереди
+	defaultMaxQueue = 3
+	// defaultHistorySize ограничивает число завершённых задач в памяти
+	defaultHistorySize = 50
+	// cancelWait - сколько ждать завершения задачи после отмены при остановке
+	cancelWait = 10 * time.Second
+	// persistTimeout ограничивает сохранение задачи в историю
+	persistTimeout = 5 * time.Second
+)
+
+var (
+	// ErrQueueFull возвращается, если очередь задач заполнена
+	ErrQueueFull = errors.New("очередь обновлений заполнена")
+	// ErrJobNotFound возвращается, если задача с указанным ID не найдена
+	ErrJobNotFound = errors.New("задача не найдена")
+	// ErrJobFinished возвращается при попытке отменить завершённую задачу
+	ErrJobFinished = errors.New("задача уже завершена")
+	// ErrShuttingDown возвращается, если сервер останавливается
+	ErrShuttingDown = errors.New("сервер останавливается, обновления не принимаются")
+
+	// errShutdown - причина отмены задачи при остановке сервера
+	errShutdown = errors.New("прервано остановкой сервера")
+)
+
+// StageProgress описывает состояние одного этапа обновления
+type StageProgress struct {
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Message    string     `json:"message,omitempty"`
+}
+
+// Job описывает задачу обновления данных. ID задачи попадает во все логи
+// её выполнения (job_id), RequestID - ID запроса, поставившего задачу.
+type Job struct {
+	ID         string          `json:"id"`
+	Trigger    string          `json:"trigger"`
+	RequestID  string          `json:"request_id,omitempty"`
+	Status     string          `json:"status"`
+	Progress   int             `json:"progress"`
+	Stages     []StageProgress `json:"stages"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+
+	cancel context.CancelCauseFunc
+	done   chan struct{} // закрывается, когда задача завершена и сохранена
+	// link - спан запроса, поставившего задачу: спан задачи ссылается на него
+	link trace.SpanContext
+}
+
+// Finished сообщает, завершена ли задача
+func (j *Job) Finished() bool {
+	switch j.Status {
+	case JobSuccess, JobFailed, JobCancelled, JobInterrupted:
+		return true
+	}
+	return false
+}
+
+// snapshot возвращает копию задачи, безопасную для чтения вне Runner
+func (j *Job) snapshot() Job {
+	cp := *j
+	cp.cancel = nil
+	cp.done = nil
+	cp.Stages = append([]StageProgress(nil), j.Stages...)
+	return cp
+}
+
+// stage описывает шаг конвейера обновления.
+// Функция возвращает skipped=true, если шаг выполнять не требовалось.
+// Ошибка optional-этапа не прерывает задачу, а лишь помечает этап как failed.
+type stage struct {
+	name     string
+	optional bool
+	run      func(ctx context.Context) (skipped bool, err error)
+}
+
+// HistoryStore - постоянное хранилище завершённых задач
+type HistoryStore interface {
+	Save(ctx context.Context, r database.RefreshJobRecord) error
+	Recent(ctx context.Context, limit int) ([]database.RefreshJobRecord, error)
+}
+
+// Listener получает уведомления о задачах. Вызывается вне блокировки Runner
+// из горутины задачи, поэтому не должен надолго блокироваться.
+type Listener func(event string, job Job)
+
+// Runner выполняет задачи обновления по одной, общий для cron и API
+type Runner struct {
+	mu          sync.Mutex
+	stages      []stage
+	listeners   []Listener
+	current     *Job
+	queue       []*Job
+	history     []*Job
+	jobs        map[string]*Job
+	maxQueue    int
+	historySize int
+	lastSuccess time.Time
+	store       HistoryStore
+	closing     bool
+}
+
+// NewRunner создаёт исполнитель задач: конвертация обоих файлов и загрузка в БД
+func NewRunner(s *Scheduler, loader *database.Loader, attendanceOutput, statementOutput string) *Runner {
+	stages := []stage{
+		{
+			name: "convert_attendance",
+			run: func(ctx context.Context) (bool, error) {
+				updated, err := s.RefreshAttendance(ctx)
+				return !updated, err
+			},
+		},
+		{
+			name: "convert_statement",
+			run: func(ctx context.Context) (bool, error) {
+				updated, err := s.RefreshStatement(ctx)
+				return !updated, err
+			},
+		},
+		{
+			name:     "load_attendance",
+			optional: true,
+			run: func(ctx context.Context) (bool, error) {
+				if database.DB == nil {
+					return true, nil
+				}
+				return false, loader.LoadAttendance(ctx, attendanceOutput)
+			},
+		},
+		{
+			name:     "load_statement",
+			optional: true,
+			run: func(ctx context.Context) (bool, error) {
+				if database.DB == nil {
+					return true, nil
+				}
+				return false, loader.LoadStatement(ctx, statementOutput)
+			},
+		},
+	}
+	return newRunner(stages)
+}
+
+func newRunner(stages []stage) *Runner {
+	return &Runner{
+		stages:      stages,
+		jobs:        make(map[string]*Job),
+		maxQueue:    defaultMaxQueue,
+		historySize: defaultHistorySize,
+	}
+}
+
+// AddListener подписывает функцию на события задач
+func (r *Runner) AddListener(l Listener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, l)
+}
+
+// emit уведомляет слушателей. Вызывается без удержания r.mu.
+func (r *Runner) emit(event string, job Job) {
+	r.mu.Lock()
+	listeners := append([]Listener(nil), r.listeners...)
+	r.mu.Unlock()
+
+	for _, l := range listeners {
+		l(event, job)
+	}
+}
+
+// Submit ставит задачу обновления в очередь и сразу возвращает её.
+// Если другая задача уже выполняется, новая ждёт в очереди.
+// ID запроса из ctx сохраняется в задаче для сквозной трассировки логов.
+func (r *Runner) Submit(ctx context.Context, trigger string) (Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closing {
+		return Job{}, ErrShuttingDown
+	}
+	if r.current != nil && len(r.queue) >= r.maxQueue {
+		return Job{}, ErrQueueFull
+	}
+
+	job := &Job{
+		ID:        newJobID(),
+		Trigger:   trigger,
+		RequestID: logging.RequestID(ctx),
+		Status:    JobQueued,
+		done:      make(chan struct{}),
+		link:      trace.SpanContextFromContext(ctx),
+		Stages:    make([]StageProgress, len(r.stages)),
+		CreatedAt: time.Now(),
+	}
+	for i, st := range r.stages {
+		job.Stages[i] = StageProgress{Name: st.name, Status: StagePending}
+	}
+	r.jobs[job.ID] = job
+
+	if r.current != nil {
+		r.queue = append(r.queue, job)
+		logger.InfoContext(jobContext(job), "Задача поставлена в очередь", "trigger", trigger, "position", len(r.queue))
+		return job.snapshot(), nil
+	}
+
+	r.start(job)
+	return job.snapshot(), nil
+}
+
+// Cancel отменяет выполняющуюся задачу или убирает её из очереди
+func (r *Runner) Cancel(id string) (Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	if job.Finished() {
+		return job.snapshot(), ErrJobFinished
+	}
+
+	if job.Status == JobQueued {
+		for i, q := range r.queue {
+			if q == job {
+				r.queue = append(r.queue[:i], r.queue[i+1:]...)
+				break
+			}
+		}
+		now := time.Now()
+		job.Status = JobCancelled
+		job.Error = context.Canceled.Error()
+		job.FinishedAt = &now
+		r.archive(job)
+		close(job.done)
+		cancelled := job.snapshot()
+		go r.persist(cancelled)
+		return cancelled, nil
+	}
+
+	// Выполняющаяся задача завершится на ближайшей проверке контекста
+	if job.cancel != nil {
+		job.cancel(context.Canceled)
+	}
+	return job.snapshot(), nil
+}
+
+// Get возвращает задачу по ID
+func (r *Runner) Get(id string) (Job, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return job.snapshot(), true
+}
+
+// Status описывает текущее состояние исполнителя
+type Status struct {
+	InProgress  bool      `json:"in_progress"`
+	Current     *Job      `json:"current"`
+	Queue       []Job     `json:"queue"`
+	LastJob     *Job      `json:"last_job"`
+	LastSuccess time.Time `json:"-"`
+}
+
+// Status возвращает текущую задачу, очередь и последнюю завершённую задачу
+func (r *Runner) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := Status{
+		InProgress:  r.current != nil,
+		Queue:       make([]Job, 0, len(r.queue)),
+		LastSuccess: r.lastSuccess,
+	}
+	if r.current != nil {
+		cur := r.current.snapshot()
+		st.Current = &cur
+	}
+	for _, q := range r.queue {
+		st.Queue = append(st.Queue, q.snapshot())
+	}
+	if len(r.history) > 0 {
+		last := r.history[0].snapshot()
+		st.LastJob = &last
+	}
+	return st
+}
+
+// History возвращает завершённые задачи, начиная с самой свежей
+func (r *Runner) History() []Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]Job, 0, len(r.history))
+	for _, j := range r.history {
+		out = append(out, j.snapshot())
+	}
+	return out
+}
+
+// jobContext возвращает контекст для логов задачи: job_id и ID запроса,
+// поставившего задачу
+func jobContext(job *Job) context.Context {
+	ctx := logging.WithJobID(context.Background(), job.ID)
+	if job.RequestID != "" {
+		ctx = logging.WithRequestID(ctx, job.RequestID)
+	}
+	return ctx
+}
+
+// start запускает задачу в отдельной горутине. Вызывается под r.mu.
+func (r *Runner) start(job *Job) {
+	ctx, cancel := context.WithCancelCause(jobContext(job))
+	now := time.Now()
+	job.cancel = cancel
+	job.Status = JobRunning
+	job.StartedAt = &now
+	r.current = job
+
+	go r.run(ctx, job)
+}
+
+// run выполняет этапы задачи, затем запускает следующую задачу из очереди
+func (r *Runner) run(ctx context.Context, job *Job) {
+	// Задача - отдельная трассировка: она живёт дольше запроса, который её
+	// поставил, поэтому со спаном запроса её связывает ссылка, а не родство
+	opts := []trace.SpanStartOption{trace.WithAttributes(
+		attribute.String("job_id", job.ID),
+		attribute.String("trigger", job.Trigger),
+	)}
+	if job.link.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: job.link}))
+	}
+	ctx, span := tracing.Tracer().Start(ctx, "refresh.job", opts...)
+
+	logger.InfoContext(ctx, "Задача обновления запущена", "trigger", job.Trigger)
+	r.mu.Lock()
+	started := job.snapshot()
+	r.mu.Unlock()
+	r.emit(EventJobStarted, started)
+
+	var jobErr error
+	for i, st := range r.stages {
+		if err := ctx.Err(); err != nil {
+			jobErr = err
+			break
+		}
+
+		r.emit(EventJobProgress, r.updateStage(job, i, StageRunning, ""))
+		stageCtx, stageSpan := tracing.Start(ctx, "refresh."+st.name)
+		skipped, err := st.run(stageCtx)
+		stageSpan.SetAttributes(attribute.Bool("skipped", skipped && err == nil))
+		tracing.End(stageSpan, err)
+		var progress Job
+		switch {
+		case err != nil && st.optional:
+			logger.WarnContext(ctx, "Ошибка необязательного этапа", "stage", st.name, "error", err)
+			progress = r.updateStage(job, i, StageFailed, err.Error())
+		case err != nil:
+			progress = r.updateStage(job, i, StageFailed, err.Error())
+			jobErr = err
+		case skipped:
+			progress = r.updateStage(job, i, StageSkipped, "")
+		default:
+			progress = r.updateStage(job, i, StageDone, "")
+		}
+		r.emit(EventJobProgress, progress)
+		if s := progress.Stages[i]; s.StartedAt != nil && s.FinishedAt != nil {
+			metrics.ObserveStage(st.name, s.Status, s.FinishedAt.Sub(*s.StartedAt))
+		}
+		if jobErr != nil {
+			break
+		}
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	job.FinishedAt = &now
+	// Причину отмены смотрим до освобождения контекста: ошибка этапа после
+	// отмены - следствие отмены, а не сбой обновления
+	cause := context.Cause(ctx)
+	job.cancel(nil)
+	job.cancel = nil
+	switch {
+	case jobErr != nil && errors.Is(cause, errShutdown):
+		job.Status = JobInterrupted
+		job.Error = errShutdown.Error()
+		logger.WarnContext(ctx, "Задача обновления прервана остановкой сервера")
+	case jobErr != nil && cause != nil:
+		job.Status = JobCancelled
+		job.Error = jobErr.Error()
+		logger.WarnContext(ctx, "Задача обновления отменена")
+	case jobErr != nil:
+		job.Status = JobFailed
+		job.Error = jobErr.Error()
+		logger.ErrorContext(ctx, "Задача обновления завершилась ошибкой", "error", jobErr)
+	default:
+		job.Status = JobSuccess
+		job.Progress = 100
+		r.lastSuccess = now
+		metrics.MarkImported(now)
+		logger.InfoContext(ctx, "Задача обновления выполнена", "duration", now.Sub(*job.StartedAt).Round(time.Millisecond).String())
+	}
+	metrics.ObserveRefresh(job.Trigger, job.Status, now.Sub(*job.StartedAt))
+	span.SetAttributes(attribute.String("status", job.Status))
+	tracing.End(span, jobErr)
+	r.archive(job)
+	r.current = nil
+	finished := job.snapshot()
+
+	if len(r.queue) > 0 {
+		next := r.queue[0]
+		r.queue = r.queue[1:]
+		r.start(next)
+	}
+	r.mu.Unlock()
+
+	r.persist(finished)
+	r.emit(EventJobFinished, finished)
+	close(job.done)
+}
+
+// Shutdown прекращает приём задач и ждёт выполняющуюся задачу, пока не
+// истечёт ctx; затем отменяет её. Прерванная задача и задачи из очереди,
+// которые так и не запустились, попадают в историю со статусом interrupted.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	r.closing = true
+	now := time.Now()
+	dropped := make([]Job, 0, len(r.queue))
+	for _, job := range r.queue {
+		job.Status = JobInterrupted
+		job.Error = errShutdown.Error()
+		job.FinishedAt = &now
+		r.archive(job)
+		close(job.done)
+		dropped = append(dropped, job.snapshot())
+	}
+	r.queue = nil
+	current := r.current
+	r.mu.Unlock()
+
+	for _, job := range dropped {
+		logger.WarnContext(jobContext(&job), "Задача из очереди не запущена: сервер останавливается")
+		r.persist(job)
+		r.emit(EventJobFinished, job)
+	}
+	if current == nil {
+		return nil
+	}
+
+	logger.InfoContext(jobContext(current), "Ожидание завершения задачи обновления")
+	select {
+	case <-current.done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	r.mu.Lock()
+	if current.cancel != nil {
+		current.cancel(errShutdown)
+	}
+	r.mu.Unlock()
+	select {
+	case <-current.done:
+		return fmt.Errorf("задача %s не завершилась вовремя и прервана", current.ID)
+	case <-time.After(cancelWait):
+		return fmt.Errorf("задача %s не остановилась после отмены", current.ID)
+	}
+}
+
+// UseHistory подключает постоянное хранилище истории: загружает последние
+// задачи и дальше сохраняет каждую завершённую. Вызывается до первой задачи.
+func (r *Runner) UseHistory(ctx context.Context, store HistoryStore) error {
+	records, err := store.Recent(ctx, r.historySize)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = store
+	for _, rec := range records {
+		var job Job
+		if err := json.Unmarshal(rec.Data, &job); err != nil {
+			logger.Warn("Запись истории обновлений пропущена", "job_id", rec.ID, "error", err)
+			continue
+		}
+		if _, ok := r.jobs[job.ID]; ok {
+			continue
+		}
+		r.history = append(r.history, &job)
+		r.jobs[job.ID] = &job
+		if job.Status == JobSuccess && job.FinishedAt != nil && job.FinishedAt.After(r.lastSuccess) {
+			r.lastSuccess = *job.FinishedAt
+		}
+	}
+	return nil
+}
+
+// persist сохраняет завершённую задачу в постоянную историю
+func (r *Runner) persist(job Job) {
+	r.mu.Lock()
+	store := r.store
+	r.mu.Unlock()
+	if store == nil {
+		return
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		logger.Warn("Задача не сохранена в историю", "job_id", job.ID, "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+	defer cancel()
+	err = store.Save(ctx, database.RefreshJobRecord{
+		ID:         job.ID,
+		Trigger:    job.Trigger,
+		Status:     job.Status,
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+		Data:       data,
+	})
+	if err != nil {
+		logger.WarnContext(jobContext(&job), "Задача не сохранена в историю", "error", err)
+	}
+}
+
+// updateStage меняет статус этапа, пересчитывает прогресс задачи и возвращает её копию
+func (r *Runner) updateStage(job *Job, idx int, status, message string) Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	st := &job.Stages[idx]
+	st.Status = status
+	st.Message = message
+	if status == StageRunning {
+		st.StartedAt = &now
+	} else {
+		st.FinishedAt = &now
+	}
+
+	completed := 0
+	for _, s := range job.Stages {
+		if s.Status != StagePending && s.Status != StageRunning {
+			completed++
+		}
+	}
+	job.Progress = completed * 100 / len(job.Stages)
+	return job.snapshot()
+}
+
+// archive переносит завершённую задачу в историю. Вызывается под r.mu.
+func (r *Runner) archive(job *Job) {
+	r.history = append([]*Job{job}, r.history...)
+	if len(r.history) > r.historySize {
+		for _, old := range r.history[r.historySize:] {
+			delete(r.jobs, old.ID)
+		}
+		r.history = r.history[:r.historySize]
+	}
+}
+
+// newJobID генерирует случайный идентификатор задачи
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
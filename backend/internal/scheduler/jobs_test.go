@@ -0,0 +1,199 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"dashboard/internal/database"
+)
+
+// waitJob ждёт завершения задачи или падает по таймауту
+func waitJob(t *testing.T, r *Runner, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, ok := r.Get(id); ok && job.Finished() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Задача %s не завершилась вовремя", id)
+	return Job{}
+}
+
+func TestRunner_QueueAndCancel(t *testing.T) {
+	release := make(chan struct{})
+	r := newRunner([]stage{
+		{
+			name: "block",
+			run: func(ctx context.Context) (bool, error) {
+				select {
+				case <-release:
+					return false, nil
+				case <-ctx.Done():
+					return false, ctx.Err()
+				}
+			},
+		},
+		{
+			name:     "optional",
+			optional: true,
+			run: func(ctx context.Context) (bool, error) {
+				return false, errors.New("нет БД")
+			},
+		},
+	})
+	r.maxQueue = 1
+
+	first, err := r.Submit(context.Background(), TriggerManual)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if first.Status != JobRunning {
+		t.Errorf("Ожидался статус running, получено %s", first.Status)
+	}
+
+	// Вторая задача ждёт в очереди, третья не помещается
+	second, err := r.Submit(context.Background(), TriggerCron)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if second.Status != JobQueued {
+		t.Errorf("Ожидался статус queued, получено %s", second.Status)
+	}
+	if _, err := r.Submit(context.Background(), TriggerCron); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Ожидалась ошибка ErrQueueFull, получено %v", err)
+	}
+
+	// Отменяем первую задачу - вторая запускается автоматически
+	if _, err := r.Cancel(first.ID); err != nil {
+		t.Fatalf("Неожиданная ошибка отмены: %v", err)
+	}
+	if job := waitJob(t, r, first.ID); job.Status != JobCancelled {
+		t.Errorf("Ожидался статус cancelled, получено %s", job.Status)
+	}
+
+	close(release)
+	job := waitJob(t, r, second.ID)
+	if job.Status != JobSuccess {
+		t.Fatalf("Ожидался статус success, получено %s (%s)", job.Status, job.Error)
+	}
+	if job.Progress != 100 {
+		t.Errorf("Ожидался прогресс 100, получено %d", job.Progress)
+	}
+	if job.Stages[1].Status != StageFailed {
+		t.Errorf("Ошибка необязательного этапа должна помечать этап как failed, получено %s", job.Stages[1].Status)
+	}
+
+	if _, err := r.Cancel(second.ID); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("Ожидалась ошибка ErrJobFinished, получено %v", err)
+	}
+	if h := r.History(); len(h) != 2 || h[0].ID != second.ID {
+		t.Errorf("История должна начинаться с последней задачи: %+v", h)
+	}
+}
+
+func TestRunner_FailedStageStopsJob(t *testing.T) {
+	called := false
+	r := newRunner([]stage{
+		{
+			name: "convert",
+			run: func(ctx context.Context) (bool, error) {
+				return false, errors.New("битый файл")
+			},
+		},
+		{
+			name: "load",
+			run: func(ctx context.Context) (bool, error) {
+				called = true
+				return false, nil
+			},
+		},
+	})
+
+	submitted, err := r.Submit(context.Background(), TriggerManual)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	job := waitJob(t, r, submitted.ID)
+	if job.Status != JobFailed {
+		t.Errorf("Ожидался статус failed, получено %s", job.Status)
+	}
+	if called {
+		t.Error("Этап после ошибки не должен выполняться")
+	}
+	if job.Stages[1].Status != StagePending {
+		t.Errorf("Ожидался статус pending для пропущенного этапа, получено %s", job.Stages[1].Status)
+	}
+}
+
+// memoryHistory - хранилище истории в памяти
+type memoryHistory struct {
+	mu      sync.Mutex
+	records map[string]database.RefreshJobRecord
+}
+
+func (m *memoryHistory) Save(ctx context.Context, r database.RefreshJobRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[r.ID] = r
+	return nil
+}
+
+func (m *memoryHistory) Recent(ctx context.Context, limit int) ([]database.RefreshJobRecord, error) {
+	return nil, nil
+}
+
+func (m *memoryHistory) status(id string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.records[id].Status
+}
+
+func TestRunner_ShutdownInterruptsJobs(t *testing.T) {
+	r := newRunner([]stage{
+		{
+			name: "block",
+			run: func(ctx context.Context) (bool, error) {
+				<-ctx.Done()
+				return false, ctx.Err()
+			},
+		},
+	})
+	store := &memoryHistory{records: map[string]database.RefreshJobRecord{}}
+	if err := r.UseHistory(context.Background(), store); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+
+	running, err := r.Submit(context.Background(), TriggerManual)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	queued, err := r.Submit(context.Background(), TriggerCron)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+
+	// Задача не завершается сама - по истечении ожидания её отменяют
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := r.Shutdown(ctx); err == nil {
+		t.Error("Ожидалась ошибка о прерванной задаче")
+	}
+
+	for _, id := range []string{running.ID, queued.ID} {
+		job, _ := r.Get(id)
+		if job.Status != JobInterrupted {
+			t.Errorf("Задача %s: ожидался статус interrupted, получено %s", id, job.Status)
+		}
+		if got := store.status(id); got != JobInterrupted {
+			t.Errorf("Задача %s: в истории ожидался статус interrupted, получено %q", id, got)
+		}
+	}
+	if _, err := r.Submit(context.Background(), TriggerManual); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Ожидалась ошибка ErrShuttingDown, получено %v", err)
+	}
+}
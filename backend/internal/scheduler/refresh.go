@@ -1,14 +1,21 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"os"
 	"time"
 
 	"dashboard/internal/converter"
+	"dashboard/internal/logging"
+	"dashboard/internal/metrics"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var logger = logging.For("scheduler")
+
 type Scheduler struct {
 	projectRoot      string
 	attendanceInput  string
@@ -34,47 +41,88 @@ func NewScheduler(projectRoot, attendanceInput, attendanceOutput, statementInput
 
 // RefreshData обновляет данные, запуская оба конвертера
 // Проверяет изменения файлов перед конвертацией (оптимизация)
-func (s *Scheduler) RefreshData() error {
-	log.Println("[Scheduler] Начало обновления данных...")
+func (s *Scheduler) RefreshData(ctx context.Context) error {
+	logger.InfoContext(ctx, "Начало обновления данных")
+
+	if _, err := s.RefreshAttendance(ctx); err != nil {
+		return err
+	}
+	if _, err := s.RefreshStatement(ctx); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Обновление данных завершено")
+	return nil
+}
 
+// RefreshAttendance конвертирует файл посещаемости, если он изменился.
+// Возвращает false, если конвертация была пропущена.
+func (s *Scheduler) RefreshAttendance(ctx context.Context) (bool, error) {
 	// Проверяем наличие входных файлов и их изменения
-	if shouldUpdate, err := s.shouldUpdateFile(s.attendanceInput, s.attendanceOutput); err != nil {
-		log.Printf("[Scheduler] Предупреждение: %v", err)
-	} else if shouldUpdate {
-		// Конвертируем посещаемость
-		log.Println("[Scheduler] Конвертация посещаемости...")
-		if err := converter.ConvertAttendance(s.attendanceInput, s.attendanceOutput); err != nil {
-			return fmt.Errorf("ошибка конвертации посещаемости: %v", err)
-		}
-		// Обновляем время последнего изменения
-		if info, err := os.Stat(s.attendanceInput); err == nil {
-			s.lastModified[s.attendanceInput] = info.ModTime()
-		}
-		log.Println("[Scheduler] Посещаемость обновлена")
-	} else {
-		log.Println("[Scheduler] Посещаемость не изменилась, пропускаем")
+	shouldUpdate, err := s.shouldUpdateFile(s.attendanceInput, s.attendanceOutput)
+	if err != nil {
+		logger.WarnContext(ctx, "Посещаемость пропущена", "error", err)
+		return false, nil
+	}
+	if !shouldUpdate {
+		logger.InfoContext(ctx, "Посещаемость не изменилась, пропускаем")
+		return false, nil
 	}
 
+	// Конвертируем посещаемость
+	logger.InfoContext(ctx, "Конвертация посещаемости", "input", s.attendanceInput)
+	stats, err := converter.ConvertAttendance(ctx, s.attendanceInput, s.attendanceOutput)
+	if err != nil {
+		return false, fmt.Errorf("ошибка конвертации посещаемости: %v", err)
+	}
+	observeRows(ctx, "attendance", stats)
+	// Обновляем время последнего изменения
+	if info, err := os.Stat(s.attendanceInput); err == nil {
+		s.lastModified[s.attendanceInput] = info.ModTime()
+	}
+	logger.InfoContext(ctx, "Посещаемость обновлена")
+	return true, nil
+}
+
+// RefreshStatement конвертирует файл ведомости, если он изменился.
+// Возвращает false, если конвертация была пропущена.
+func (s *Scheduler) RefreshStatement(ctx context.Context) (bool, error) {
 	// Проверяем наличие файла ведомости и его изменения
-	if shouldUpdate, err := s.shouldUpdateFile(s.statementInput, s.statementOutput); err != nil {
-		log.Printf("[Scheduler] Предупреждение: %v", err)
-	} else if shouldUpdate {
-		// Конвертируем ведомость
-		log.Println("[Scheduler] Конвертация ведомости...")
-		if err := converter.ConvertStatement(s.statementInput, s.statementOutput, s.pythonScript); err != nil {
-			return fmt.Errorf("ошибка конвертации ведомости: %v", err)
-		}
-		// Обновляем время последнего изменения
-		if info, err := os.Stat(s.statementInput); err == nil {
-			s.lastModified[s.statementInput] = info.ModTime()
-		}
-		log.Println("[Scheduler] Ведомость обновлена")
-	} else {
-		log.Println("[Scheduler] Ведомость не изменилась, пропускаем")
+	shouldUpdate, err := s.shouldUpdateFile(s.statementInput, s.statementOutput)
+	if err != nil {
+		logger.WarnContext(ctx, "Ведомость пропущена", "error", err)
+		return false, nil
+	}
+	if !shouldUpdate {
+		logger.InfoContext(ctx, "Ведомость не изменилась, пропускаем")
+		return false, nil
 	}
 
-	log.Println("[Scheduler] Обновление данных завершено успешно!")
-	return nil
+	// Конвертируем ведомость
+	logger.InfoContext(ctx, "Конвертация ведомости", "input", s.statementInput)
+	stats, err := converter.ConvertStatement(ctx, s.statementInput, s.statementOutput, s.pythonScript)
+	if err != nil {
+		return false, fmt.Errorf("ошибка конвертации ведомости: %v", err)
+	}
+	observeRows(ctx, "statement", stats)
+	// Обновляем время последнего изменения
+	if info, err := os.Stat(s.statementInput); err == nil {
+		s.lastModified[s.statementInput] = info.ModTime()
+	}
+	logger.InfoContext(ctx, "Ведомость обновлена")
+	return true, nil
+}
+
+// observeRows пишет итог конвертации в лог, метрики и спан этапа
+func observeRows(ctx context.Context, name string, stats converter.Stats) {
+	logger.InfoContext(ctx, "Итог конвертации", "converter", name,
+		"imported", stats.Imported, "skipped", stats.Skipped, "failed", stats.Failed)
+	metrics.ObserveRows(name, stats.Imported, stats.Skipped, stats.Failed)
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int("rows.imported", stats.Imported),
+		attribute.Int("rows.skipped", stats.Skipped),
+		attribute.Int("rows.failed", stats.Failed),
+	)
 }
 
 // shouldUpdateFile проверяет, нужно ли обновлять файл
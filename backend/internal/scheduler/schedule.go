@@ -0,0 +1,217 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"dashboard/internal/quiet"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ScheduleConfig - расписание планового обновления
+type ScheduleConfig struct {
+	Specs    []string // cron-выражения (5 полей, @daily, @every 90m)
+	Location *time.Location
+	Quiet    []quiet.Window
+}
+
+// ScheduleStatus описывает расписание для статуса обновления
+type ScheduleStatus struct {
+	Schedules  []string   `json:"schedules"`
+	Timezone   string     `json:"timezone"`
+	QuietHours []string   `json:"quiet_hours"`
+	Paused     bool       `json:"paused"`
+	PausedAt   *time.Time `json:"paused_at,omitempty"`
+	PausedBy   string     `json:"paused_by,omitempty"`
+	NextRun    *time.Time `json:"next_run"`
+}
+
+// maxQuietSteps ограничивает перебор запусков, попадающих в окна тишины
+const maxQuietSteps = 10000
+
+// Schedule запускает задачи обновления по cron-расписанию. Расписание
+// можно приостановить и заменить без перезапуска сервера.
+type Schedule struct {
+	runner *Runner
+
+	mu       sync.Mutex
+	cfg      ScheduleConfig
+	cron     *cron.Cron
+	started  bool
+	paused   bool
+	pausedAt time.Time
+	pausedBy string
+}
+
+// NewSchedule создаёт расписание обновления для исполнителя задач
+func NewSchedule(runner *Runner, cfg ScheduleConfig) (*Schedule, error) {
+	s := &Schedule{runner: runner}
+	c, err := s.build(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.cfg, s.cron = cfg, c
+	return s, nil
+}
+
+// build создаёт cron с задачами по всем выражениям расписания
+func (s *Schedule) build(cfg ScheduleConfig) (*cron.Cron, error) {
+	if len(cfg.Specs) == 0 {
+		return nil, fmt.Errorf("расписание обновления пусто")
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	c := cron.New(cron.WithLocation(cfg.Location))
+	for _, spec := range cfg.Specs {
+		if _, err := c.AddFunc(spec, s.tick); err != nil {
+			return nil, fmt.Errorf("некорректное расписание %q: %v", spec, err)
+		}
+	}
+	return c, nil
+}
+
+// Start запускает расписание
+func (s *Schedule) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = true
+	s.cron.Start()
+}
+
+// Stop останавливает расписание, не дожидаясь выполняющихся задач
+func (s *Schedule) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = false
+	s.cron.Stop()
+}
+
+// Update заменяет расписание. При ошибке действующее расписание сохраняется.
+func (s *Schedule) Update(cfg ScheduleConfig) error {
+	c, err := s.build(cfg)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cron.Stop()
+	s.cfg, s.cron = cfg, c
+	if s.started {
+		s.cron.Start()
+	}
+	return nil
+}
+
+// Pause приостанавливает плановые обновления. Ручной запуск остаётся доступен.
+func (s *Schedule) Pause(by string) ScheduleStatus {
+	s.mu.Lock()
+	if !s.paused {
+		s.paused, s.pausedAt, s.pausedBy = true, time.Now(), by
+		logger.Info("Плановое обновление приостановлено", "by", by)
+	}
+	s.mu.Unlock()
+	return s.Status()
+}
+
+// Resume возобновляет плановые обновления
+func (s *Schedule) Resume() ScheduleStatus {
+	s.mu.Lock()
+	if s.paused {
+		s.paused, s.pausedAt, s.pausedBy = false, time.Time{}, ""
+		logger.Info("Плановое обновление возобновлено")
+	}
+	s.mu.Unlock()
+	return s.Status()
+}
+
+// Status возвращает расписание и время следующего запуска
+func (s *Schedule) Status() ScheduleStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := ScheduleStatus{
+		Schedules:  append([]string{}, s.cfg.Specs...),
+		Timezone:   s.location().String(),
+		QuietHours: make([]string, 0, len(s.cfg.Quiet)),
+		Paused:     s.paused,
+		PausedBy:   s.pausedBy,
+	}
+	for _, w := range s.cfg.Quiet {
+		st.QuietHours = append(st.QuietHours, w.String())
+	}
+	if s.paused {
+		at := s.pausedAt
+		st.PausedAt = &at
+		return st
+	}
+	if next := s.nextRun(time.Now()); !next.IsZero() {
+		st.NextRun = &next
+	}
+	return st
+}
+
+// nextRun ищет ближайший запуск вне окон тишины. Вызывается под s.mu.
+func (s *Schedule) nextRun(now time.Time) time.Time {
+	var best time.Time
+	for _, e := range s.cron.Entries() {
+		t := e.Next
+		if t.IsZero() {
+			t = e.Schedule.Next(now.In(s.location()))
+		}
+		for i := 0; i < maxQuietSteps && !t.IsZero() && s.quiet(t); i++ {
+			t = e.Schedule.Next(t)
+		}
+		if t.IsZero() || s.quiet(t) {
+			continue
+		}
+		if best.IsZero() || t.Before(best) {
+			best = t
+		}
+	}
+	return best
+}
+
+// quiet сообщает, попадает ли момент в окно тишины. Вызывается под s.mu.
+func (s *Schedule) quiet(t time.Time) bool {
+	t = t.In(s.location())
+	for _, w := range s.cfg.Quiet {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schedule) location() *time.Location {
+	if s.cfg.Location == nil {
+		return time.Local
+	}
+	return s.cfg.Location
+}
+
+// tick - обработчик cron: ставит задачу в очередь, если расписание
+// не приостановлено и сейчас не окно тишины
+func (s *Schedule) tick() {
+	s.mu.Lock()
+	paused, quiet := s.paused, s.quiet(time.Now())
+	s.mu.Unlock()
+
+	switch {
+	case paused:
+		logger.Info("Плановое обновление пропущено: расписание приостановлено")
+		return
+	case quiet:
+		logger.Info("Плановое обновление пропущено: окно тишины")
+		return
+	}
+	job, err := s.runner.Submit(context.Background(), TriggerCron)
+	if err != nil {
+		logger.Warn("Плановое обновление пропущено", "error", err)
+		return
+	}
+	logger.Info("Плановое обновление поставлено в очередь", "job_id", job.ID)
+}
@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"dashboard/internal/quiet"
+)
+
+func TestScheduleNextRun(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	windows, _ := quiet.Parse([]string{"12:00-13:00"})
+	s, err := NewSchedule(newRunner(nil), ScheduleConfig{
+		Specs:    []string{"*/30 8-17 * * 1-5", "0 2 * * *"},
+		Location: loc,
+		Quiet:    windows,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Понедельник 11:45 по Москве: 12:00 и 12:30 - окно тишины
+	now := time.Date(2026, 3, 2, 11, 45, 0, 0, loc)
+	s.mu.Lock()
+	next := s.nextRun(now)
+	s.mu.Unlock()
+	if want := time.Date(2026, 3, 2, 13, 0, 0, 0, loc); !next.Equal(want) {
+		t.Errorf("следующий запуск %v, ожидалось %v", next, want)
+	}
+
+	// Пятница 18:00: следующий запуск - ночной в субботу
+	now = time.Date(2026, 3, 6, 18, 0, 0, 0, loc)
+	s.mu.Lock()
+	next = s.nextRun(now)
+	s.mu.Unlock()
+	if want := time.Date(2026, 3, 7, 2, 0, 0, 0, loc); !next.Equal(want) {
+		t.Errorf("следующий запуск %v, ожидалось %v", next, want)
+	}
+
+	if st := s.Pause("admin"); !st.Paused || st.NextRun != nil || st.PausedBy != "admin" {
+		t.Errorf("приостановленное расписание: %+v", st)
+	}
+	if st := s.Resume(); st.Paused || st.NextRun == nil {
+		t.Errorf("возобновлённое расписание: %+v", st)
+	}
+
+	if err := s.Update(ScheduleConfig{Specs: []string{"каждый час"}}); err == nil {
+		t.Error("некорректное выражение должно отклоняться")
+	}
+	if st := s.Status(); len(st.Schedules) != 2 || st.Timezone != "MSK" {
+		t.Errorf("при ошибке расписание не должно меняться: %+v", st)
+	}
+}
@@ -1,34 +1,126 @@
 package services
 
 import (
-	"log"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
 
+	"dashboard/internal/database"
+	"dashboard/internal/logging"
 	"dashboard/internal/models"
 )
 
-// CheckAlerts проверяет пороги пропусков и логирует предупреждения
-func CheckAlerts(data []models.FlatRecord, threshold int) {
-	groupMissed := make(map[string]int)
-	groupStudents := make(map[string]map[string]struct{})
+var logger = logging.For("alerts")
 
-	// Собираем статистику по группам
-	for _, rec := range data {
-		groupMissed[rec.Group] += rec.Missed
-		if groupStudents[rec.Group] == nil {
-			groupStudents[rec.Group] = make(map[string]struct{})
-		}
-		groupStudents[rec.Group][rec.Student] = struct{}{}
+// RuleGroupAverage - имя встроенного правила "среднее по группе"
+const RuleGroupAverage = "group_average"
+
+// AlertListener получает каждый новый алерт
+type AlertListener func(models.Alert)
+
+// AlertService проверяет правила после каждого обновления
+// и сохраняет срабатывания в БД
+type AlertService struct {
+	store         *database.AlertStore
+	rules         *database.RuleStore
+	statementPath string
+	threshold     int
+
+	mu        sync.Mutex
+	listeners []AlertListener
+}
+
+// NewAlertService создаёт сервис алертов
+func NewAlertService(store *database.AlertStore, rules *database.RuleStore, statementPath string, threshold int) *AlertService {
+	return &AlertService{
+		store:         store,
+		rules:         rules,
+		statementPath: statementPath,
+		threshold:     threshold,
 	}
+}
 
-	// Проверяем пороги
-	for grp, missed := range groupMissed {
-		n := len(groupStudents[grp])
-		if n == 0 {
-			continue
-		}
-		avg := missed / n
-		if avg >= threshold {
-			log.Printf("[Alerts] ALERT: группа %s превысила порог пропусков: среднее %d часов на студента (порог: %d)", grp, avg, threshold)
+// AddListener подписывает функцию на новые алерты
+func (s *AlertService) AddListener(l AlertListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, l)
+}
+
+// Rules возвращает правила из БД. Пока правил нет (или БД недоступна),
+// действует встроенное правило по порогу из конфигурации.
+func (s *AlertService) Rules() []models.AlertRule {
+	rules, err := s.rules.List()
+	if err != nil || len(rules) == 0 {
+		s.mu.Lock()
+		threshold := s.threshold
+		s.mu.Unlock()
+		return DefaultRules(threshold)
+	}
+	return rules
+}
+
+// SetThreshold меняет порог встроенного правила (перезагрузка конфигурации)
+func (s *AlertService) SetThreshold(threshold int) {
+	s.mu.Lock()
+	s.threshold = threshold
+	s.mu.Unlock()
+}
+
+// Evaluate проверяет все включённые правила по снимку и ведомости,
+// синхронизирует алерты в БД и уведомляет слушателей о новых.
+func (s *AlertService) Evaluate(ctx context.Context, snap *Snapshot) ([]models.Alert, error) {
+	in := s.input(ctx, snap)
+
+	var detected []models.Alert
+	for _, rule := range s.Rules() {
+		detected = append(detected, EvaluateRule(rule, in)...)
+	}
+
+	// Алерты удалённых и выключенных правил тоже закрываются
+	created, resolved, err := s.store.Sync(detected, nil)
+	if err != nil {
+		return nil, err
+	}
+	logger.InfoContext(ctx, "Проверка алертов завершена", "detected", len(detected), "created", len(created), "resolved", resolved)
+
+	s.mu.Lock()
+	listeners := append([]AlertListener(nil), s.listeners...)
+	s.mu.Unlock()
+	for _, a := range created {
+		for _, l := range listeners {
+			l(a)
 		}
 	}
+	return created, nil
+}
+
+// Preview проверяет правило без сохранения результатов
+func (s *AlertService) Preview(ctx context.Context, rule models.AlertRule, snap *Snapshot) []models.Alert {
+	rule.Enabled = true
+	return EvaluateRule(rule, s.input(ctx, snap))
+}
+
+// input собирает данные для правил: снимок посещаемости и ведомость
+func (s *AlertService) input(ctx context.Context, snap *Snapshot) RuleInput {
+	in := RuleInput{Records: snap.Records, Now: time.Now()}
+	if s.statementPath == "" {
+		return in
+	}
+
+	raw, err := os.ReadFile(s.statementPath)
+	if err != nil {
+		logger.WarnContext(ctx, "Ведомость недоступна", "error", err)
+		return in
+	}
+	if err := json.Unmarshal(raw, &in.Statement); err != nil {
+		logger.WarnContext(ctx, "Ошибка разбора ведомости", "error", err)
+	}
+	return in
+}
+
+func roundTo2(v float64) float64 {
+	return float64(int64(v*100+0.5)) / 100
 }
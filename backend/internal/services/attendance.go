@@ -1,19 +1,33 @@
 package services
 
 import (
-	"encoding/json"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"dashboard/internal/logging"
 	"dashboard/internal/models"
+	"dashboard/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// AttendanceService предоставляет бизнес-логику для работы с посещаемостью
+var attendanceLog = logging.For("attendance")
+
+// AttendanceService предоставляет бизнес-логику для работы с посещаемостью.
+// Данные хранятся в памяти в виде неизменяемого снимка с индексами,
+// который атомарно заменяется после каждого успешного обновления.
 type AttendanceService struct {
 	attendancePath string
+	snapshot       atomic.Pointer[Snapshot]
+	reloadMu       sync.Mutex
 }
 
 // NewAttendanceService создаёт новый сервис
@@ -23,20 +37,46 @@ func NewAttendanceService(attendancePath string) *AttendanceService {
 	}
 }
 
-// LoadFromJSON загружает данные из JSON файла
-func (s *AttendanceService) LoadFromJSON() ([]models.DepartmentJSON, []models.FlatRecord, error) {
+// Snapshot возвращает текущий снимок данных, при первом обращении загружает его с диска
+func (s *AttendanceService) Snapshot(ctx context.Context) (*Snapshot, error) {
+	if snap := s.snapshot.Load(); snap != nil {
+		return snap, nil
+	}
+	snap, _, err := s.Reload(ctx)
+	return snap, err
+}
+
+// Reload перечитывает attendance.json и атомарно подменяет снимок.
+// changed=false, если содержимое файла не изменилось и снимок остался прежним.
+func (s *AttendanceService) Reload(ctx context.Context) (snap *Snapshot, changed bool, err error) {
+	ctx, span := tracing.Start(ctx, "attendance.Reload")
+	defer func() {
+		span.SetAttributes(attribute.Bool("changed", changed))
+		tracing.End(span, err)
+	}()
+
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
 	raw, err := os.ReadFile(s.attendancePath)
 	if err != nil {
-		return nil, nil, err
+		return nil, false, err
 	}
 
-	var departments []models.DepartmentJSON
-	if err := json.Unmarshal(raw, &departments); err != nil {
-		return nil, nil, err
+	current := s.snapshot.Load()
+	sum := sha256.Sum256(raw)
+	if current != nil && current.Version == hex.EncodeToString(sum[:8]) {
+		return current, false, nil
 	}
 
-	flat := models.Flatten(departments)
-	return departments, flat, nil
+	snap, err = ParseSnapshot(raw)
+	if err != nil {
+		return nil, false, err
+	}
+	s.snapshot.Store(snap)
+	span.SetAttributes(attribute.String("version", snap.Version), attribute.Int("records", len(snap.Records)))
+	attendanceLog.InfoContext(ctx, "Активирован снимок данных", "version", snap.Version, "records", len(snap.Records))
+	return snap, true, nil
 }
 
 // FilterParams параметры фильтрации
@@ -50,10 +90,29 @@ type FilterParams struct {
 	Period      string
 	Search      string
 	MissedMin   int
+
+	// Scope - область видимости пользователя. nil - без ограничений
+	// (внутренние вызовы); обработчики API всегда её заполняют.
+	Scope *models.Scope
 }
 
-// Filter фильтрует записи по параметрам
-func (s *AttendanceService) Filter(records []models.FlatRecord, params FilterParams) []models.FlatRecord {
+// DependsOnToday сообщает, зависит ли результат фильтрации от текущей даты
+func (p FilterParams) DependsOnToday() bool {
+	return p.Period != "" || p.Date == "today"
+}
+
+// Filter фильтрует записи снимка по параметрам
+func (s *AttendanceService) Filter(ctx context.Context, snap *Snapshot, params FilterParams) []models.FlatRecord {
+	_, span := tracing.Start(ctx, "attendance.Filter")
+	defer span.End()
+	out := filter(snap, params)
+	span.SetAttributes(attribute.Int("records", len(out)))
+	return out
+}
+
+// filter подбирает записи: кандидаты берутся из самого узкого подходящего
+// индекса, остальные условия проверяются только для них
+func filter(snap *Snapshot, params FilterParams) []models.FlatRecord {
 	today := time.Now().Format("2006-01-02")
 	var from, to string
 
@@ -73,64 +132,97 @@ func (s *AttendanceService) Filter(records []models.FlatRecord, params FilterPar
 		to = params.DateTo
 	}
 
-	searchLower := ""
+	exactDate := ""
+	if from == "" && to == "" {
+		if params.Date == "today" {
+			exactDate = today
+		} else {
+			exactDate = params.Date
+		}
+	}
+
+	// Выбираем наименьший список кандидатов среди индексов
+	candidates, indexed := []int(nil), false
+	narrow := func(ids []int) {
+		if !indexed || len(ids) < len(candidates) {
+			candidates, indexed = ids, true
+		}
+	}
+	if params.Department != "" {
+		narrow(snap.byDepartment[params.Department])
+	}
+	if params.Group != "" {
+		narrow(snap.byGroup[params.Group])
+	}
+	if params.Student != "" {
+		narrow(snap.byStudent[params.Student])
+	}
+	if exactDate != "" {
+		narrow(snap.byDate[exactDate])
+	}
+	restricted := params.Scope != nil && !params.Scope.All
+	if restricted {
+		narrow(snap.scopeRecords(*params.Scope))
+	}
+	if (from != "" || to != "") && (!indexed || len(candidates) > 0) {
+		if ids := snap.dateRange(from, to); !indexed || len(ids) < len(candidates) {
+			candidates, indexed = ids, true
+		}
+	}
+
+	var searchMatch []bool
 	if params.Search != "" {
-		searchLower = strings.ToLower(params.Search)
+		searchMatch = snap.matchSearch(strings.ToLower(params.Search))
 	}
 
-	out := make([]models.FlatRecord, 0, len(records))
-	for _, rec := range records {
-		// Фильтр по отделению
+	match := func(i int) bool {
+		rec := &snap.Records[i]
 		if params.Department != "" && rec.Department != params.Department {
-			continue
+			return false
 		}
-
-		// Фильтр по группе
 		if params.Group != "" && rec.Group != params.Group {
-			continue
+			return false
+		}
+		if restricted && !params.Scope.Allows(rec.Department, rec.Group) {
+			return false
 		}
-
-		// Фильтр по студенту
 		if params.Student != "" && rec.Student != params.Student {
-			continue
+			return false
 		}
-
-		// Поиск по подстроке
-		if searchLower != "" {
-			ok := strings.Contains(strings.ToLower(rec.Department), searchLower) ||
-				strings.Contains(strings.ToLower(rec.Group), searchLower) ||
-				strings.Contains(strings.ToLower(rec.Student), searchLower)
-			if !ok {
-				continue
-			}
+		if searchMatch != nil && !searchMatch[snap.recordKey[i]] {
+			return false
 		}
-
-		// Фильтр по минимальному количеству пропусков
 		if params.MissedMin >= 0 && rec.Missed < params.MissedMin {
-			continue
+			return false
+		}
+		if from != "" && rec.Date < from {
+			return false
 		}
+		if to != "" && rec.Date > to {
+			return false
+		}
+		if exactDate != "" && rec.Date != exactDate {
+			return false
+		}
+		return true
+	}
 
-		// Фильтр по дате
-		if from != "" || to != "" {
-			if from != "" && rec.Date < from {
-				continue
-			}
-			if to != "" && rec.Date > to {
-				continue
-			}
-		} else {
-			if params.Date == "today" {
-				if rec.Date != today {
-					continue
-				}
-			} else if params.Date != "" && rec.Date != params.Date {
-				continue
+	if !indexed {
+		out := make([]models.FlatRecord, 0, len(snap.Records))
+		for i := range snap.Records {
+			if match(i) {
+				out = append(out, snap.Records[i])
 			}
 		}
-
-		out = append(out, rec)
+		return out
 	}
 
+	out := make([]models.FlatRecord, 0, len(candidates))
+	for _, i := range candidates {
+		if match(i) {
+			out = append(out, snap.Records[i])
+		}
+	}
 	return out
 }
 
@@ -195,8 +287,10 @@ type StudentDrillItem struct {
 }
 
 // BuildSummary строит сводку по данным
-func (s *AttendanceService) BuildSummary(departments []models.DepartmentJSON, filtered []models.FlatRecord) SummaryResponse {
-	byDept := totalByDept(departments)
+func (s *AttendanceService) BuildSummary(ctx context.Context, snap *Snapshot, filtered []models.FlatRecord, scope *models.Scope) SummaryResponse {
+	_, span := tracing.Start(ctx, "attendance.BuildSummary", attribute.Int("records", len(filtered)))
+	defer span.End()
+	byDept, _ := snap.totals(scope)
 	absentSet := make(map[string]struct{})
 	deptAbsent := make(map[string]int)
 	deptMissed := make(map[string]int)
@@ -256,8 +350,10 @@ func (s *AttendanceService) BuildSummary(departments []models.DepartmentJSON, fi
 }
 
 // BuildDrillDepartments строит drill-down по отделениям
-func (s *AttendanceService) BuildDrillDepartments(departments []models.DepartmentJSON, filtered []models.FlatRecord) []DeptDrillItem {
-	byDept := totalByDept(departments)
+func (s *AttendanceService) BuildDrillDepartments(ctx context.Context, snap *Snapshot, filtered []models.FlatRecord, scope *models.Scope) []DeptDrillItem {
+	_, span := tracing.Start(ctx, "attendance.BuildDrillDepartments", attribute.Int("records", len(filtered)))
+	defer span.End()
+	byDept, _ := snap.totals(scope)
 	deptAbsent := make(map[string]int)
 	deptMissed := make(map[string]int)
 	seen := make(map[string]map[string]struct{})
@@ -297,8 +393,10 @@ func (s *AttendanceService) BuildDrillDepartments(departments []models.Departmen
 }
 
 // BuildDrillGroups строит drill-down по группам
-func (s *AttendanceService) BuildDrillGroups(departments []models.DepartmentJSON, filtered []models.FlatRecord, department string) []GroupDrillItem {
-	byGroup := totalByGroup(departments)
+func (s *AttendanceService) BuildDrillGroups(ctx context.Context, snap *Snapshot, filtered []models.FlatRecord, department string, scope *models.Scope) []GroupDrillItem {
+	_, span := tracing.Start(ctx, "attendance.BuildDrillGroups", attribute.Int("records", len(filtered)))
+	defer span.End()
+	_, byGroup := snap.totals(scope)
 	if byGroup[department] == nil {
 		return []GroupDrillItem{}
 	}
@@ -334,7 +432,9 @@ func (s *AttendanceService) BuildDrillGroups(departments []models.DepartmentJSON
 }
 
 // BuildDrillStudents строит drill-down по студентам
-func (s *AttendanceService) BuildDrillStudents(filtered []models.FlatRecord, department, group string) []StudentDrillItem {
+func (s *AttendanceService) BuildDrillStudents(ctx context.Context, filtered []models.FlatRecord, department, group string) []StudentDrillItem {
+	_, span := tracing.Start(ctx, "attendance.BuildDrillStudents", attribute.Int("records", len(filtered)))
+	defer span.End()
 	type agg struct {
 		missed int
 		dates  []string
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"dashboard/internal/converter"
+	"dashboard/internal/models"
+)
+
+// RuleInput данные, по которым проверяются правила
+type RuleInput struct {
+	Records   []models.FlatRecord
+	Statement []converter.DepartmentSummary
+	Now       time.Time
+}
+
+// DefaultRules возвращает встроенное правило, которое действует, пока
+// в БД не заведено ни одного правила (порог ABSENCE_THRESHOLD из конфигурации)
+func DefaultRules(threshold int) []models.AlertRule {
+	return []models.AlertRule{{
+		Name:              RuleGroupAverage,
+		Type:              models.RuleTypeGroupAverage,
+		Description:       "Среднее число пропущенных часов на студента в группе",
+		Enabled:           true,
+		Threshold:         float64(threshold),
+		CriticalThreshold: float64(2 * threshold),
+	}}
+}
+
+// ValidateRule проверяет корректность описания правила
+func ValidateRule(r *models.AlertRule) error {
+	if r.Name == "" {
+		return fmt.Errorf("не указано имя правила")
+	}
+	switch r.Type {
+	case models.RuleTypeGroupAverage, models.RuleTypeStudentHours, models.RuleTypeAbsenceStreak, models.RuleTypeUnexcused:
+	case models.RuleTypeScheduledPercent:
+		if r.WindowDays <= 0 {
+			return fmt.Errorf("для правила %s нужно указать window_days", r.Type)
+		}
+		if r.ScheduledHoursPerDay <= 0 {
+			return fmt.Errorf("для правила %s нужно указать scheduled_hours_per_day", r.Type)
+		}
+	default:
+		return fmt.Errorf("неизвестный тип правила: %q", r.Type)
+	}
+	if r.Threshold <= 0 {
+		return fmt.Errorf("порог должен быть больше нуля")
+	}
+	if r.CriticalThreshold != 0 && r.CriticalThreshold < r.Threshold {
+		return fmt.Errorf("критический порог не может быть меньше основного")
+	}
+	if r.WindowDays < 0 {
+		return fmt.Errorf("window_days не может быть отрицательным")
+	}
+	for dept, o := range r.Overrides {
+		if o.Threshold != nil && *o.Threshold <= 0 {
+			return fmt.Errorf("порог для отделения %s должен быть больше нуля", dept)
+		}
+	}
+	return nil
+}
+
+// EvaluateRule проверяет одно правило и возвращает срабатывания
+func EvaluateRule(rule models.AlertRule, in RuleInput) []models.Alert {
+	if in.Now.IsZero() {
+		in.Now = time.Now()
+	}
+	records := in.Records
+	if rule.WindowDays > 0 {
+		records = windowRecords(records, in.Now, rule.WindowDays)
+	}
+
+	switch rule.Type {
+	case models.RuleTypeGroupAverage:
+		return evaluateGroupAverage(rule, records)
+	case models.RuleTypeStudentHours:
+		return evaluateStudentHours(rule, records)
+	case models.RuleTypeAbsenceStreak:
+		return evaluateAbsenceStreak(rule, records)
+	case models.RuleTypeScheduledPercent:
+		return evaluateScheduledPercent(rule, records, in.Now)
+	case models.RuleTypeUnexcused:
+		return evaluateUnexcused(rule, in.Statement)
+	}
+	return nil
+}
+
+// check сравнивает значение с порогами правила для отделения
+func check(rule models.AlertRule, department string, value float64) (severity string, threshold float64, ok bool) {
+	threshold, critical, enabled := rule.ForDepartment(department)
+	if !enabled || value < threshold {
+		return "", threshold, false
+	}
+	if critical > 0 && value >= critical {
+		return models.SeverityCritical, threshold, true
+	}
+	return models.SeverityWarning, threshold, true
+}
+
+// windowRecords оставляет записи за последние days дней, включая сегодняшний:
+// те же дни, что считает countSchoolDays(now-days, now)
+func windowRecords(records []models.FlatRecord, now time.Time, days int) []models.FlatRecord {
+	from := now.AddDate(0, 0, -(days - 1)).Format("2006-01-02")
+	to := now.Format("2006-01-02")
+	out := make([]models.FlatRecord, 0, len(records))
+	for _, rec := range records {
+		if rec.Date >= from && rec.Date <= to {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// windowSuffix поясняет окно правила в тексте алерта
+func windowSuffix(rule models.AlertRule) string {
+	if rule.WindowDays > 0 {
+		return fmt.Sprintf(" за %d дн.", rule.WindowDays)
+	}
+	return ""
+}
+
+type studentKey struct {
+	department string
+	group      string
+	student    string
+}
+
+// studentTotals суммирует пропуски по студентам в порядке первого появления
+func studentTotals(records []models.FlatRecord) ([]studentKey, map[studentKey]int) {
+	totals := make(map[studentKey]int)
+	var order []studentKey
+	for _, rec := range records {
+		k := studentKey{rec.Department, rec.Group, rec.Student}
+		if _, ok := totals[k]; !ok {
+			order = append(order, k)
+		}
+		totals[k] += rec.Missed
+	}
+	return order, totals
+}
+
+func evaluateGroupAverage(rule models.AlertRule, records []models.FlatRecord) []models.Alert {
+	type groupKey struct {
+		department string
+		group      string
+	}
+	groupMissed := make(map[groupKey]int)
+	groupStudents := make(map[groupKey]map[string]struct{})
+	var order []groupKey
+
+	// Собираем статистику по группам
+	for _, rec := range records {
+		k := groupKey{rec.Department, rec.Group}
+		if groupStudents[k] == nil {
+			groupStudents[k] = make(map[string]struct{})
+			order = append(order, k)
+		}
+		groupMissed[k] += rec.Missed
+		groupStudents[k][rec.Student] = struct{}{}
+	}
+
+	var out []models.Alert
+	for _, k := range order {
+		n := len(groupStudents[k])
+		if n == 0 {
+			continue
+		}
+		avg := float64(groupMissed[k]) / float64(n)
+		severity, threshold, ok := check(rule, k.department, avg)
+		if !ok {
+			continue
+		}
+		out = append(out, models.Alert{
+			Rule:        rule.Name,
+			Severity:    severity,
+			SubjectType: models.SubjectGroup,
+			Department:  k.department,
+			Group:       k.group,
+			MetricValue: roundTo2(avg),
+			Threshold:   threshold,
+			Message: fmt.Sprintf("Группа %s превысила порог пропусков%s: среднее %.1f ч на студента (порог: %g)",
+				k.group, windowSuffix(rule), avg, threshold),
+		})
+	}
+	return out
+}
+
+func evaluateStudentHours(rule models.AlertRule, records []models.FlatRecord) []models.Alert {
+	order, totals := studentTotals(records)
+
+	var out []models.Alert
+	for _, k := range order {
+		value := float64(totals[k])
+		severity, threshold, ok := check(rule, k.department, value)
+		if !ok {
+			continue
+		}
+		out = append(out, studentAlert(rule, k, severity, value, threshold,
+			fmt.Sprintf("Студент %s (группа %s) пропустил %g ч%s (порог: %g)",
+				k.student, k.group, value, windowSuffix(rule), threshold)))
+	}
+	return out
+}
+
+func evaluateAbsenceStreak(rule models.AlertRule, records []models.FlatRecord) []models.Alert {
+	dates := make(map[studentKey]map[string]struct{})
+	var order []studentKey
+	for _, rec := range records {
+		if rec.Missed <= 0 {
+			continue
+		}
+		k := studentKey{rec.Department, rec.Group, rec.Student}
+		if dates[k] == nil {
+			dates[k] = make(map[string]struct{})
+			order = append(order, k)
+		}
+		dates[k][rec.Date] = struct{}{}
+	}
+
+	var out []models.Alert
+	for _, k := range order {
+		streak := longestStreak(dates[k], rule.FiveDayWeek)
+		value := float64(streak)
+		severity, threshold, ok := check(rule, k.department, value)
+		if !ok {
+			continue
+		}
+		out = append(out, studentAlert(rule, k, severity, value, threshold,
+			fmt.Sprintf("Студент %s (группа %s) пропускает занятия %d учебных дней подряд (порог: %g)",
+				k.student, k.group, streak, threshold)))
+	}
+	return out
+}
+
+func evaluateScheduledPercent(rule models.AlertRule, records []models.FlatRecord, now time.Time) []models.Alert {
+	from := now.AddDate(0, 0, -rule.WindowDays)
+	planned := float64(countSchoolDays(from, now, rule.FiveDayWeek)) * rule.ScheduledHoursPerDay
+	if planned <= 0 {
+		return nil
+	}
+
+	order, totals := studentTotals(records)
+	var out []models.Alert
+	for _, k := range order {
+		value := roundTo2(float64(totals[k]) / planned * 100)
+		severity, threshold, ok := check(rule, k.department, value)
+		if !ok {
+			continue
+		}
+		out = append(out, studentAlert(rule, k, severity, value, threshold,
+			fmt.Sprintf("Студент %s (группа %s) пропустил %.1f%% запланированных часов%s (порог: %g%%)",
+				k.student, k.group, value, windowSuffix(rule), threshold)))
+	}
+	return out
+}
+
+func evaluateUnexcused(rule models.AlertRule, statement []converter.DepartmentSummary) []models.Alert {
+	var out []models.Alert
+	for _, d := range statement {
+		for _, spec := range d.Specialties {
+			for _, g := range spec.Groups {
+				for _, st := range g.Students {
+					value := float64(st.MissedBad)
+					severity, threshold, ok := check(rule, d.Department, value)
+					if !ok {
+						continue
+					}
+					k := studentKey{d.Department, g.Group, st.Student}
+					out = append(out, studentAlert(rule, k, severity, value, threshold,
+						fmt.Sprintf("Студент %s (группа %s) пропустил %g ч без уважительной причины (порог: %g)",
+							k.student, k.group, value, threshold)))
+				}
+			}
+		}
+	}
+	return out
+}
+
+func studentAlert(rule models.AlertRule, k studentKey, severity string, value, threshold float64, message string) models.Alert {
+	return models.Alert{
+		Rule:        rule.Name,
+		Severity:    severity,
+		SubjectType: models.SubjectStudent,
+		Department:  k.department,
+		Group:       k.group,
+		Student:     k.student,
+		MetricValue: value,
+		Threshold:   threshold,
+		Message:     message,
+	}
+}
+
+// isSchoolDay - воскресенье всегда выходной, суббота - при пятидневке
+func isSchoolDay(t time.Time, fiveDayWeek bool) bool {
+	switch t.Weekday() {
+	case time.Sunday:
+		return false
+	case time.Saturday:
+		return !fiveDayWeek
+	}
+	return true
+}
+
+// countSchoolDays считает учебные дни в интервале (from, to]
+func countSchoolDays(from, to time.Time, fiveDayWeek bool) int {
+	n := 0
+	for d := from.AddDate(0, 0, 1); !d.After(to); d = d.AddDate(0, 0, 1) {
+		if isSchoolDay(d, fiveDayWeek) {
+			n++
+		}
+	}
+	return n
+}
+
+// longestStreak находит самую длинную серию учебных дней подряд с пропусками
+func longestStreak(dates map[string]struct{}, fiveDayWeek bool) int {
+	days := make([]time.Time, 0, len(dates))
+	for s := range dates {
+		if t, err := time.Parse("2006-01-02", s); err == nil {
+			days = append(days, t)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	best, cur := 0, 0
+	for i, d := range days {
+		if i > 0 && nextSchoolDay(days[i-1], fiveDayWeek).Equal(d) {
+			cur++
+		} else {
+			cur = 1
+		}
+		if cur > best {
+			best = cur
+		}
+	}
+	return best
+}
+
+func nextSchoolDay(t time.Time, fiveDayWeek bool) time.Time {
+	next := t.AddDate(0, 0, 1)
+	for !isSchoolDay(next, fiveDayWeek) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"dashboard/internal/converter"
+	"dashboard/internal/models"
+)
+
+func TestEvaluateRule_GroupAverage(t *testing.T) {
+	data := []models.FlatRecord{
+		// Группа 11: 2 студента, 21 час - среднее 10.5 (раньше целочисленное деление давало 10)
+		{Department: "Отделение 1", Group: "11", Student: "А", Date: "2025-09-01", Missed: 12},
+		{Department: "Отделение 1", Group: "11", Student: "Б", Date: "2025-09-01", Missed: 9},
+		// Группа 12: среднее 9.5 - ниже порога
+		{Department: "Отделение 1", Group: "12", Student: "В", Date: "2025-09-01", Missed: 10},
+		{Department: "Отделение 1", Group: "12", Student: "Г", Date: "2025-09-01", Missed: 9},
+		// Группа 13: среднее 25 - критический уровень
+		{Department: "Отделение 2", Group: "13", Student: "Д", Date: "2025-09-01", Missed: 25},
+	}
+
+	alerts := EvaluateRule(DefaultRules(10)[0], RuleInput{Records: data})
+	if len(alerts) != 2 {
+		t.Fatalf("Ожидалось 2 алерта, получено %d: %+v", len(alerts), alerts)
+	}
+
+	if alerts[0].Group != "11" || alerts[0].MetricValue != 10.5 || alerts[0].Severity != models.SeverityWarning {
+		t.Errorf("Неверный алерт для группы 11: %+v", alerts[0])
+	}
+	if alerts[1].Group != "13" || alerts[1].Severity != models.SeverityCritical {
+		t.Errorf("Неверный алерт для группы 13: %+v", alerts[1])
+	}
+	if alerts[0].MakeFingerprint() == alerts[1].MakeFingerprint() {
+		t.Error("Алерты разных групп должны иметь разные ключи")
+	}
+}
+
+func TestEvaluateRule_StudentHoursWindowAndOverride(t *testing.T) {
+	now := time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+	data := []models.FlatRecord{
+		// Старый пропуск вне окна 14 дней не учитывается
+		{Department: "Отделение 1", Group: "11", Student: "А", Date: "2025-09-01", Missed: 20},
+		{Department: "Отделение 1", Group: "11", Student: "А", Date: "2025-10-10", Missed: 8},
+		{Department: "Отделение 1", Group: "11", Student: "Б", Date: "2025-10-10", Missed: 14},
+		{Department: "Отделение 2", Group: "21", Student: "В", Date: "2025-10-10", Missed: 14},
+	}
+
+	higher := 16.0
+	rule := models.AlertRule{
+		Name:       "hours-14d",
+		Type:       models.RuleTypeStudentHours,
+		Enabled:    true,
+		Threshold:  12,
+		WindowDays: 14,
+		Overrides:  map[string]models.RuleOverride{"Отделение 2": {Threshold: &higher}},
+	}
+	if err := ValidateRule(&rule); err != nil {
+		t.Fatalf("Правило должно быть корректным: %v", err)
+	}
+
+	alerts := EvaluateRule(rule, RuleInput{Records: data, Now: now})
+	if len(alerts) != 1 || alerts[0].Student != "Б" {
+		t.Fatalf("Ожидался один алерт для студента Б, получено: %+v", alerts)
+	}
+	if alerts[0].SubjectType != models.SubjectStudent || alerts[0].Rule != "hours-14d" {
+		t.Errorf("Неверные поля алерта: %+v", alerts[0])
+	}
+}
+
+func TestEvaluateRule_AbsenceStreak(t *testing.T) {
+	// Пт 03.10, Сб 04.10, Пн 06.10 - три учебных дня подряд при шестидневке
+	data := []models.FlatRecord{
+		{Department: "Отделение 1", Group: "11", Student: "А", Date: "2025-10-03", Missed: 2},
+		{Department: "Отделение 1", Group: "11", Student: "А", Date: "2025-10-04", Missed: 2},
+		{Department: "Отделение 1", Group: "11", Student: "А", Date: "2025-10-06", Missed: 2},
+		{Department: "Отделение 1", Group: "11", Student: "Б", Date: "2025-10-03", Missed: 2},
+		{Department: "Отделение 1", Group: "11", Student: "Б", Date: "2025-10-06", Missed: 2},
+	}
+	rule := models.AlertRule{Name: "streak", Type: models.RuleTypeAbsenceStreak, Enabled: true, Threshold: 3}
+
+	alerts := EvaluateRule(rule, RuleInput{Records: data})
+	if len(alerts) != 1 || alerts[0].Student != "А" || alerts[0].MetricValue != 3 {
+		t.Fatalf("Ожидалась серия из 3 дней у студента А, получено: %+v", alerts)
+	}
+
+	// При пятидневке у студента Б пятница и понедельник тоже идут подряд
+	rule.FiveDayWeek = true
+	rule.Threshold = 2
+	if alerts := EvaluateRule(rule, RuleInput{Records: data}); len(alerts) != 2 {
+		t.Errorf("Ожидалось 2 алерта при пятидневке, получено: %+v", alerts)
+	}
+}
+
+func TestEvaluateRule_ScheduledPercentAndUnexcused(t *testing.T) {
+	now := time.Date(2025, 10, 13, 12, 0, 0, 0, time.UTC) // понедельник
+	// Окно 7 дней при шестидневке - 6 учебных дней по 6 часов = 36 часов
+	data := []models.FlatRecord{
+		{Department: "Отделение 1", Group: "11", Student: "А", Date: "2025-10-10", Missed: 9},
+	}
+	rule := models.AlertRule{
+		Name: "percent", Type: models.RuleTypeScheduledPercent, Enabled: true,
+		Threshold: 25, WindowDays: 7, ScheduledHoursPerDay: 6,
+	}
+	alerts := EvaluateRule(rule, RuleInput{Records: data, Now: now})
+	if len(alerts) != 1 || alerts[0].MetricValue != 25 {
+		t.Fatalf("Ожидалось 25%% пропусков, получено: %+v", alerts)
+	}
+
+	statement := []converter.DepartmentSummary{{
+		Department: "Отделение 1",
+		Specialties: []converter.SpecialtySummary{{
+			Groups: []converter.GroupSummary{{
+				Group: "11",
+				Students: []converter.StudentSummary{
+					{Student: "А", MissedBad: 10, MissedTotal: 30},
+					{Student: "Б", MissedBad: 2, MissedTotal: 40},
+				},
+			}},
+		}},
+	}}
+	unexcused := models.AlertRule{Name: "unexcused", Type: models.RuleTypeUnexcused, Enabled: true, Threshold: 10}
+	alerts = EvaluateRule(unexcused, RuleInput{Statement: statement})
+	if len(alerts) != 1 || alerts[0].Student != "А" {
+		t.Errorf("Ожидался алерт по неуважительным пропускам у студента А, получено: %+v", alerts)
+	}
+}
+
+func TestWindowBoundary(t *testing.T) {
+	now := time.Date(2025, 10, 13, 12, 0, 0, 0, time.UTC) // понедельник
+	data := []models.FlatRecord{
+		// Окно 7 дней - с 07.10 по 13.10; 06.10 уже восьмой день
+		{Department: "Отделение 1", Group: "11", Student: "А", Date: "2025-10-06", Missed: 10},
+		{Department: "Отделение 1", Group: "11", Student: "Б", Date: "2025-10-07", Missed: 10},
+		{Department: "Отделение 1", Group: "11", Student: "В", Date: "2025-10-13", Missed: 10},
+	}
+	got := windowRecords(data, now, 7)
+	if len(got) != 2 || got[0].Student != "Б" || got[1].Student != "В" {
+		t.Errorf("Ожидались записи Б и В, получено: %+v", got)
+	}
+	// Учебных дней за те же 7 дней при шестидневке - 6 (без воскресенья 12.10)
+	if n := countSchoolDays(now.AddDate(0, 0, -7), now, false); n != 6 {
+		t.Errorf("Ожидалось 6 учебных дней, получено %d", n)
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	bad := []models.AlertRule{
+		{Name: "", Type: models.RuleTypeStudentHours, Threshold: 1},
+		{Name: "x", Type: "unknown", Threshold: 1},
+		{Name: "x", Type: models.RuleTypeStudentHours, Threshold: 0},
+		{Name: "x", Type: models.RuleTypeScheduledPercent, Threshold: 10},
+		{Name: "x", Type: models.RuleTypeStudentHours, Threshold: 10, CriticalThreshold: 5},
+	}
+	for _, r := range bad {
+		if err := ValidateRule(&r); err == nil {
+			t.Errorf("Ожидалась ошибка проверки для правила %+v", r)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"dashboard/internal/models"
+)
+
+// Snapshot - неизменяемый снимок данных посещаемости с индексами.
+// После построения снимок только читается, поэтому его безопасно
+// использовать из нескольких запросов одновременно.
+type Snapshot struct {
+	Departments []models.DepartmentJSON
+	Records     []models.FlatRecord
+
+	// Version - хэш содержимого attendance.json, используется в ETag
+	Version  string
+	LoadedAt time.Time
+
+	byDepartment map[string][]int
+	byGroup      map[string][]int
+	byStudent    map[string][]int
+	byDate       map[string][]int
+
+	// dateOrder - индексы записей, отсортированные по дате (для диапазонов)
+	dateOrder []int
+
+	// Поисковый индекс: для каждой записи - номер тройки
+	// (отделение, группа, студент), для каждой тройки - строка в нижнем регистре
+	recordKey  []int
+	searchKeys []string
+
+	totalByDept  map[string]int
+	totalByGroup map[string]map[string]int
+}
+
+// SnapshotInfo - краткое описание снимка для API и событий
+type SnapshotInfo struct {
+	Version  string    `json:"version"`
+	LoadedAt time.Time `json:"loaded_at"`
+	Records  int       `json:"records"`
+}
+
+// Info возвращает краткое описание снимка
+func (s *Snapshot) Info() SnapshotInfo {
+	return SnapshotInfo{
+		Version:  s.Version,
+		LoadedAt: s.LoadedAt,
+		Records:  len(s.Records),
+	}
+}
+
+// ParseSnapshot строит снимок из содержимого attendance.json
+func ParseSnapshot(raw []byte) (*Snapshot, error) {
+	var departments []models.DepartmentJSON
+	if err := json.Unmarshal(raw, &departments); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(raw)
+	return NewSnapshot(departments, hex.EncodeToString(sum[:8])), nil
+}
+
+// NewSnapshot строит индексы по иерархии отделений
+func NewSnapshot(departments []models.DepartmentJSON, version string) *Snapshot {
+	records := models.Flatten(departments)
+
+	s := &Snapshot{
+		Departments:  departments,
+		Records:      records,
+		Version:      version,
+		LoadedAt:     time.Now(),
+		byDepartment: make(map[string][]int),
+		byGroup:      make(map[string][]int),
+		byStudent:    make(map[string][]int),
+		byDate:       make(map[string][]int),
+		dateOrder:    make([]int, len(records)),
+		recordKey:    make([]int, len(records)),
+		totalByDept:  totalByDept(departments),
+		totalByGroup: totalByGroup(departments),
+	}
+
+	keys := make(map[string]int)
+	for i, rec := range records {
+		s.byDepartment[rec.Department] = append(s.byDepartment[rec.Department], i)
+		s.byGroup[rec.Group] = append(s.byGroup[rec.Group], i)
+		s.byStudent[rec.Student] = append(s.byStudent[rec.Student], i)
+		s.byDate[rec.Date] = append(s.byDate[rec.Date], i)
+		s.dateOrder[i] = i
+
+		k := rec.Department + "\x00" + rec.Group + "\x00" + rec.Student
+		id, ok := keys[k]
+		if !ok {
+			id = len(s.searchKeys)
+			keys[k] = id
+			s.searchKeys = append(s.searchKeys, strings.ToLower(k))
+		}
+		s.recordKey[i] = id
+	}
+
+	sort.SliceStable(s.dateOrder, func(a, b int) bool {
+		return records[s.dateOrder[a]].Date < records[s.dateOrder[b]].Date
+	})
+
+	return s
+}
+
+// dateRange возвращает индексы записей с датой в диапазоне [from, to].
+// Пустая граница означает отсутствие ограничения.
+func (s *Snapshot) dateRange(from, to string) []int {
+	lo := 0
+	if from != "" {
+		lo = sort.Search(len(s.dateOrder), func(i int) bool {
+			return s.Records[s.dateOrder[i]].Date >= from
+		})
+	}
+	hi := len(s.dateOrder)
+	if to != "" {
+		hi = sort.Search(len(s.dateOrder), func(i int) bool {
+			return s.Records[s.dateOrder[i]].Date > to
+		})
+	}
+	if lo >= hi {
+		return nil
+	}
+
+	out := append([]int(nil), s.dateOrder[lo:hi]...)
+	sort.Ints(out)
+	return out
+}
+
+// matchSearch возвращает отметки троек (отделение, группа, студент),
+// содержащих подстроку в нижнем регистре
+func (s *Snapshot) matchSearch(searchLower string) []bool {
+	out := make([]bool, len(s.searchKeys))
+	for i, k := range s.searchKeys {
+		out[i] = strings.Contains(k, searchLower)
+	}
+	return out
+}
+
+// totals возвращает число студентов по отделениям и группам
+// в пределах области видимости
+func (s *Snapshot) totals(scope *models.Scope) (map[string]int, map[string]map[string]int) {
+	if scope == nil || scope.All {
+		return s.totalByDept, s.totalByGroup
+	}
+
+	byDept := make(map[string]int)
+	byGroup := make(map[string]map[string]int)
+	for dept, groups := range s.totalByGroup {
+		for grp, n := range groups {
+			if !scope.Allows(dept, grp) {
+				continue
+			}
+			if byGroup[dept] == nil {
+				byGroup[dept] = make(map[string]int)
+			}
+			byGroup[dept][grp] = n
+			if n > 0 {
+				byDept[dept] += n
+			}
+		}
+	}
+	return byDept, byGroup
+}
+
+// scopeRecords возвращает отсортированные индексы записей,
+// входящих в отделения и группы области видимости
+func (s *Snapshot) scopeRecords(scope models.Scope) []int {
+	var ids []int
+	for _, d := range scope.Departments {
+		ids = append(ids, s.byDepartment[d]...)
+	}
+	for _, g := range scope.Groups {
+		ids = append(ids, s.byGroup[g]...)
+	}
+	sort.Ints(ids)
+
+	out := ids[:0]
+	for i, id := range ids {
+		if i == 0 || id != ids[i-1] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
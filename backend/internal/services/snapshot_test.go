@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"dashboard/internal/models"
+)
+
+// buildTestDepartments генерирует данные: depts отделений по groups групп по
+// students студентов, у каждого студента days дней с пропусками
+func buildTestDepartments(depts, groups, students, days int) []models.DepartmentJSON {
+	start := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	out := make([]models.DepartmentJSON, 0, depts)
+	for d := 0; d < depts; d++ {
+		dept := models.DepartmentJSON{Department: fmt.Sprintf("Отделение %d", d)}
+		for g := 0; g < groups; g++ {
+			grp := models.GroupJSON{Group: fmt.Sprintf("%d%d-ис", d, g)}
+			for st := 0; st < students; st++ {
+				stu := models.StudentJSON{Student: fmt.Sprintf("Иванов%d%d%d Иван Иванович", d, g, st)}
+				for day := 0; day < days; day++ {
+					stu.Attendance = append(stu.Attendance, models.AttendanceRecordJSON{
+						Date:   start.AddDate(0, 0, day).Format("2006-01-02"),
+						Missed: (st + day) % 5,
+					})
+				}
+				grp.Students = append(grp.Students, stu)
+			}
+			dept.Groups = append(dept.Groups, grp)
+		}
+		out = append(out, dept)
+	}
+	return out
+}
+
+// linearFilter - прежняя реализация фильтра полным перебором, эталон для тестов и бенчмарков
+func linearFilter(records []models.FlatRecord, params FilterParams) []models.FlatRecord {
+	searchLower := strings.ToLower(params.Search)
+	out := make([]models.FlatRecord, 0, len(records))
+	for _, rec := range records {
+		if params.Department != "" && rec.Department != params.Department {
+			continue
+		}
+		if params.Group != "" && rec.Group != params.Group {
+			continue
+		}
+		if params.Student != "" && rec.Student != params.Student {
+			continue
+		}
+		if searchLower != "" {
+			ok := strings.Contains(strings.ToLower(rec.Department), searchLower) ||
+				strings.Contains(strings.ToLower(rec.Group), searchLower) ||
+				strings.Contains(strings.ToLower(rec.Student), searchLower)
+			if !ok {
+				continue
+			}
+		}
+		if params.MissedMin >= 0 && rec.Missed < params.MissedMin {
+			continue
+		}
+		if params.DateFrom != "" && rec.Date < params.DateFrom {
+			continue
+		}
+		if params.DateTo != "" && rec.Date > params.DateTo {
+			continue
+		}
+		if params.DateFrom == "" && params.DateTo == "" && params.Date != "" && rec.Date != params.Date {
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+var filterCases = map[string]FilterParams{
+	"all":        {MissedMin: -1},
+	"department": {Department: "Отделение 3", MissedMin: -1},
+	"group":      {Group: "31-ис", MissedMin: 2},
+	"student":    {Student: "Иванов315 Иван Иванович", MissedMin: -1},
+	"date":       {Date: "2025-09-10", MissedMin: -1},
+	"range":      {Department: "Отделение 1", DateFrom: "2025-09-05", DateTo: "2025-09-12", MissedMin: -1},
+	"search":     {Search: "иванов31", MissedMin: 1},
+	"empty":      {Department: "нет такого", MissedMin: -1},
+}
+
+func TestAttendanceService_FilterMatchesLinearScan(t *testing.T) {
+	snap := NewSnapshot(buildTestDepartments(5, 4, 20, 30), "test")
+	svc := &AttendanceService{}
+
+	for name, params := range filterCases {
+		t.Run(name, func(t *testing.T) {
+			got := svc.Filter(context.Background(), snap, params)
+			want := linearFilter(snap.Records, params)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Результат индексного фильтра отличается от перебора: получено %d записей, ожидалось %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestAttendanceService_FilterRespectsScope(t *testing.T) {
+	snap := NewSnapshot(buildTestDepartments(3, 3, 5, 10), "test")
+	svc := &AttendanceService{}
+
+	curator := models.ScopeFor(models.RoleCurator, nil, []string{"11-ис", "22-ис"})
+	for _, params := range []FilterParams{
+		{MissedMin: -1, Scope: &curator},
+		{Department: "Отделение 0", MissedMin: -1, Scope: &curator},
+		{Search: "иванов", MissedMin: 1, Scope: &curator},
+	} {
+		for _, rec := range svc.Filter(context.Background(), snap, params) {
+			if rec.Group != "11-ис" && rec.Group != "22-ис" {
+				t.Fatalf("Куратор видит чужую группу %s", rec.Group)
+			}
+		}
+	}
+	if got := svc.Filter(context.Background(), snap, FilterParams{MissedMin: -1, Scope: &curator}); len(got) != 2*5*10 {
+		t.Errorf("Куратор должен видеть все записи своих групп: %d", len(got))
+	}
+
+	none := models.Scope{}
+	if got := svc.Filter(context.Background(), snap, FilterParams{MissedMin: -1, Scope: &none}); len(got) != 0 {
+		t.Errorf("Пустая область видимости не должна давать доступ: %d записей", len(got))
+	}
+
+	head := models.ScopeFor(models.RoleDepartmentHead, []string{"Отделение 1"}, nil)
+	filtered := svc.Filter(context.Background(), snap, FilterParams{MissedMin: -1, Scope: &head})
+	summary := svc.BuildSummary(context.Background(), snap, filtered, &head)
+	if summary.TotalStudents != 3*5 || len(summary.ByDepartment) != 1 {
+		t.Errorf("Сводка заведующего должна охватывать только его отделение: %+v", summary)
+	}
+	if groups := svc.BuildDrillGroups(context.Background(), snap, filtered, "Отделение 2", &head); len(groups) != 0 {
+		t.Errorf("Группы чужого отделения не должны возвращаться: %+v", groups)
+	}
+}
+
+func TestAttendanceService_ReloadSwapsOnlyOnChange(t *testing.T) {
+	path := t.TempDir() + "/attendance.json"
+	write := func(body string) {
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatalf("Ошибка записи файла: %v", err)
+		}
+	}
+
+	write(`[{"department":"Отделение 1","groups":[{"group":"11","students":[{"student":"А Б В","attendance":[{"date":"2025-09-01","missed":2}]}]}]}]`)
+	svc := NewAttendanceService(path)
+
+	first, err := svc.Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	if _, changed, _ := svc.Reload(context.Background()); changed {
+		t.Error("Снимок не должен меняться, если файл не изменился")
+	}
+
+	write(`[]`)
+	second, changed, err := svc.Reload(context.Background())
+	if err != nil || !changed {
+		t.Fatalf("Ожидалась подмена снимка, changed=%v err=%v", changed, err)
+	}
+	if second.Version == first.Version || len(second.Records) != 0 {
+		t.Errorf("Новый снимок должен отражать новый файл: %+v", second.Info())
+	}
+}
+
+func BenchmarkFilter(b *testing.B) {
+	snap := NewSnapshot(buildTestDepartments(8, 10, 25, 120), "bench")
+	svc := &AttendanceService{}
+
+	for name, params := range filterCases {
+		b.Run("linear/"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				linearFilter(snap.Records, params)
+			}
+		})
+		b.Run("indexed/"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				svc.Filter(context.Background(), snap, params)
+			}
+		})
+	}
+}
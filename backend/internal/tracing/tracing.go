@@ -0,0 +1,88 @@
+// Package tracing настраивает трассировку OpenTelemetry: экспорт спанов
+// по OTLP/HTTP в локальный коллектор. По умолчанию выключена - тогда
+// спаны ничего не стоят и никуда не отправляются.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation - имя библиотеки инструментирования в спанах
+const instrumentation = "dashboard"
+
+// Config - настройки экспорта
+type Config struct {
+	Enabled     bool
+	Endpoint    string  // host:port коллектора OTLP/HTTP, например localhost:4318
+	Insecure    bool    // без TLS (локальный коллектор)
+	SampleRatio float64 // доля трассировок, 1 - все
+	ServiceName string
+	Environment string
+}
+
+// Setup включает экспорт спанов. Возвращаемая функция отправляет
+// накопленные спаны и останавливает экспорт; её нужно вызвать при завершении.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания экспортёра OTLP: %v", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.DeploymentEnvironment(cfg.Environment),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка описания сервиса для трассировки: %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer возвращает трассировщик приложения. Он берётся из глобального
+// провайдера в момент вызова, поэтому корректен и до Setup (пустые спаны).
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// Start открывает внутренний спан
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End закрывает спан, отмечая в нём ошибку
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
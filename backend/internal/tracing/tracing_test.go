@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(prev)
+
+	// Выключенная трассировка ничего не экспортирует
+	shutdown, err := Setup(context.Background(), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown выключенной трассировки: %v", err)
+	}
+
+	// Экспортёр создаётся без подключения к коллектору
+	shutdown, err = Setup(context.Background(), Config{
+		Enabled: true, Endpoint: "localhost:4318", Insecure: true,
+		SampleRatio: 1, ServiceName: "dashboard-backend", Environment: "test",
+	})
+	if err != nil {
+		t.Fatalf("ошибка настройки экспорта: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	shutdown(ctx)
+}
+
+func TestEnd(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(prev)
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+
+	ctx, parent := Start(context.Background(), "refresh.job")
+	_, child := Start(ctx, "refresh.convert_attendance")
+	End(child, errors.New("файл повреждён"))
+	End(parent, nil)
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ожидалось 2 спана, получено %d", len(spans))
+	}
+	if spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Error("спан этапа должен быть дочерним для спана задачи")
+	}
+	if spans[0].Status().Code != codes.Error || len(spans[0].Events()) == 0 {
+		t.Errorf("ошибка этапа не отмечена в спане: %+v", spans[0].Status())
+	}
+	if spans[1].Status().Code == codes.Error {
+		t.Error("спан без ошибки отмечен как ошибочный")
+	}
+}
@@ -0,0 +1,283 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"dashboard/internal/events"
+	"dashboard/internal/logging"
+	"dashboard/internal/models"
+)
+
+var logger = logging.For("webhooks")
+
+// Заголовки запроса к подписчику
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// EventTypes - события, на которые можно подписаться.
+// Ход обновления (refresh.progress) наружу не отправляется.
+var EventTypes = []string{
+	events.RefreshStarted,
+	events.RefreshCompleted,
+	events.RefreshFailed,
+	events.SnapshotActive,
+	events.AlertCreated,
+}
+
+// Store - хранилище подписок и истории доставки
+type Store interface {
+	List() ([]models.Webhook, error)
+	Get(id int) (models.Webhook, error)
+	CreateDelivery(d models.WebhookDelivery) (models.WebhookDelivery, error)
+	UpdateDelivery(d models.WebhookDelivery) error
+	GetDelivery(id int) (models.WebhookDelivery, error)
+}
+
+// Dispatcher отправляет события брокера подписчикам.
+// Каждая доставка выполняется в своей горутине с повторами
+// и экспоненциальной задержкой между попытками.
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+
+	// MaxAttempts - сколько раз пытаться доставить событие
+	MaxAttempts int
+	// BaseDelay - задержка перед второй попыткой, дальше удваивается до MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher создаёт рассыльщик webhooks
+func NewDispatcher(store Store) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		store:       store,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts: 5,
+		BaseDelay:   2 * time.Second,
+		MaxDelay:    time.Minute,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Listen подписывается на события брокера и рассылает их до вызова Stop
+func (d *Dispatcher) Listen(b *events.Broker) {
+	allowed := make(map[string]bool, len(EventTypes))
+	for _, t := range EventTypes {
+		allowed[t] = true
+	}
+	sub := b.Subscribe(func(ev events.Event) bool { return allowed[ev.Type] }, 0)
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		for {
+			select {
+			case <-d.ctx.Done():
+				b.Unsubscribe(sub)
+				return
+			case ev, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				if err := d.Dispatch(ev); err != nil {
+					logger.Error("Ошибка рассылки события", "event", ev.Type, "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop прекращает приём событий, прерывает текущие доставки и ждёт
+// завершения горутин. Недоставленные события остаются в статусе pending,
+// их можно отправить повторно через Replay.
+func (d *Dispatcher) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// Dispatch создаёт доставки события для всех подходящих подписок
+func (d *Dispatcher) Dispatch(ev events.Event) error {
+	hooks, err := d.store.List()
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	for _, w := range hooks {
+		if !w.Enabled || !w.Matches(ev.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(ev); err != nil {
+				return fmt.Errorf("ошибка сериализации события: %v", err)
+			}
+		}
+		delivery, err := d.store.CreateDelivery(models.WebhookDelivery{
+			WebhookID: w.ID,
+			EventType: ev.Type,
+			Payload:   payload,
+		})
+		if err != nil {
+			return err
+		}
+		d.start(w, delivery)
+	}
+	return nil
+}
+
+// Replay повторно отправляет сохранённую доставку как новую
+func (d *Dispatcher) Replay(deliveryID int) (models.WebhookDelivery, error) {
+	orig, err := d.store.GetDelivery(deliveryID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	w, err := d.store.Get(orig.WebhookID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	delivery, err := d.store.CreateDelivery(models.WebhookDelivery{
+		WebhookID: w.ID,
+		EventType: orig.EventType,
+		Payload:   orig.Payload,
+		ReplayOf:  &orig.ID,
+	})
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	d.start(w, delivery)
+	return delivery, nil
+}
+
+func (d *Dispatcher) start(w models.Webhook, delivery models.WebhookDelivery) {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.deliver(w, delivery)
+	}()
+}
+
+// deliver выполняет попытки доставки до успеха, постоянной ошибки
+// (ответ 4xx, кроме 408 и 429) или исчерпания попыток
+func (d *Dispatcher) deliver(w models.Webhook, delivery models.WebhookDelivery) {
+	for delivery.Attempts < d.MaxAttempts {
+		if delivery.Attempts > 0 {
+			select {
+			case <-d.ctx.Done():
+				return
+			case <-time.After(d.backoff(delivery.Attempts)):
+			}
+		}
+
+		delivery.Attempts++
+		status, err := d.send(w, delivery)
+		delivery.ResponseStatus = status
+		delivery.Error = ""
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+
+		switch {
+		case err == nil:
+			delivery.Status = models.WebhookDelivered
+		case !retryable(status) || delivery.Attempts >= d.MaxAttempts:
+			delivery.Status = models.WebhookFailed
+			logger.Warn("Доставка не удалась", "delivery_id", delivery.ID, "webhook", w.Name, "attempts", delivery.Attempts, "error", err)
+		default:
+			delivery.Status = models.WebhookPending
+		}
+
+		if err := d.store.UpdateDelivery(delivery); err != nil {
+			logger.Warn("Не удалось сохранить доставку", "delivery_id", delivery.ID, "error", err)
+		}
+		if delivery.Status != models.WebhookPending {
+			return
+		}
+	}
+}
+
+// send выполняет одну попытку и возвращает код ответа (0 - нет ответа)
+func (d *Dispatcher) send(w models.Webhook, delivery models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, w.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dashboard-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, Sign(w.Secret, ts, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("подписчик ответил %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > d.MaxDelay {
+		delay = d.MaxDelay
+	}
+	return delay
+}
+
+// retryable - нет ответа, ответ 5xx, 408 или 429
+func retryable(status int) bool {
+	return status == 0 || status >= 500 || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests
+}
+
+// Sign вычисляет подпись тела запроса: "sha256=" + HMAC-SHA256(secret, timestamp + "." + body).
+// Подписчик проверяет её тем же способом и отбрасывает запросы со старой меткой времени.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись запроса
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// NewSecret генерирует секрет для новой подписки
+func NewSecret() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
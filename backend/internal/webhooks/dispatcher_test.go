@@ -0,0 +1,180 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"dashboard/internal/database"
+	"dashboard/internal/events"
+	"dashboard/internal/models"
+)
+
+// memoryStore - хранилище в памяти для тестов
+type memoryStore struct {
+	mu         sync.Mutex
+	hooks      []models.Webhook
+	deliveries []models.WebhookDelivery
+}
+
+func (s *memoryStore) List() ([]models.Webhook, error) { return s.hooks, nil }
+
+func (s *memoryStore) Get(id int) (models.Webhook, error) {
+	for _, w := range s.hooks {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return models.Webhook{}, database.ErrNotFound
+}
+
+func (s *memoryStore) CreateDelivery(d models.WebhookDelivery) (models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d.ID = len(s.deliveries) + 1
+	d.Status = models.WebhookPending
+	s.deliveries = append(s.deliveries, d)
+	return d, nil
+}
+
+func (s *memoryStore) UpdateDelivery(d models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries[d.ID-1] = d
+	return nil
+}
+
+func (s *memoryStore) GetDelivery(id int) (models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id <= 0 || id > len(s.deliveries) {
+		return models.WebhookDelivery{}, database.ErrNotFound
+	}
+	return s.deliveries[id-1], nil
+}
+
+func (s *memoryStore) delivery(id int) models.WebhookDelivery {
+	d, _ := s.GetDelivery(id)
+	return d
+}
+
+// receiver - локальный подписчик, отвечающий кодами из списка по очереди
+type receiver struct {
+	mu       sync.Mutex
+	codes    []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	code := http.StatusOK
+	if len(r.codes) > 0 {
+		code, r.codes = r.codes[0], r.codes[1:]
+	}
+	r.mu.Unlock()
+	w.WriteHeader(code)
+}
+
+func newTestDispatcher(store Store) *Dispatcher {
+	d := NewDispatcher(store)
+	d.MaxAttempts = 3
+	d.BaseDelay = time.Millisecond
+	return d
+}
+
+func TestDispatcher_SignsAndRetries(t *testing.T) {
+	rcv := &receiver{codes: []int{http.StatusInternalServerError, http.StatusOK}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	store := &memoryStore{hooks: []models.Webhook{
+		{ID: 1, Name: "portal", URL: srv.URL, Secret: "s3cret", Events: []string{events.AlertCreated}, Enabled: true},
+		{ID: 2, Name: "bot", URL: srv.URL, Secret: "other", Events: []string{events.RefreshCompleted}, Enabled: true},
+	}}
+	d := newTestDispatcher(store)
+
+	ev := events.Event{ID: 5, Type: events.AlertCreated, Time: time.Now(), Data: map[string]string{"group": "11-ис"}}
+	if err := d.Dispatch(ev); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	d.wg.Wait()
+
+	if len(store.deliveries) != 1 {
+		t.Fatalf("Событие должно уйти только подписчику alert.created, доставок: %d", len(store.deliveries))
+	}
+	got := store.delivery(1)
+	if got.Status != models.WebhookDelivered || got.Attempts != 2 || got.ResponseStatus != http.StatusOK {
+		t.Errorf("Ожидалась доставка со второй попытки, получено %+v", got)
+	}
+
+	req, body := rcv.requests[1], rcv.bodies[1]
+	if !Verify("s3cret", req.Header.Get(HeaderTimestamp), body, req.Header.Get(HeaderSignature)) {
+		t.Error("Подпись запроса не прошла проверку")
+	}
+	if req.Header.Get(HeaderEvent) != events.AlertCreated || req.Header.Get(HeaderDelivery) != "1" {
+		t.Errorf("Неверные заголовки: %v", req.Header)
+	}
+	var payload events.Event
+	if err := json.Unmarshal(body, &payload); err != nil || payload.ID != 5 || payload.Type != events.AlertCreated {
+		t.Errorf("Неверное тело запроса: %s", body)
+	}
+}
+
+func TestDispatcher_StopsOnClientErrorAndReplays(t *testing.T) {
+	rcv := &receiver{codes: []int{http.StatusBadRequest}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	store := &memoryStore{hooks: []models.Webhook{
+		{ID: 1, Name: "portal", URL: srv.URL, Secret: "s3cret", Enabled: true},
+	}}
+	d := newTestDispatcher(store)
+
+	if err := d.Dispatch(events.Event{ID: 1, Type: events.RefreshCompleted}); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+	d.wg.Wait()
+
+	failed := store.delivery(1)
+	if failed.Status != models.WebhookFailed || failed.Attempts != 1 {
+		t.Fatalf("Ответ 400 не должен повторяться: %+v", failed)
+	}
+
+	replay, err := d.Replay(failed.ID)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка повтора: %v", err)
+	}
+	d.wg.Wait()
+
+	got := store.delivery(replay.ID)
+	if got.Status != models.WebhookDelivered || got.ReplayOf == nil || *got.ReplayOf != failed.ID {
+		t.Errorf("Повтор должен быть доставлен и ссылаться на исходную доставку: %+v", got)
+	}
+	if string(rcv.bodies[0]) != string(rcv.bodies[1]) {
+		t.Error("Повтор должен отправлять то же тело")
+	}
+}
+
+func TestDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	rcv := &receiver{codes: []int{503, 503, 503, 200}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	store := &memoryStore{hooks: []models.Webhook{{ID: 1, Name: "portal", URL: srv.URL, Enabled: true}}}
+	d := newTestDispatcher(store)
+	d.Dispatch(events.Event{ID: 1, Type: events.RefreshFailed})
+	d.wg.Wait()
+
+	got := store.delivery(1)
+	if got.Status != models.WebhookFailed || got.Attempts != 3 {
+		t.Errorf("Ожидалось 3 неудачные попытки, получено %+v", got)
+	}
+}